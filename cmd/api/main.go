@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/velosypedno/zlagoda/internal/config"
 	"github.com/velosypedno/zlagoda/internal/ioc"
@@ -13,6 +14,9 @@ import (
 
 func main() {
 	cfg := config.Load()
+	// Timestamps taken with time.Now() are in store time
+	time.Local = cfg.STORE_LOCATION
+
 	handlerContainer, err := ioc.BuildHandlerContainer(cfg)
 	if err != nil {
 		log.Fatal("Failed to build handler container:", err)
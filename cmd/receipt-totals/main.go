@@ -0,0 +1,64 @@
+// Command receipt-totals checks every receipt's stored totals against the
+// ones derived from its sale lines, and with -repair recalculates the
+// receipts that differ.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/ioc"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/repos"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+func main() {
+	repair := flag.Bool("repair", false, "recalculate the mismatched receipts")
+	flag.Parse()
+
+	cfg := config.Load()
+	db, err := ioc.OpenDB(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer db.Close()
+
+	service := services.NewReceiptTotalsService(repos.NewReceiptRepo(db), repos.NewSaleRepo(db))
+
+	if !*repair {
+		mismatches, err := service.FindMismatches()
+		if err != nil {
+			log.Fatal("Failed to check receipt totals:", err)
+		}
+		printMismatches(mismatches)
+		fmt.Printf("%d receipts do not match their lines\n", len(mismatches))
+		if len(mismatches) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	repaired, err := service.RepairMismatches()
+	printMismatches(repaired)
+	fmt.Printf("%d receipts repaired\n", len(repaired))
+	if err != nil {
+		log.Fatal("Failed to repair receipt totals:", err)
+	}
+}
+
+func printMismatches(mismatches []models.ReceiptTotalsMismatch) {
+	for _, m := range mismatches {
+		fmt.Printf(
+			"%s\tsum_total %s -> %s\tvat %s -> %s\tdiscount_sum %s -> %s\tlines_vat %d\n",
+			m.ReceiptNumber,
+			m.Stored.TotalSum, m.Derived.TotalSum,
+			m.Stored.VAT, m.Derived.VAT,
+			m.Stored.DiscountSum, m.Derived.DiscountSum,
+			m.LinesVAT,
+		)
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE receipt
+DROP COLUMN IF EXISTS discount_sum,
+DROP COLUMN IF EXISTS discount_percent;
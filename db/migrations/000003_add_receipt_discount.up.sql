@@ -0,0 +1,3 @@
+ALTER TABLE receipt
+ADD COLUMN discount_percent INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN discount_sum DECIMAL(13,4) NOT NULL DEFAULT 0;
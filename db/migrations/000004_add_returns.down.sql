@@ -0,0 +1,3 @@
+DROP VIEW IF EXISTS sale_movement;
+DROP TABLE IF EXISTS return_item;
+DROP TABLE IF EXISTS receipt_return;
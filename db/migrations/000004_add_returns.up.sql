@@ -0,0 +1,64 @@
+CREATE TABLE receipt_return (
+    return_number VARCHAR(10) PRIMARY KEY NOT NULL,
+    receipt_number VARCHAR(10) NOT NULL,
+    employee_id VARCHAR(10) NOT NULL,
+    return_date TIMESTAMP NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    sum_total DECIMAL(13,4) NOT NULL,
+    vat DECIMAL(13,4) NOT NULL,
+    FOREIGN KEY (receipt_number)
+        REFERENCES receipt(receipt_number)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+CREATE TABLE return_item (
+    return_number VARCHAR(10) NOT NULL,
+    upc VARCHAR(12) NOT NULL,
+    receipt_number VARCHAR(10) NOT NULL,
+    product_number INTEGER NOT NULL CHECK (product_number > 0),
+    selling_price DECIMAL(13,4) NOT NULL,
+    PRIMARY KEY (return_number, upc),
+    FOREIGN KEY (return_number)
+        REFERENCES receipt_return(return_number)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (upc, receipt_number)
+        REFERENCES sale(upc, receipt_number)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX return_item_sale_idx ON return_item (receipt_number, upc);
+
+-- Every unit sold or returned, signed so that returns count as negative
+-- quantity and revenue on the day they were processed.
+CREATE VIEW sale_movement AS
+SELECT
+    s.upc,
+    s.receipt_number,
+    r.employee_id,
+    r.card_number,
+    r.print_date AS movement_date,
+    s.product_number,
+    s.selling_price,
+    s.product_number * s.selling_price AS revenue
+FROM sale s
+JOIN receipt r ON r.receipt_number = s.receipt_number
+UNION ALL
+SELECT
+    ri.upc,
+    ri.receipt_number,
+    rr.employee_id,
+    r.card_number,
+    rr.return_date AS movement_date,
+    -ri.product_number,
+    ri.selling_price,
+    -(ri.product_number * ri.selling_price) AS revenue
+FROM return_item ri
+JOIN receipt_return rr ON rr.return_number = ri.return_number
+JOIN receipt r ON r.receipt_number = ri.receipt_number;
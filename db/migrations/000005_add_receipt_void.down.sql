@@ -0,0 +1,33 @@
+DROP VIEW IF EXISTS sale_movement;
+
+CREATE VIEW sale_movement AS
+SELECT
+    s.upc,
+    s.receipt_number,
+    r.employee_id,
+    r.card_number,
+    r.print_date AS movement_date,
+    s.product_number,
+    s.selling_price,
+    s.product_number * s.selling_price AS revenue
+FROM sale s
+JOIN receipt r ON r.receipt_number = s.receipt_number
+UNION ALL
+SELECT
+    ri.upc,
+    ri.receipt_number,
+    rr.employee_id,
+    r.card_number,
+    rr.return_date AS movement_date,
+    -ri.product_number,
+    ri.selling_price,
+    -(ri.product_number * ri.selling_price) AS revenue
+FROM return_item ri
+JOIN receipt_return rr ON rr.return_number = ri.return_number
+JOIN receipt r ON r.receipt_number = ri.receipt_number;
+
+ALTER TABLE receipt
+DROP CONSTRAINT IF EXISTS receipt_voided_by_fkey,
+DROP COLUMN IF EXISTS void_reason,
+DROP COLUMN IF EXISTS voided_by,
+DROP COLUMN IF EXISTS voided_at;
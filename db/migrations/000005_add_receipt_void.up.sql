@@ -0,0 +1,37 @@
+ALTER TABLE receipt
+ADD COLUMN voided_at TIMESTAMP,
+ADD COLUMN voided_by VARCHAR(10),
+ADD COLUMN void_reason VARCHAR(255),
+ADD CONSTRAINT receipt_voided_by_fkey
+    FOREIGN KEY (voided_by)
+    REFERENCES employee(employee_id)
+    ON UPDATE CASCADE
+    ON DELETE NO ACTION;
+
+CREATE OR REPLACE VIEW sale_movement AS
+SELECT
+    s.upc,
+    s.receipt_number,
+    r.employee_id,
+    r.card_number,
+    r.print_date AS movement_date,
+    s.product_number,
+    s.selling_price,
+    s.product_number * s.selling_price AS revenue,
+    r.voided_at IS NOT NULL AS voided
+FROM sale s
+JOIN receipt r ON r.receipt_number = s.receipt_number
+UNION ALL
+SELECT
+    ri.upc,
+    ri.receipt_number,
+    rr.employee_id,
+    r.card_number,
+    rr.return_date AS movement_date,
+    -ri.product_number,
+    ri.selling_price,
+    -(ri.product_number * ri.selling_price) AS revenue,
+    r.voided_at IS NOT NULL AS voided
+FROM return_item ri
+JOIN receipt_return rr ON rr.return_number = ri.return_number
+JOIN receipt r ON r.receipt_number = ri.receipt_number;
@@ -0,0 +1,41 @@
+CREATE TABLE cart (
+    cart_id VARCHAR(10) PRIMARY KEY NOT NULL,
+    employee_id VARCHAR(10) NOT NULL,
+    card_number VARCHAR(13),
+    status VARCHAR(10) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'finalized', 'cancelled', 'expired')),
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    receipt_number VARCHAR(10),
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (card_number)
+        REFERENCES customer_card(card_number)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL,
+    FOREIGN KEY (receipt_number)
+        REFERENCES receipt(receipt_number)
+        ON UPDATE CASCADE
+        ON DELETE SET NULL
+);
+
+CREATE INDEX cart_open_expires_idx ON cart (expires_at) WHERE status = 'open';
+
+CREATE TABLE cart_item (
+    cart_id VARCHAR(10) NOT NULL,
+    upc VARCHAR(12) NOT NULL,
+    product_number INTEGER NOT NULL CHECK (product_number > 0),
+    added_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (cart_id, upc),
+    FOREIGN KEY (cart_id)
+        REFERENCES cart(cart_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (upc)
+        REFERENCES store_product(upc)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
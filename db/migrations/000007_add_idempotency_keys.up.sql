@@ -0,0 +1,18 @@
+CREATE TABLE idempotency_key (
+    idempotency_key VARCHAR(255) NOT NULL,
+    employee_id VARCHAR(10) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    request_hash CHAR(64) NOT NULL,
+    status_code INTEGER,
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (employee_id, idempotency_key),
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX idempotency_key_expires_idx ON idempotency_key (expires_at);
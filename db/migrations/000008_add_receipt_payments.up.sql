@@ -0,0 +1,14 @@
+CREATE TABLE receipt_payment (
+    payment_id SERIAL PRIMARY KEY,
+    receipt_number VARCHAR(10) NOT NULL,
+    tender VARCHAR(10) NOT NULL CHECK (tender IN ('cash', 'card', 'voucher')),
+    amount DECIMAL(13,4) NOT NULL CHECK (amount >= 0),
+    tendered DECIMAL(13,4) NOT NULL CHECK (tendered >= amount),
+    reference VARCHAR(50),
+    FOREIGN KEY (receipt_number)
+        REFERENCES receipt(receipt_number)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX receipt_payment_receipt_idx ON receipt_payment (receipt_number);
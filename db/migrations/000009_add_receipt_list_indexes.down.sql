@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS receipt_active_print_date_idx;
+DROP INDEX IF EXISTS receipt_voided_at_sort_idx;
+DROP INDEX IF EXISTS receipt_card_sort_idx;
+DROP INDEX IF EXISTS receipt_card_print_date_idx;
+DROP INDEX IF EXISTS receipt_employee_print_date_idx;
+DROP INDEX IF EXISTS receipt_sum_total_idx;
+DROP INDEX IF EXISTS receipt_print_date_idx;
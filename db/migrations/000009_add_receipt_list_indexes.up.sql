@@ -0,0 +1,7 @@
+CREATE INDEX receipt_print_date_idx ON receipt (print_date, receipt_number);
+CREATE INDEX receipt_sum_total_idx ON receipt (sum_total, receipt_number);
+CREATE INDEX receipt_employee_print_date_idx ON receipt (employee_id, print_date, receipt_number);
+CREATE INDEX receipt_card_print_date_idx ON receipt (card_number, print_date, receipt_number);
+CREATE INDEX receipt_card_sort_idx ON receipt ((COALESCE(card_number, '')), receipt_number);
+CREATE INDEX receipt_voided_at_sort_idx ON receipt ((COALESCE(voided_at, '-infinity'::timestamp)), receipt_number);
+CREATE INDEX receipt_active_print_date_idx ON receipt (print_date, receipt_number) WHERE voided_at IS NULL;
@@ -0,0 +1,11 @@
+DROP VIEW IF EXISTS receipt_vat;
+
+ALTER TABLE sale
+DROP COLUMN IF EXISTS vat_sum,
+DROP COLUMN IF EXISTS vat_rate;
+
+ALTER TABLE product
+DROP COLUMN IF EXISTS vat_rate;
+
+ALTER TABLE category
+DROP COLUMN IF EXISTS vat_rate;
@@ -0,0 +1,36 @@
+ALTER TABLE category
+ADD COLUMN vat_rate DECIMAL(5,4) CHECK (vat_rate >= 0 AND vat_rate < 1);
+
+ALTER TABLE product
+ADD COLUMN vat_rate DECIMAL(5,4) CHECK (vat_rate >= 0 AND vat_rate < 1);
+
+ALTER TABLE sale
+ADD COLUMN vat_rate DECIMAL(5,4),
+ADD COLUMN vat_sum DECIMAL(13,4);
+
+-- Receipts written so far were taxed at one rate on the discounted total
+UPDATE sale s
+SET
+    vat_rate = CASE WHEN r.sum_total > 0 THEN ROUND(r.vat / r.sum_total, 4) ELSE 0 END
+FROM receipt r
+WHERE r.receipt_number = s.receipt_number;
+
+UPDATE sale s
+SET
+    vat_sum = ROUND(s.product_number * s.selling_price * (100 - r.discount_percent) / 100 * s.vat_rate, 4)
+FROM receipt r
+WHERE r.receipt_number = s.receipt_number;
+
+ALTER TABLE sale
+ALTER COLUMN vat_rate SET NOT NULL,
+ALTER COLUMN vat_sum SET NOT NULL;
+
+CREATE VIEW receipt_vat AS
+SELECT
+    s.receipt_number,
+    s.vat_rate,
+    SUM(s.product_number * s.selling_price * (100 - r.discount_percent) / 100) AS taxable_sum,
+    SUM(s.vat_sum) AS vat_sum
+FROM sale s
+JOIN receipt r ON r.receipt_number = s.receipt_number
+GROUP BY s.receipt_number, s.vat_rate;
@@ -0,0 +1,9 @@
+CREATE OR REPLACE VIEW receipt_vat AS
+SELECT
+    s.receipt_number,
+    s.vat_rate,
+    SUM(s.product_number * s.selling_price * (100 - r.discount_percent) / 100) AS taxable_sum,
+    SUM(s.vat_sum) AS vat_sum
+FROM sale s
+JOIN receipt r ON r.receipt_number = s.receipt_number
+GROUP BY s.receipt_number, s.vat_rate;
@@ -0,0 +1,10 @@
+-- Receipt level VAT amounts are rounded to kopecks per rate
+CREATE OR REPLACE VIEW receipt_vat AS
+SELECT
+    s.receipt_number,
+    s.vat_rate,
+    ROUND(SUM(s.product_number * s.selling_price * (100 - r.discount_percent) / 100), 2) AS taxable_sum,
+    ROUND(SUM(s.vat_sum), 2) AS vat_sum
+FROM sale s
+JOIN receipt r ON r.receipt_number = s.receipt_number
+GROUP BY s.receipt_number, s.vat_rate;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS receipt_return_shift_idx;
+DROP INDEX IF EXISTS receipt_shift_idx;
+
+ALTER TABLE receipt_return
+DROP COLUMN IF EXISTS shift_id;
+
+ALTER TABLE receipt
+DROP COLUMN IF EXISTS shift_id;
+
+DROP TABLE IF EXISTS shift;
@@ -0,0 +1,42 @@
+CREATE TABLE shift (
+    shift_id SERIAL PRIMARY KEY,
+    register_id VARCHAR(10) NOT NULL,
+    employee_id VARCHAR(10) NOT NULL,
+    opened_at TIMESTAMP NOT NULL,
+    opening_float DECIMAL(13,4) NOT NULL CHECK (opening_float >= 0),
+    closed_at TIMESTAMP,
+    closed_by VARCHAR(10),
+    CHECK (closed_at IS NULL OR closed_at >= opened_at),
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (closed_by)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+-- A register and a cashier can each have only one open shift
+CREATE UNIQUE INDEX shift_open_register_idx ON shift (register_id) WHERE closed_at IS NULL;
+CREATE UNIQUE INDEX shift_open_employee_idx ON shift (employee_id) WHERE closed_at IS NULL;
+
+-- Receipts and returns written before shifts existed keep a NULL shift
+ALTER TABLE receipt
+ADD COLUMN shift_id INTEGER,
+ADD CONSTRAINT receipt_shift_id_fkey
+    FOREIGN KEY (shift_id)
+    REFERENCES shift(shift_id)
+    ON UPDATE CASCADE
+    ON DELETE NO ACTION;
+
+ALTER TABLE receipt_return
+ADD COLUMN shift_id INTEGER,
+ADD CONSTRAINT receipt_return_shift_id_fkey
+    FOREIGN KEY (shift_id)
+    REFERENCES shift(shift_id)
+    ON UPDATE CASCADE
+    ON DELETE NO ACTION;
+
+CREATE INDEX receipt_shift_idx ON receipt (shift_id);
+CREATE INDEX receipt_return_shift_idx ON receipt_return (shift_id);
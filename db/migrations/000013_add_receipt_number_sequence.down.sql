@@ -0,0 +1 @@
+DROP TABLE IF EXISTS receipt_number_sequence;
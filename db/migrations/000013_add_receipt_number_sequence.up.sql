@@ -0,0 +1,6 @@
+-- Last receipt number issued on each register. The row is updated inside the
+-- checkout transaction, so a rolled back receipt gives its number back.
+CREATE TABLE receipt_number_sequence (
+    register_id VARCHAR(10) PRIMARY KEY,
+    last_number INTEGER NOT NULL CHECK (last_number > 0)
+);
@@ -0,0 +1,58 @@
+-- Timestamps are stored as instants. Rows written before this migration hold
+-- UTC wall-clock times, which is what the API container and the browser's
+-- toISOString() produced.
+DROP VIEW sale_movement;
+DROP INDEX receipt_voided_at_sort_idx;
+
+ALTER TABLE receipt
+ALTER COLUMN print_date TYPE TIMESTAMPTZ USING print_date AT TIME ZONE 'UTC',
+ALTER COLUMN voided_at TYPE TIMESTAMPTZ USING voided_at AT TIME ZONE 'UTC';
+
+ALTER TABLE receipt_return
+ALTER COLUMN return_date TYPE TIMESTAMPTZ USING return_date AT TIME ZONE 'UTC';
+
+ALTER TABLE cart
+ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+ALTER COLUMN updated_at TYPE TIMESTAMPTZ USING updated_at AT TIME ZONE 'UTC',
+ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC';
+
+ALTER TABLE cart_item
+ALTER COLUMN added_at TYPE TIMESTAMPTZ USING added_at AT TIME ZONE 'UTC';
+
+ALTER TABLE idempotency_key
+ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE 'UTC',
+ALTER COLUMN expires_at TYPE TIMESTAMPTZ USING expires_at AT TIME ZONE 'UTC';
+
+ALTER TABLE shift
+ALTER COLUMN opened_at TYPE TIMESTAMPTZ USING opened_at AT TIME ZONE 'UTC',
+ALTER COLUMN closed_at TYPE TIMESTAMPTZ USING closed_at AT TIME ZONE 'UTC';
+
+CREATE INDEX receipt_voided_at_sort_idx ON receipt ((COALESCE(voided_at, '-infinity'::timestamptz)), receipt_number);
+
+CREATE VIEW sale_movement AS
+SELECT
+    s.upc,
+    s.receipt_number,
+    r.employee_id,
+    r.card_number,
+    r.print_date AS movement_date,
+    s.product_number,
+    s.selling_price,
+    s.product_number * s.selling_price AS revenue,
+    r.voided_at IS NOT NULL AS voided
+FROM sale s
+JOIN receipt r ON r.receipt_number = s.receipt_number
+UNION ALL
+SELECT
+    ri.upc,
+    ri.receipt_number,
+    rr.employee_id,
+    r.card_number,
+    rr.return_date AS movement_date,
+    -ri.product_number,
+    ri.selling_price,
+    -(ri.product_number * ri.selling_price) AS revenue,
+    r.voided_at IS NOT NULL AS voided
+FROM return_item ri
+JOIN receipt_return rr ON rr.return_number = ri.return_number
+JOIN receipt r ON r.receipt_number = ri.receipt_number;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS receipt_sync_conflict;
+DROP TABLE IF EXISTS receipt_sync;
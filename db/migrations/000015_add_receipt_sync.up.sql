@@ -0,0 +1,24 @@
+-- Receipts uploaded by tills that sold while offline, keyed by the ID the
+-- till generated, so that a batch can be sent again without double selling
+CREATE TABLE receipt_sync (
+    client_id VARCHAR(64) PRIMARY KEY,
+    receipt_number VARCHAR(10) NOT NULL UNIQUE,
+    synced_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (receipt_number)
+        REFERENCES receipt(receipt_number)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+-- UPCs an offline receipt sold beyond the stock on hand when it was synced
+CREATE TABLE receipt_sync_conflict (
+    client_id VARCHAR(64) NOT NULL,
+    upc VARCHAR(12) NOT NULL,
+    requested INTEGER NOT NULL,
+    available INTEGER NOT NULL,
+    PRIMARY KEY (client_id, upc),
+    FOREIGN KEY (client_id)
+        REFERENCES receipt_sync(client_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS stock_movement;
+DROP FUNCTION IF EXISTS stock_movement_append_only();
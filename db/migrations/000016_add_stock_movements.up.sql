@@ -0,0 +1,35 @@
+-- Append-only ledger of every change to store_product.products_number.
+-- balance is products_number right after the movement. upc has no foreign
+-- key, so the history of a deleted store product is kept.
+CREATE TABLE stock_movement (
+    movement_id BIGSERIAL PRIMARY KEY,
+    upc VARCHAR(12) NOT NULL,
+    moved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('opening', 'sale', 'return', 'void', 'delivery', 'adjustment', 'promo_transfer')),
+    delta INTEGER NOT NULL,
+    balance INTEGER NOT NULL,
+    employee_id VARCHAR(10),
+    reason VARCHAR(255),
+    reference VARCHAR(50),
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX stock_movement_upc_idx ON stock_movement (upc, movement_id);
+
+CREATE FUNCTION stock_movement_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movement is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movement_append_only
+BEFORE UPDATE OR DELETE ON stock_movement
+FOR EACH ROW EXECUTE FUNCTION stock_movement_append_only();
+
+-- The stock on hand before the ledger existed opens every UPC's history
+INSERT INTO stock_movement (upc, kind, delta, balance, reason)
+SELECT upc, 'opening', products_number, products_number, 'Stock before the ledger'
+FROM store_product;
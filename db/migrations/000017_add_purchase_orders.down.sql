@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS purchase_order_delivery;
+DROP TABLE IF EXISTS purchase_order_line;
+DROP TABLE IF EXISTS purchase_order;
+DROP TABLE IF EXISTS supplier;
@@ -0,0 +1,72 @@
+CREATE TABLE supplier (
+    supplier_id SERIAL PRIMARY KEY,
+    supplier_name VARCHAR(100) NOT NULL,
+    contact_name VARCHAR(100),
+    phone_number VARCHAR(13),
+    email VARCHAR(100),
+    city VARCHAR(50),
+    street VARCHAR(50),
+    zip_code VARCHAR(9)
+);
+
+CREATE TABLE purchase_order (
+    order_id SERIAL PRIMARY KEY,
+    supplier_id INTEGER NOT NULL,
+    employee_id VARCHAR(10) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft'
+        CHECK (status IN ('draft', 'sent', 'partially_received', 'received', 'cancelled')),
+    note VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL,
+    sent_at TIMESTAMPTZ,
+    FOREIGN KEY (supplier_id)
+        REFERENCES supplier(supplier_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX purchase_order_supplier_idx ON purchase_order (supplier_id);
+
+-- expected_cost is the agreed cost of one unit
+CREATE TABLE purchase_order_line (
+    order_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    expected_cost DECIMAL(13,4) NOT NULL CHECK (expected_cost >= 0),
+    received_quantity INTEGER NOT NULL DEFAULT 0
+        CHECK (received_quantity >= 0 AND received_quantity <= quantity),
+    PRIMARY KEY (order_id, product_id),
+    FOREIGN KEY (order_id)
+        REFERENCES purchase_order(order_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (product_id)
+        REFERENCES product(product_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+-- Every receiving against an order, by the UPC the units were booked to
+CREATE TABLE purchase_order_delivery (
+    delivery_id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL,
+    product_id INTEGER NOT NULL,
+    upc VARCHAR(12) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    received_at TIMESTAMPTZ NOT NULL,
+    employee_id VARCHAR(10) NOT NULL,
+    FOREIGN KEY (order_id, product_id)
+        REFERENCES purchase_order_line(order_id, product_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX purchase_order_delivery_order_idx ON purchase_order_delivery (order_id);
@@ -0,0 +1,55 @@
+-- A supplier's delivery booked into stock in one go. A reversed document
+-- keeps its lines; its stock is taken back out and its prices restored.
+CREATE TABLE goods_received (
+    document_id SERIAL PRIMARY KEY,
+    supplier_id INTEGER NOT NULL,
+    invoice_number VARCHAR(50) NOT NULL,
+    invoice_date DATE NOT NULL,
+    employee_id VARCHAR(10) NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'posted'
+        CHECK (status IN ('posted', 'reversed')),
+    posted_at TIMESTAMPTZ NOT NULL,
+    reversed_at TIMESTAMPTZ,
+    reversed_by VARCHAR(10),
+    reversal_reason VARCHAR(255),
+    CHECK ((status = 'reversed') = (reversed_at IS NOT NULL)),
+    FOREIGN KEY (supplier_id)
+        REFERENCES supplier(supplier_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (reversed_by)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+-- An invoice can only be booked once unless its document was reversed
+CREATE UNIQUE INDEX goods_received_invoice_idx ON goods_received (supplier_id, invoice_number) WHERE status = 'posted';
+CREATE INDEX goods_received_invoice_date_idx ON goods_received (invoice_date);
+
+-- previous_price is the selling price before the line, restored on reversal
+-- when the line set a new_price
+CREATE TABLE goods_received_line (
+    document_id INTEGER NOT NULL,
+    line_no INTEGER NOT NULL,
+    upc VARCHAR(12) NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    cost_price DECIMAL(13,4) NOT NULL CHECK (cost_price >= 0),
+    new_price DECIMAL(13,4) CHECK (new_price >= 0),
+    previous_price DECIMAL(13,4) NOT NULL,
+    PRIMARY KEY (document_id, line_no),
+    UNIQUE (document_id, upc),
+    FOREIGN KEY (document_id)
+        REFERENCES goods_received(document_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (product_id)
+        REFERENCES product(product_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
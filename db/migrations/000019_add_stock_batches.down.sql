@@ -0,0 +1,2 @@
+ALTER TABLE goods_received_line DROP COLUMN IF EXISTS expiry_date;
+DROP TABLE IF EXISTS stock_batch;
@@ -0,0 +1,27 @@
+-- Units of a UPC on hand, by the delivery they came with. Stock increases
+-- open a batch; decreases take units from the batch that expires first.
+-- expiry_date is NULL when it is unknown, such as for returned units.
+CREATE TABLE stock_batch (
+    batch_id SERIAL PRIMARY KEY,
+    upc VARCHAR(12) NOT NULL,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expiry_date DATE,
+    initial_quantity INTEGER NOT NULL CHECK (initial_quantity > 0),
+    quantity INTEGER NOT NULL CHECK (quantity >= 0 AND quantity <= initial_quantity),
+    reference VARCHAR(50),
+    FOREIGN KEY (upc)
+        REFERENCES store_product(upc)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
+
+CREATE INDEX stock_batch_upc_idx ON stock_batch (upc, expiry_date) WHERE quantity > 0;
+CREATE INDEX stock_batch_expiry_idx ON stock_batch (expiry_date) WHERE quantity > 0;
+
+ALTER TABLE goods_received_line ADD COLUMN expiry_date DATE;
+
+-- The stock on hand before batches existed is one batch of unknown expiry
+INSERT INTO stock_batch (upc, initial_quantity, quantity, reference)
+SELECT upc, products_number, products_number, 'Stock before batches'
+FROM store_product
+WHERE products_number > 0;
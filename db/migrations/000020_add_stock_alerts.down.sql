@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS stock_alert;
+
+ALTER TABLE product
+DROP CONSTRAINT IF EXISTS product_reorder_levels_check,
+DROP COLUMN IF EXISTS reorder_quantity,
+DROP COLUMN IF EXISTS min_stock;
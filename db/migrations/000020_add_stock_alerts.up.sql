@@ -0,0 +1,40 @@
+-- A product with a min_stock should keep at least that many units on each
+-- of its UPCs; reorder_quantity is how many to order when it does not
+ALTER TABLE product
+ADD COLUMN min_stock INTEGER CHECK (min_stock >= 0),
+ADD COLUMN reorder_quantity INTEGER CHECK (reorder_quantity > 0),
+ADD CONSTRAINT product_reorder_levels_check
+    CHECK ((min_stock IS NULL) = (reorder_quantity IS NULL));
+
+-- Raised when a sale or adjustment takes a UPC below its product's
+-- min_stock. stock is the UPC's stock right after movement_id. An alert stays
+-- open until it is resolved by hand or the stock is back at min_stock.
+CREATE TABLE stock_alert (
+    alert_id SERIAL PRIMARY KEY,
+    upc VARCHAR(12) NOT NULL,
+    movement_id BIGINT NOT NULL,
+    stock INTEGER NOT NULL,
+    min_stock INTEGER NOT NULL,
+    reorder_quantity INTEGER NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'resolved')),
+    resolved_at TIMESTAMPTZ,
+    resolved_by VARCHAR(10),
+    CHECK ((status = 'resolved') = (resolved_at IS NOT NULL)),
+    FOREIGN KEY (upc)
+        REFERENCES store_product(upc)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (movement_id)
+        REFERENCES stock_movement(movement_id)
+        ON DELETE NO ACTION,
+    FOREIGN KEY (resolved_by)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+-- A UPC has at most one open alert
+CREATE UNIQUE INDEX stock_alert_open_upc_idx ON stock_alert (upc) WHERE status = 'open';
+CREATE INDEX stock_alert_created_idx ON stock_alert (created_at);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS stocktake_line;
+DROP TABLE IF EXISTS stocktake_count;
+DROP TABLE IF EXISTS stocktake;
@@ -0,0 +1,74 @@
+-- A count of the shelf stock of one category, or of every store product when
+-- category_id is NULL. Counts are added while it is open; approving it
+-- adjusts each counted UPC to its count and freezes the result in
+-- stocktake_line.
+CREATE TABLE stocktake (
+    stocktake_id SERIAL PRIMARY KEY,
+    category_id INTEGER,
+    note VARCHAR(255),
+    status VARCHAR(10) NOT NULL DEFAULT 'open'
+        CHECK (status IN ('open', 'approved', 'cancelled')),
+    opened_by VARCHAR(10) NOT NULL,
+    opened_at TIMESTAMPTZ NOT NULL,
+    closed_by VARCHAR(10),
+    closed_at TIMESTAMPTZ,
+    CHECK ((status = 'open') = (closed_at IS NULL)),
+    FOREIGN KEY (category_id)
+        REFERENCES category(category_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (opened_by)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION,
+    FOREIGN KEY (closed_by)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX stocktake_opened_idx ON stocktake (opened_at);
+
+-- One count of a UPC. The counts of a UPC add up, so that the shelf and the
+-- back room can be counted apart.
+CREATE TABLE stocktake_count (
+    count_id SERIAL PRIMARY KEY,
+    stocktake_id INTEGER NOT NULL,
+    upc VARCHAR(12) NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity >= 0),
+    location VARCHAR(50),
+    employee_id VARCHAR(10) NOT NULL,
+    counted_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (stocktake_id)
+        REFERENCES stocktake(stocktake_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX stocktake_count_upc_idx ON stocktake_count (stocktake_id, upc);
+
+-- Written on approval: the merged count of each counted UPC against its
+-- stock at that moment. variance is counted - system_stock, the adjustment
+-- that was posted.
+CREATE TABLE stocktake_line (
+    stocktake_id INTEGER NOT NULL,
+    upc VARCHAR(12) NOT NULL,
+    product_id INTEGER NOT NULL,
+    counted INTEGER NOT NULL,
+    system_stock INTEGER NOT NULL,
+    variance INTEGER NOT NULL,
+    PRIMARY KEY (stocktake_id, upc),
+    CHECK (variance = counted - system_stock),
+    FOREIGN KEY (stocktake_id)
+        REFERENCES stocktake(stocktake_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (product_id)
+        REFERENCES product(product_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS write_off_line;
+DROP TABLE IF EXISTS write_off;
+
+-- The ledger is append-only, so write-off movements stay and the old check
+-- is not validated against them
+ALTER TABLE stock_movement
+DROP CONSTRAINT IF EXISTS stock_movement_kind_check,
+ADD CONSTRAINT stock_movement_kind_check
+    CHECK (kind IN ('opening', 'sale', 'return', 'void', 'delivery', 'adjustment', 'promo_transfer')) NOT VALID;
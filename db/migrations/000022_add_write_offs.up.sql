@@ -0,0 +1,40 @@
+ALTER TABLE stock_movement
+DROP CONSTRAINT stock_movement_kind_check,
+ADD CONSTRAINT stock_movement_kind_check
+    CHECK (kind IN ('opening', 'sale', 'return', 'void', 'delivery', 'adjustment', 'promo_transfer', 'write_off'));
+
+-- Goods taken out of stock without being sold, for one reason. Each line is
+-- valued at the UPC's selling price when it was written off.
+CREATE TABLE write_off (
+    write_off_id SERIAL PRIMARY KEY,
+    reason VARCHAR(20) NOT NULL
+        CHECK (reason IN ('expired', 'damaged', 'theft', 'internal_use')),
+    note VARCHAR(255),
+    employee_id VARCHAR(10) NOT NULL,
+    written_off_at TIMESTAMPTZ NOT NULL,
+    FOREIGN KEY (employee_id)
+        REFERENCES employee(employee_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
+
+CREATE INDEX write_off_written_off_idx ON write_off (written_off_at);
+
+CREATE TABLE write_off_line (
+    write_off_id INTEGER NOT NULL,
+    line_no INTEGER NOT NULL,
+    upc VARCHAR(12) NOT NULL,
+    product_id INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(13,4) NOT NULL CHECK (unit_price >= 0),
+    PRIMARY KEY (write_off_id, line_no),
+    UNIQUE (write_off_id, upc),
+    FOREIGN KEY (write_off_id)
+        REFERENCES write_off(write_off_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE,
+    FOREIGN KEY (product_id)
+        REFERENCES product(product_id)
+        ON UPDATE CASCADE
+        ON DELETE NO ACTION
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS receipt_sync_tender_mismatch;
@@ -0,0 +1,11 @@
+-- Offline receipts whose payments did not match the total at the prices
+-- they were synced at; the payments were recorded as the till took them
+CREATE TABLE receipt_sync_tender_mismatch (
+    client_id VARCHAR(64) PRIMARY KEY,
+    due DECIMAL(13,4) NOT NULL,
+    paid DECIMAL(13,4) NOT NULL,
+    FOREIGN KEY (client_id)
+        REFERENCES receipt_sync(client_id)
+        ON UPDATE CASCADE
+        ON DELETE CASCADE
+);
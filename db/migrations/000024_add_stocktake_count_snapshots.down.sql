@@ -0,0 +1 @@
+ALTER TABLE stocktake_count DROP COLUMN IF EXISTS system_stock;
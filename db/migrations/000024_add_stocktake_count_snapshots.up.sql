@@ -0,0 +1,13 @@
+-- The stock of the UPC when it was counted. Approval posts the count against
+-- this snapshot, so sales and deliveries made between the count and the
+-- approval are not adjusted away. Counts made before this column existed
+-- take the stock as it is now.
+ALTER TABLE stocktake_count ADD COLUMN system_stock INTEGER;
+
+UPDATE stocktake_count c
+SET system_stock = COALESCE(
+    (SELECT sp.products_number FROM store_product sp WHERE sp.upc = c.upc),
+    0
+);
+
+ALTER TABLE stocktake_count ALTER COLUMN system_stock SET NOT NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE stock_movement
+DROP CONSTRAINT stock_movement_employee_id_fkey,
+ADD CONSTRAINT stock_movement_employee_id_fkey
+    FOREIGN KEY (employee_id)
+    REFERENCES employee(employee_id)
+    ON UPDATE CASCADE
+    ON DELETE NO ACTION;
@@ -0,0 +1,10 @@
+-- stock_movement is append-only, so an employee ID change cannot cascade into
+-- it: the trigger would reject the update. Refuse the change on the employee
+-- instead, with a foreign key error that names the ledger.
+ALTER TABLE stock_movement
+DROP CONSTRAINT stock_movement_employee_id_fkey,
+ADD CONSTRAINT stock_movement_employee_id_fkey
+    FOREIGN KEY (employee_id)
+    REFERENCES employee(employee_id)
+    ON UPDATE RESTRICT
+    ON DELETE NO ACTION;
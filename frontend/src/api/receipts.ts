@@ -3,6 +3,8 @@ import type {
   ReceiptCreate,
   ReceiptCreateComplete,
   ReceiptCreateResponse,
+  ReceiptList,
+  ReceiptListParams,
   ReceiptRetrieve,
   ReceiptUpdate
 } from "../types/receipt";
@@ -11,11 +13,16 @@ import type { SaleWithDetails } from "../types/sale";
 export const createReceipt = (receipt: ReceiptCreate) =>
   axios.post<{ id: string }>("/api/receipts", receipt);
 
-export const createReceiptComplete = (receipt: ReceiptCreateComplete) =>
-  axios.post<{ id: string }>("/api/receipts/complete", receipt);
+export const createReceiptComplete = (
+  receipt: ReceiptCreateComplete,
+  idempotencyKey?: string,
+) =>
+  axios.post<{ id: string; change: number }>("/api/receipts/complete", receipt, {
+    headers: idempotencyKey ? { "Idempotency-Key": idempotencyKey } : {},
+  });
 
-export const fetchReceipts = () =>
-  axios.get<ReceiptRetrieve[]>("/api/receipts");
+export const fetchReceipts = (params: ReceiptListParams = {}) =>
+  axios.get<ReceiptList>("/api/receipts", { params });
 
 export const fetchReceiptDetails = (receiptNumber: string) =>
   axios.get<ReceiptRetrieve>(`/api/receipts/${receiptNumber}`);
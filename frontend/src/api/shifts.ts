@@ -0,0 +1,10 @@
+import axios from "./axios";
+import type { Shift, ShiftOpen } from "../types/shift";
+
+export const fetchCurrentShift = () => axios.get<Shift>("/api/shifts/current");
+
+export const openShift = (shift: ShiftOpen) =>
+  axios.post<Shift>("/api/shifts", shift);
+
+export const closeShift = (shiftId: number) =>
+  axios.post(`/api/shifts/${shiftId}/close`);
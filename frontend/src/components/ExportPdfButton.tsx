@@ -4,6 +4,9 @@ interface ExportPdfButtonProps {
   entityType: string;
   entityId?: string | number;
   apiEndpoint: string;
+  // Property of the response that holds the rows, for endpoints that wrap
+  // them in an envelope such as { receipts, next }.
+  dataKey?: string;
   title?: string;
   columns: Array<{
     key: string;
@@ -19,6 +22,7 @@ const ExportPdfButton: React.FC<ExportPdfButtonProps> = ({
   entityType,
   entityId,
   apiEndpoint,
+  dataKey,
   title,
   columns,
   className = "bg-green-500 text-white px-4 py-2 rounded hover:bg-green-600 transition",
@@ -42,7 +46,8 @@ const ExportPdfButton: React.FC<ExportPdfButtonProps> = ({
       throw new Error(`Failed to fetch ${entityType} data`);
     }
 
-    return response.json();
+    const body = await response.json();
+    return dataKey ? (body as Record<string, unknown>)[dataKey] : body;
   };
 
   const formatValue = (value: unknown): string => {
@@ -1,17 +1,21 @@
 import { useState, useEffect, useRef } from "react";
 import { createReceiptComplete } from "../api/receipts";
-import type { ReceiptCreateComplete } from "../types/receipt";
+import type { ReceiptCreateComplete, Tender } from "../types/receipt";
 import type { StoreProductWithDetails } from "../types/store_product";
 import type { Product } from "../types/product";
 import type { CustomerCard } from "../types/customer_card";
 import { fetchStoreProductsWithDetails } from "../api/store_products";
 import { fetchProducts } from "../api/products";
 import { getCustomerCards } from "../api/customer_cards";
+import { closeShift, fetchCurrentShift, openShift } from "../api/shifts";
+import type { Shift } from "../types/shift";
 import { useAuth } from "../contexts/AuthContext";
 
 const CreateReceipt = () => {
   const { user } = useAuth();
   const [cardNumber, setCardNumber] = useState<string | undefined>(undefined);
+  const [tender, setTender] = useState<Tender>("cash");
+  const [cashReceived, setCashReceived] = useState<string>("");
   const [items, setItems] = useState<{ upc: string; product_number: number }[]>(
     [],
   );
@@ -20,17 +24,62 @@ const CreateReceipt = () => {
   const [customerCards, setCustomerCards] = useState<CustomerCard[]>([]);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
-  const [success, setSuccess] = useState<{ receipt_number: string } | null>(
-    null,
-  );
+  const [success, setSuccess] = useState<{
+    receipt_number: string;
+    change: number;
+  } | null>(null);
+  const [shift, setShift] = useState<Shift | null>(null);
+  const [registerId, setRegisterId] = useState("");
+  const [openingFloat, setOpeningFloat] = useState("");
   const itemRefs = useRef<(HTMLSelectElement | null)[]>([]);
+  // Retrying the same receipt resends the same key and print date, so the
+  // server creates it only once. Editing the receipt starts a new attempt.
+  const attempt = useRef<{ key: string; printDate: string | null }>({
+    key: crypto.randomUUID(),
+    printDate: null,
+  });
+
+  useEffect(() => {
+    attempt.current = { key: crypto.randomUUID(), printDate: null };
+  }, [items, cardNumber, tender, cashReceived]);
 
   useEffect(() => {
     fetchStoreProductsWithDetails().then((res) => setProducts(res.data || []));
     fetchProducts().then((res) => setAllProducts(res.data || []));
     getCustomerCards().then(setCustomerCards);
+    fetchCurrentShift()
+      .then((res) => setShift(res.data))
+      .catch(() => setShift(null));
   }, []);
 
+  const handleOpenShift = async () => {
+    setError(null);
+    try {
+      const res = await openShift({
+        register_id: registerId,
+        opening_float: parseFloat(openingFloat) || 0,
+      });
+      setShift(res.data);
+    } catch (err: unknown) {
+      setError(
+        `Error: ${(err as any)?.response?.data?.error || "Failed to open shift"}`,
+      );
+    }
+  };
+
+  const handleCloseShift = async () => {
+    if (!shift) return;
+    setError(null);
+    try {
+      await closeShift(shift.shift_id);
+      setShift(null);
+    } catch (err: unknown) {
+      setError(
+        `Error: ${(err as any)?.response?.data?.error || "Failed to close shift"}`,
+      );
+    }
+  };
+
   const addItem = () => {
     if (items.length > 0) {
       const last = items[items.length - 1];
@@ -57,13 +106,13 @@ const CreateReceipt = () => {
     setItems(items.filter((_, i) => i !== idx));
   };
 
-  // Calculate price for an item
+  // Calculate price for an item. A promotional UPC already carries its
+  // discounted selling price, the same one the server charges.
   const getItemPrice = (upc: string, quantity: number) => {
     const product = products.find((p) => p.upc === upc);
     if (!product) return { price: 0, isPromo: false, total: 0 };
-    let price = product.selling_price;
+    const price = product.selling_price;
     const isPromo = product.promotional_product;
-    if (isPromo) price = price * 0.8;
     return { price, isPromo, total: price * quantity };
   };
 
@@ -91,9 +140,21 @@ const CreateReceipt = () => {
       setLoading(false);
       return;
     }
+    const tendered =
+      tender === "cash" && cashReceived !== ""
+        ? parseFloat(cashReceived)
+        : roundedTotal;
+    if (isNaN(tendered) || tendered < roundedTotal) {
+      setError("Cash received does not cover the total.");
+      setLoading(false);
+      return;
+    }
     try {
-      const now = new Date();
-      const print_date = now.toISOString().slice(0, 19).replace("T", " ");
+      if (!attempt.current.printDate) {
+        const now = new Date();
+        attempt.current.printDate = now.toISOString();
+      }
+      const print_date = attempt.current.printDate;
       const receipt: ReceiptCreateComplete = {
         employee_id: user.employee_id,
         card_number: cardNumber || null,
@@ -106,12 +167,14 @@ const CreateReceipt = () => {
             selling_price: price,
           };
         }),
+        payments: [{ tender, tendered }],
       };
       console.log("Sending receipt data:", JSON.stringify(receipt, null, 2));
-      const res = await createReceiptComplete(receipt);
-      setSuccess({ receipt_number: res.data.id });
+      const res = await createReceiptComplete(receipt, attempt.current.key);
+      setSuccess({ receipt_number: res.data.id, change: res.data.change });
       setItems([]);
       setCardNumber(undefined);
+      setCashReceived("");
     } catch (err: unknown) {
       console.error("Receipt creation error:", err);
       console.error("Error response:", (err as any)?.response);
@@ -139,6 +202,7 @@ const CreateReceipt = () => {
   const discountPercent = selectedCard ? selectedCard.percent : 0;
   const discountAmount = subtotal * (discountPercent / 100);
   const total = subtotal - discountAmount;
+  const roundedTotal = Math.round(total * 100) / 100;
 
   return (
     <div className="max-w-2xl mx-auto p-6 bg-white rounded shadow">
@@ -151,6 +215,11 @@ const CreateReceipt = () => {
           <div>
             Receipt created! Receipt Number: <b>{success.receipt_number}</b>
           </div>
+          {success.change > 0 && (
+            <div>
+              Change due: <b>${success.change.toFixed(2)}</b>
+            </div>
+          )}
           <button
             className="bg-blue-500 text-white px-3 py-1 rounded w-fit"
             onClick={() => setSuccess(null)}
@@ -159,6 +228,47 @@ const CreateReceipt = () => {
           </button>
         </div>
       )}
+      <div className="border p-2 rounded mb-4 flex gap-2 items-center">
+        {shift ? (
+          <>
+            <span>
+              Shift #{shift.shift_id} on register <b>{shift.register_id}</b>
+            </span>
+            <button
+              type="button"
+              onClick={handleCloseShift}
+              className="ml-auto bg-gray-600 text-white px-3 py-1 rounded text-sm"
+            >
+              Close shift
+            </button>
+          </>
+        ) : (
+          <>
+            <input
+              placeholder="Register"
+              value={registerId}
+              onChange={(e) => setRegisterId(e.target.value)}
+              className="border p-1 rounded w-24"
+            />
+            <input
+              type="number"
+              min={0}
+              step="0.01"
+              placeholder="Opening float"
+              value={openingFloat}
+              onChange={(e) => setOpeningFloat(e.target.value)}
+              className="border p-1 rounded w-32"
+            />
+            <button
+              type="button"
+              onClick={handleOpenShift}
+              className="bg-blue-500 text-white px-3 py-1 rounded text-sm"
+            >
+              Open shift
+            </button>
+          </>
+        )}
+      </div>
       <form onSubmit={handleSubmit} className="space-y-4">
         <div>
           <label className="font-medium">Cashier</label>
@@ -314,10 +424,40 @@ const CreateReceipt = () => {
           <br />
           Final Total: ${total.toFixed(2)}
         </div>
+        <div>
+          <label className="font-medium">Payment</label>
+          <div className="flex gap-2 mt-1">
+            <select
+              value={tender}
+              onChange={(e) => setTender(e.target.value as Tender)}
+              className="border p-2 rounded"
+            >
+              <option value="cash">Cash</option>
+              <option value="card">Card</option>
+            </select>
+            {tender === "cash" && (
+              <input
+                type="number"
+                min={0}
+                step="0.01"
+                placeholder={roundedTotal.toFixed(2)}
+                value={cashReceived}
+                onChange={(e) => setCashReceived(e.target.value)}
+                className="border p-2 rounded w-32"
+              />
+            )}
+          </div>
+          {tender === "cash" && cashReceived !== "" && (
+            <div className="text-xs text-gray-500 mt-1">
+              Change: $
+              {Math.max(parseFloat(cashReceived) - roundedTotal, 0).toFixed(2)}
+            </div>
+          )}
+        </div>
         <button
           type="submit"
           className="bg-green-600 text-white px-4 py-2 rounded mt-2"
-          disabled={loading}
+          disabled={loading || !shift}
         >
           {loading ? "Creating..." : "Create Receipt"}
         </button>
@@ -1,5 +1,5 @@
 import React, { useEffect, useState } from "react";
-import type { ReceiptRetrieve } from "../types/receipt";
+import type { ReceiptListParams, ReceiptRetrieve } from "../types/receipt";
 import type { Employee } from "../types/employee";
 import { Link } from "react-router-dom";
 import { fetchReceipts } from "../api/receipts";
@@ -9,7 +9,9 @@ import ExportPdfButton from "../components/ExportPdfButton";
 const Receipts: React.FC = () => {
   const [receipts, setReceipts] = useState<ReceiptRetrieve[]>([]);
   const [employees, setEmployees] = useState<Employee[]>([]);
+  const [next, setNext] = useState<string | null>(null);
   const [loading, setLoading] = useState(true);
+  const [loadingMore, setLoadingMore] = useState(false);
   const [error, setError] = useState<string | null>(null);
 
   // Filter states
@@ -18,15 +20,41 @@ const Receipts: React.FC = () => {
   const [filterDateFrom, setFilterDateFrom] = useState<string>("");
   const [filterDateTo, setFilterDateTo] = useState<string>("");
 
+  // Get today's local date in YYYY-MM-DD format; the server reads it as a
+  // day in the store's time zone
+  const getTodayDate = () => {
+    const today = new Date();
+    const month = String(today.getMonth() + 1).padStart(2, "0");
+    const day = String(today.getDate()).padStart(2, "0");
+    return `${today.getFullYear()}-${month}-${day}`;
+  };
+
+  // Filters are applied by the server; the list is paged with a cursor
+  const listParams = (): ReceiptListParams => {
+    const params: ReceiptListParams = {};
+    if (filterCashier) params.employee_id = filterCashier;
+    if (filterToday) {
+      params.from = getTodayDate();
+      params.to = getTodayDate();
+    } else {
+      if (filterDateFrom) params.from = filterDateFrom;
+      if (filterDateTo) params.to = filterDateTo;
+    }
+    return params;
+  };
+
+  useEffect(() => {
+    fetchEmployees()
+      .then((res) => setEmployees(res.data))
+      .catch(() => setEmployees([]));
+  }, []);
+
   useEffect(() => {
     setLoading(true);
-    Promise.all([
-      fetchReceipts().then((res) => res.data),
-      fetchEmployees().then((res) => res.data),
-    ])
-      .then(([receiptsData, employeesData]) => {
-        setReceipts(receiptsData);
-        setEmployees(employeesData);
+    fetchReceipts(listParams())
+      .then((res) => {
+        setReceipts(res.data.receipts);
+        setNext(res.data.next);
         setError(null);
       })
       .catch((err) => {
@@ -35,40 +63,25 @@ const Receipts: React.FC = () => {
         );
       })
       .finally(() => setLoading(false));
-  }, []);
+    // eslint-disable-next-line react-hooks/exhaustive-deps
+  }, [filterToday, filterCashier, filterDateFrom, filterDateTo]);
 
-  // Get today's date in YYYY-MM-DD format
-  const getTodayDate = () => {
-    const today = new Date();
-    return today.toISOString().split("T")[0];
+  const loadMore = () => {
+    if (!next) return;
+    setLoadingMore(true);
+    fetchReceipts({ ...listParams(), cursor: next })
+      .then((res) => {
+        setReceipts((prev) => [...prev, ...res.data.receipts]);
+        setNext(res.data.next);
+      })
+      .catch((err) => {
+        setError(
+          err?.response?.data?.error || err.message || "Failed to load data",
+        );
+      })
+      .finally(() => setLoadingMore(false));
   };
 
-  // Filter receipts based on current filter states
-  const filteredReceipts = receipts.filter((receipt) => {
-    // Extract date part from timestamp for filtering
-    const receiptDate = receipt.print_date.split(" ")[0]; // Gets "2024-01-15" from "2024-01-15 14:30:00"
-
-    // Filter by today
-    if (filterToday && receiptDate !== getTodayDate()) {
-      return false;
-    }
-
-    // Filter by cashier
-    if (filterCashier && receipt.employee_id !== filterCashier) {
-      return false;
-    }
-
-    // Filter by date range
-    if (filterDateFrom && receiptDate < filterDateFrom) {
-      return false;
-    }
-    if (filterDateTo && receiptDate > filterDateTo) {
-      return false;
-    }
-
-    return true;
-  });
-
   // Get cashier name by employee ID
   const getCashierName = (employeeId: string) => {
     const employee = employees.find((emp) => emp.employee_id === employeeId);
@@ -109,7 +122,8 @@ const Receipts: React.FC = () => {
         <div className="flex gap-2">
           <ExportPdfButton
             entityType="Receipts"
-            apiEndpoint="/api/receipts"
+            apiEndpoint="/api/receipts?limit=200"
+            dataKey="receipts"
             title="Receipts Report"
             filename="receipts-export.pdf"
             columns={[
@@ -199,7 +213,7 @@ const Receipts: React.FC = () => {
             Clear Filters
           </button>
           <span className="ml-3 text-sm text-gray-600">
-            Showing {filteredReceipts.length} of {receipts.length} receipts
+            Showing {receipts.length} receipts{next ? " (more available)" : ""}
           </span>
         </div>
       </div>
@@ -223,7 +237,7 @@ const Receipts: React.FC = () => {
               </tr>
             </thead>
             <tbody>
-              {filteredReceipts.map((receipt) => (
+              {receipts.map((receipt) => (
                 <tr key={receipt.receipt_number} className="hover:bg-gray-50">
                   <td className="px-3 py-2 border font-mono">
                     {receipt.receipt_number}
@@ -258,17 +272,26 @@ const Receipts: React.FC = () => {
                   </td>
                 </tr>
               ))}
-              {filteredReceipts.length === 0 && (
+              {receipts.length === 0 && (
                 <tr>
                   <td colSpan={7} className="text-center text-gray-500 py-4">
-                    {receipts.length === 0
-                      ? "No receipts found."
-                      : "No receipts match the current filters."}
+                    No receipts found.
                   </td>
                 </tr>
               )}
             </tbody>
           </table>
+          {next && (
+            <div className="mt-4 text-center">
+              <button
+                onClick={loadMore}
+                disabled={loadingMore}
+                className="bg-blue-500 text-white px-4 py-2 rounded hover:bg-blue-600 disabled:opacity-50"
+              >
+                {loadingMore ? "Loading..." : "Load more"}
+              </button>
+            </div>
+          )}
         </div>
       )}
     </div>
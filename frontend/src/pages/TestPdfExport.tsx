@@ -144,7 +144,8 @@ const TestPdfExport: React.FC = () => {
           </p>
           <ExportPdfButton
             entityType="Receipts"
-            apiEndpoint="/api/receipts"
+            apiEndpoint="/api/receipts?limit=200"
+            dataKey="receipts"
             title="Receipts Transaction Report"
             filename="test-receipts-export.pdf"
             columns={[
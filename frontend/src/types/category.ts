@@ -1,5 +1,6 @@
 export interface Category {
     id: number;
     name: string;
+    vat_rate?: number | null;
   }
   
\ No newline at end of file
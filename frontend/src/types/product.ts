@@ -3,6 +3,7 @@ export interface Product {
   name: string;
   characteristics: string;
   category_id: number;
+  vat_rate?: number | null;
 }
 
 export interface ProductCreate {
@@ -8,8 +8,14 @@ export interface ReceiptCreate {
   employee_id: string;
   card_number?: string;
   print_date: string;
-  sum_total: number;
-  vat: number;
+}
+
+export type Tender = "cash" | "card" | "voucher";
+
+export interface ReceiptPayment {
+  tender: Tender;
+  tendered: number;
+  reference?: string;
 }
 
 export interface ReceiptCreateComplete {
@@ -17,6 +23,7 @@ export interface ReceiptCreateComplete {
   card_number?: string | null;
   print_date: string;
   items: ReceiptItem[];
+  payments: ReceiptPayment[];
 }
 
 export interface ReceiptRetrieve {
@@ -26,14 +33,39 @@ export interface ReceiptRetrieve {
   print_date: string;
   sum_total: number;
   vat: number;
+  voided_at?: string | null;
+  vat_breakdown?: ReceiptVATLine[];
+}
+
+export interface ReceiptVATLine {
+  vat_rate: number;
+  taxable_sum: number;
+  vat_sum: number;
+}
+
+export interface ReceiptListParams {
+  employee_id?: string;
+  card_number?: string;
+  from?: string;
+  to?: string;
+  min_total?: number;
+  max_total?: number;
+  voided?: "true" | "false" | "all";
+  sort?: "print_date" | "sum_total" | "employee_id" | "card_number" | "voided_at";
+  order?: "asc" | "desc";
+  limit?: number;
+  cursor?: string;
+}
+
+export interface ReceiptList {
+  receipts: ReceiptRetrieve[];
+  next: string | null;
 }
 
 export interface ReceiptUpdate {
   employee_id?: string;
   card_number?: string;
   print_date?: string;
-  sum_total?: number;
-  vat?: number;
 }
 
 export interface ReceiptCreateResponse {
@@ -0,0 +1,14 @@
+export interface Shift {
+  shift_id: number;
+  register_id: string;
+  employee_id: string;
+  opened_at: string;
+  opening_float: number;
+  closed_at: string | null;
+  closed_by: string | null;
+}
+
+export interface ShiftOpen {
+  register_id: string;
+  opening_float: number;
+}
@@ -5,16 +5,46 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"time"
+	// Embedded zone database, so STORE_TIMEZONE loads without system tzdata
+	_ "time/tzdata"
 
 	"github.com/joho/godotenv"
+	"github.com/shopspring/decimal"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+const (
+	ReceiptNumberingRandom     = "random"
+	ReceiptNumberingSequential = "sequential"
 )
 
 type Config struct {
-	DB_DRIVER    string
-	DB_DSN       string
-	PORT         string
-	VAT_RATE     float64
-	SECRET_KEY   string
+	DB_DRIVER  string
+	DB_DSN     string
+	PORT       string
+	VAT_RATE   models.Rate
+	SECRET_KEY string
+	CART_TTL   time.Duration
+
+	// STORE_LOCATION is the store's time zone. Database sessions run in it,
+	// so CURRENT_DATE and date casts in reports follow the store's day.
+	STORE_TIMEZONE string
+	STORE_LOCATION *time.Location
+
+	IDEMPOTENCY_TTL time.Duration
+
+	RECEIPT_NUMBERING string
+
+	STORE_NAME              string
+	STORE_ADDRESS           string
+	STORE_TAX_ID            string
+	RECEIPT_WIDTH           int
+	RECEIPT_TEMPLATE        string
+	RECEIPT_ESCPOS_CODEPAGE byte
+
+	PDF_FONT_PATH      string
+	PDF_FONT_BOLD_PATH string
 }
 
 func Load() *Config {
@@ -22,25 +52,102 @@ func Load() *Config {
 		log.Println(err)
 	}
 
-	vatRate := 0.2
+	vatRate := models.NewRate(decimal.New(2, -1))
 	if envVat := os.Getenv("VAT_RATE"); envVat != "" {
-		if rate, err := strconv.ParseFloat(envVat, 64); err == nil && rate >= 0 && rate <= 1 {
+		if rate, err := models.ParseRate(envVat); err == nil && !rate.Decimal().IsNegative() && rate.Decimal().LessThanOrEqual(decimal.NewFromInt(1)) {
 			vatRate = rate
 		}
 	}
 
+	storeTimezone := os.Getenv("STORE_TIMEZONE")
+	if storeTimezone == "" {
+		storeTimezone = "Europe/Kyiv"
+	}
+	storeLocation, err := time.LoadLocation(storeTimezone)
+	if err != nil {
+		log.Printf("Invalid STORE_TIMEZONE %q, using UTC: %v", storeTimezone, err)
+		storeTimezone, storeLocation = "UTC", time.UTC
+	}
+
+	cartTTL := 30 * time.Minute
+	if envTTL := os.Getenv("CART_TTL_MINUTES"); envTTL != "" {
+		if minutes, err := strconv.Atoi(envTTL); err == nil && minutes > 0 {
+			cartTTL = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	idempotencyTTL := 24 * time.Hour
+	if envTTL := os.Getenv("IDEMPOTENCY_TTL_HOURS"); envTTL != "" {
+		if hours, err := strconv.Atoi(envTTL); err == nil && hours > 0 {
+			idempotencyTTL = time.Duration(hours) * time.Hour
+		}
+	}
+
+	receiptNumbering := ReceiptNumberingSequential
+	if envNumbering := os.Getenv("RECEIPT_NUMBERING"); envNumbering == ReceiptNumberingRandom {
+		receiptNumbering = envNumbering
+	}
+
+	// 48 columns fits 80mm paper, 58mm printers take 32
+	receiptWidth := 48
+	if envWidth := os.Getenv("RECEIPT_WIDTH"); envWidth != "" {
+		if width, err := strconv.Atoi(envWidth); err == nil && width > 0 {
+			receiptWidth = width
+		}
+	}
+
+	var escposCodePage byte = 17
+	if envCodePage := os.Getenv("RECEIPT_ESCPOS_CODEPAGE"); envCodePage != "" {
+		if codePage, err := strconv.ParseUint(envCodePage, 10, 8); err == nil {
+			escposCodePage = byte(codePage)
+		}
+	}
+
+	storeName := os.Getenv("STORE_NAME")
+	if storeName == "" {
+		storeName = "ZLAGODA"
+	}
+
+	pdfFontPath := os.Getenv("PDF_FONT_PATH")
+	if pdfFontPath == "" {
+		pdfFontPath = "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"
+	}
+	pdfFontBoldPath := os.Getenv("PDF_FONT_BOLD_PATH")
+	if pdfFontBoldPath == "" {
+		pdfFontBoldPath = "/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf"
+	}
+
 	return &Config{
 		DB_DSN: fmt.Sprintf(
-			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+			"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable timezone=%s",
 			os.Getenv("DB_HOST"),
 			os.Getenv("DB_PORT"),
 			os.Getenv("DB_USER"),
 			os.Getenv("DB_PASSWORD"),
 			os.Getenv("DB_NAME"),
+			storeTimezone,
 		),
-		DB_DRIVER:    os.Getenv("DB_DRIVER"),
-		PORT:         os.Getenv("PORT"),
-		VAT_RATE:     vatRate,
-		SECRET_KEY:   os.Getenv("SECRET_KEY"),
+		DB_DRIVER:  os.Getenv("DB_DRIVER"),
+		PORT:       os.Getenv("PORT"),
+		VAT_RATE:   vatRate,
+		SECRET_KEY: os.Getenv("SECRET_KEY"),
+		CART_TTL:   cartTTL,
+
+		STORE_TIMEZONE: storeTimezone,
+		STORE_LOCATION: storeLocation,
+
+		IDEMPOTENCY_TTL: idempotencyTTL,
+
+		RECEIPT_NUMBERING: receiptNumbering,
+
+		STORE_NAME:              storeName,
+		STORE_ADDRESS:           os.Getenv("STORE_ADDRESS"),
+		STORE_TAX_ID:            os.Getenv("STORE_TAX_ID"),
+		RECEIPT_WIDTH:           receiptWidth,
+		RECEIPT_TEMPLATE:        os.Getenv("RECEIPT_TEMPLATE"),
+		RECEIPT_ESCPOS_CODEPAGE: escposCodePage,
+
+		PDF_FONT_PATH:      pdfFontPath,
+		PDF_FONT_BOLD_PATH: pdfFontBoldPath,
 	}
 }
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+type cartService interface {
+	CreateCart(employeeID string, cardNumber *string) (models.CartView, error)
+	GetCart(cartID string) (models.CartView, error)
+	AddItem(cartID, upc string, quantity int) (models.CartView, error)
+	SetItemQuantity(cartID, upc string, quantity int) (models.CartView, error)
+	RemoveItem(cartID, upc string) (models.CartView, error)
+	AttachCard(cartID, cardNumber string) (models.CartView, error)
+	DetachCard(cartID string) (models.CartView, error)
+	CancelCart(cartID string) error
+	FinalizeCart(cartID string, payments []models.PaymentCreate) (models.ReceiptCompleteResult, error)
+}
+
+// cartErrorStatus maps cart service errors to HTTP status codes.
+func cartErrorStatus(err error) int {
+	var stockErr *services.InsufficientStockError
+	switch {
+	case errors.Is(err, sql.ErrNoRows),
+		errors.Is(err, services.ErrCartUnknownUPC),
+		errors.Is(err, services.ErrCartUnknownCard),
+		errors.Is(err, services.ErrUnknownCustomerCard),
+		errors.Is(err, services.ErrCartItemNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrCartNotOpen),
+		errors.Is(err, services.ErrCartExpired),
+		errors.Is(err, services.ErrCartEmpty),
+		errors.Is(err, services.ErrTendersDoNotCover),
+		errors.Is(err, services.ErrNonCashOverpaid),
+		errors.Is(err, services.ErrNoOpenShift),
+		errors.Is(err, services.ErrReceiptNumbersExhausted),
+		errors.As(err, &stockErr):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func cartIDParam(c *gin.Context) (string, bool) {
+	cartID := c.Param("cart_id")
+	if len(cartID) != 10 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cart ID"})
+		return "", false
+	}
+	return cartID, true
+}
+
+func NewCartCreatePOSTHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type request struct {
+			CardNumber *string `json:"card_number" binding:"omitempty,len=13"`
+		}
+		// The body is optional, a cart can be opened without a card
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+			log.Printf("[CartCreatePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		cart, err := service.CreateCart(employeeID, req.CardNumber)
+		if err != nil {
+			log.Printf("[CartCreatePOST] Service error: %v", err)
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to create cart: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, cart)
+	}
+}
+
+func NewCartRetrieveGETHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := cartIDParam(c)
+		if !ok {
+			return
+		}
+
+		cart, err := service.GetCart(cartID)
+		if err != nil {
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to retrieve cart: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func NewCartItemAddPOSTHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := cartIDParam(c)
+		if !ok {
+			return
+		}
+
+		type request struct {
+			UPC           *string `json:"upc" binding:"required,len=12"`
+			ProductNumber *int    `json:"product_number" binding:"required,gte=1"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[CartItemAddPOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		cart, err := service.AddItem(cartID, *req.UPC, *req.ProductNumber)
+		if err != nil {
+			log.Printf("[CartItemAddPOST] Service error: %v", err)
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to add item: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func NewCartItemUpdatePUTHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := cartIDParam(c)
+		if !ok {
+			return
+		}
+		upc := c.Param("upc")
+		if len(upc) != 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UPC"})
+			return
+		}
+
+		type request struct {
+			ProductNumber *int `json:"product_number" binding:"required,gte=1"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[CartItemUpdatePUT] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		cart, err := service.SetItemQuantity(cartID, upc, *req.ProductNumber)
+		if err != nil {
+			log.Printf("[CartItemUpdatePUT] Service error: %v", err)
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to update item: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func NewCartItemDeleteDELETEHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := cartIDParam(c)
+		if !ok {
+			return
+		}
+		upc := c.Param("upc")
+		if len(upc) != 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UPC"})
+			return
+		}
+
+		cart, err := service.RemoveItem(cartID, upc)
+		if err != nil {
+			log.Printf("[CartItemDeleteDELETE] Service error: %v", err)
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to remove item: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func NewCartCardPUTHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := cartIDParam(c)
+		if !ok {
+			return
+		}
+
+		type request struct {
+			CardNumber *string `json:"card_number" binding:"required,len=13"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[CartCardPUT] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		cart, err := service.AttachCard(cartID, *req.CardNumber)
+		if err != nil {
+			log.Printf("[CartCardPUT] Service error: %v", err)
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to attach card: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func NewCartCardDeleteDELETEHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := cartIDParam(c)
+		if !ok {
+			return
+		}
+
+		cart, err := service.DetachCard(cartID)
+		if err != nil {
+			log.Printf("[CartCardDeleteDELETE] Service error: %v", err)
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to detach card: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, cart)
+	}
+}
+
+func NewCartCancelDELETEHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := cartIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := service.CancelCart(cartID); err != nil {
+			log.Printf("[CartCancelDELETE] Service error: %v", err)
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to cancel cart: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Cart cancelled successfully"})
+	}
+}
+
+func NewCartFinalizePOSTHandler(service cartService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cartID, ok := cartIDParam(c)
+		if !ok {
+			return
+		}
+
+		type request struct {
+			Payments []paymentRequest `json:"payments" binding:"required,min=1,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[CartFinalizePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		result, err := service.FinalizeCart(cartID, paymentModels(req.Payments))
+		var stockErr *services.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			log.Printf("[CartFinalizePOST] Insufficient stock: %v", err)
+			c.JSON(http.StatusConflict, gin.H{
+				"error":     "Failed to finalize cart: " + err.Error(),
+				"shortages": stockErr.Shortages,
+			})
+			return
+		}
+		if err != nil {
+			log.Printf("[CartFinalizePOST] Service error: %v", err)
+			c.JSON(cartErrorStatus(err), gin.H{"error": "Failed to finalize cart: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"id":               result.ReceiptNumber,
+			"subtotal":         result.Subtotal,
+			"discount_percent": result.DiscountPercent,
+			"discount_sum":     result.DiscountSum,
+			"sum_total":        result.TotalSum,
+			"vat":              result.VAT,
+			"items":            result.Lines,
+			"payments":         result.Payments,
+			"change":           result.Change,
+		})
+	}
+}
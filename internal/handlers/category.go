@@ -15,7 +15,8 @@ type categoryCreator interface {
 func NewCategoryCreatePOSTHandler(service categoryCreator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type request struct {
-			Name string `json:"name" binding:"required"`
+			Name    string   `json:"name" binding:"required"`
+			VATRate *float64 `json:"vat_rate" binding:"omitempty,gte=0,lt=1"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -23,7 +24,8 @@ func NewCategoryCreatePOSTHandler(service categoryCreator) gin.HandlerFunc {
 			return
 		}
 		model := models.CategoryCreate{
-			Name: req.Name,
+			Name:    req.Name,
+			VATRate: req.VATRate,
 		}
 		id, err := service.CreateCategory(model)
 		if err != nil {
@@ -31,7 +33,7 @@ func NewCategoryCreatePOSTHandler(service categoryCreator) gin.HandlerFunc {
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"id": id, "name": req.Name})
+		c.JSON(http.StatusCreated, gin.H{"id": id, "name": req.Name, "vat_rate": req.VATRate})
 	}
 }
 
@@ -43,8 +45,9 @@ type categoryReader interface {
 func NewCategoryRetrieveGETHandler(service categoryReader) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type response struct {
-			ID   int    `json:"id"`
-			Name string `json:"name"`
+			ID      int      `json:"id"`
+			Name    string   `json:"name"`
+			VATRate *float64 `json:"vat_rate"`
 		}
 
 		idStr := c.Param("id")
@@ -61,8 +64,9 @@ func NewCategoryRetrieveGETHandler(service categoryReader) gin.HandlerFunc {
 		}
 
 		resp := response{
-			ID:   category.ID,
-			Name: category.Name,
+			ID:      category.ID,
+			Name:    category.Name,
+			VATRate: category.VATRate,
 		}
 
 		c.JSON(http.StatusOK, resp)
@@ -71,8 +75,9 @@ func NewCategoryRetrieveGETHandler(service categoryReader) gin.HandlerFunc {
 
 func NewCategoryListGETHandler(service categoryReader) gin.HandlerFunc {
 	type responseItem struct {
-		ID   int    `json:"id"`
-		Name string `json:"name"`
+		ID      int      `json:"id"`
+		Name    string   `json:"name"`
+		VATRate *float64 `json:"vat_rate"`
 	}
 
 	return func(c *gin.Context) {
@@ -85,8 +90,9 @@ func NewCategoryListGETHandler(service categoryReader) gin.HandlerFunc {
 		var resp []responseItem
 		for _, cat := range categories {
 			resp = append(resp, responseItem{
-				ID:   cat.ID,
-				Name: cat.Name,
+				ID:      cat.ID,
+				Name:    cat.Name,
+				VATRate: cat.VATRate,
 			})
 		}
 
@@ -162,3 +168,65 @@ func NewCategoryUpdatePATCHHandler(service categoryUpdater) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"message": "Category updated successfully"})
 	}
 }
+
+type categoryVATRateSetter interface {
+	SetCategoryVATRate(id int, rate *float64) error
+	GetCategoryByID(id int) (models.CategoryRetrieve, error)
+}
+
+// NewCategoryVATRatePUTHandler sets the VAT rate of every product in the
+// category that has no rate of its own.
+func NewCategoryVATRatePUTHandler(service categoryVATRateSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		type request struct {
+			VATRate *float64 `json:"vat_rate" binding:"required,gte=0,lt=1"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if _, err := service.GetCategoryByID(id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found: " + err.Error()})
+			return
+		}
+
+		if err := service.SetCategoryVATRate(id, req.VATRate); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set VAT rate: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Category VAT rate updated successfully"})
+	}
+}
+
+// NewCategoryVATRateDELETEHandler clears the category rate so its products
+// fall back to the store default VAT_RATE.
+func NewCategoryVATRateDELETEHandler(service categoryVATRateSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if _, err := service.GetCategoryByID(id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Category not found: " + err.Error()})
+			return
+		}
+
+		if err := service.SetCategoryVATRate(id, nil); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear VAT rate: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Category VAT rate cleared successfully"})
+	}
+}
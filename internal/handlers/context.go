@@ -0,0 +1,14 @@
+package handlers
+
+import "github.com/gin-gonic/gin"
+
+// currentEmployeeID returns the employee_id that AuthMiddleware stored for
+// the authenticated request.
+func currentEmployeeID(c *gin.Context) (string, bool) {
+	employeeID, exists := c.Get("employee_id")
+	if !exists {
+		return "", false
+	}
+	employeeIDStr, ok := employeeID.(string)
+	return employeeIDStr, ok
+}
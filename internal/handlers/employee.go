@@ -20,17 +20,17 @@ type employeeCreator interface {
 func NewEmployeeCreatePOSTHandler(service employeeCreator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type request struct {
-			Surname     *string  `json:"empl_surname" binding:"required"`
-			Name        *string  `json:"empl_name" binding:"required"`
-			Patronymic  *string  `json:"empl_patronymic"`
-			Role        *string  `json:"empl_role" binding:"required"`
-			Salary      *float64 `json:"salary" binding:"required,gte=0"`
-			DateOfBirth *string  `json:"date_of_birth" binding:"required"`
-			DateOfStart *string  `json:"date_of_start" binding:"required"`
-			PhoneNumber *string  `json:"phone_number" binding:"required,len=13,startswith=+380"`
-			City        *string  `json:"city" binding:"required"`
-			Street      *string  `json:"street" binding:"required"`
-			ZipCode     *string  `json:"zip_code" binding:"required"`
+			Surname     *string       `json:"empl_surname" binding:"required"`
+			Name        *string       `json:"empl_name" binding:"required"`
+			Patronymic  *string       `json:"empl_patronymic"`
+			Role        *string       `json:"empl_role" binding:"required"`
+			Salary      *models.Money `json:"salary" binding:"required,gte=0"`
+			DateOfBirth *string       `json:"date_of_birth" binding:"required"`
+			DateOfStart *string       `json:"date_of_start" binding:"required"`
+			PhoneNumber *string       `json:"phone_number" binding:"required,len=13,startswith=+380"`
+			City        *string       `json:"city" binding:"required"`
+			Street      *string       `json:"street" binding:"required"`
+			ZipCode     *string       `json:"zip_code" binding:"required"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -101,18 +101,18 @@ type employeeReader interface {
 func NewEmployeeRetrieveGETHandler(service employeeReader) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type response struct {
-			ID          *string  `json:"employee_id"`
-			Surname     *string  `json:"empl_surname"`
-			Name        *string  `json:"empl_name"`
-			Patronymic  *string  `json:"empl_patronymic"`
-			Role        *string  `json:"empl_role"`
-			Salary      *float64 `json:"salary"`
-			DateOfBirth *string  `json:"date_of_birth"`
-			DateOfStart *string  `json:"date_of_start"`
-			PhoneNumber *string  `json:"phone_number"`
-			City        *string  `json:"city"`
-			Street      *string  `json:"street"`
-			ZipCode     *string  `json:"zip_code"`
+			ID          *string       `json:"employee_id"`
+			Surname     *string       `json:"empl_surname"`
+			Name        *string       `json:"empl_name"`
+			Patronymic  *string       `json:"empl_patronymic"`
+			Role        *string       `json:"empl_role"`
+			Salary      *models.Money `json:"salary"`
+			DateOfBirth *string       `json:"date_of_birth"`
+			DateOfStart *string       `json:"date_of_start"`
+			PhoneNumber *string       `json:"phone_number"`
+			City        *string       `json:"city"`
+			Street      *string       `json:"street"`
+			ZipCode     *string       `json:"zip_code"`
 		}
 		var id string = c.Param("id")
 		if len(id) != 10 {
@@ -149,18 +149,18 @@ func NewEmployeeRetrieveGETHandler(service employeeReader) gin.HandlerFunc {
 
 func NewEmployeesListGETHandler(service employeeReader) gin.HandlerFunc {
 	type responseItem struct {
-		ID          *string  `json:"employee_id"`
-		Surname     *string  `json:"empl_surname"`
-		Name        *string  `json:"empl_name"`
-		Patronymic  *string  `json:"empl_patronymic"`
-		Role        *string  `json:"empl_role"`
-		Salary      *float64 `json:"salary"`
-		DateOfBirth *string  `json:"date_of_birth"`
-		DateOfStart *string  `json:"date_of_start"`
-		PhoneNumber *string  `json:"phone_number"`
-		City        *string  `json:"city"`
-		Street      *string  `json:"street"`
-		ZipCode     *string  `json:"zip_code"`
+		ID          *string       `json:"employee_id"`
+		Surname     *string       `json:"empl_surname"`
+		Name        *string       `json:"empl_name"`
+		Patronymic  *string       `json:"empl_patronymic"`
+		Role        *string       `json:"empl_role"`
+		Salary      *models.Money `json:"salary"`
+		DateOfBirth *string       `json:"date_of_birth"`
+		DateOfStart *string       `json:"date_of_start"`
+		PhoneNumber *string       `json:"phone_number"`
+		City        *string       `json:"city"`
+		Street      *string       `json:"street"`
+		ZipCode     *string       `json:"zip_code"`
 	}
 
 	return func(c *gin.Context) {
@@ -235,17 +235,17 @@ func NewEmployeeUpdatePATCHHandler(service employeeUpdater) gin.HandlerFunc {
 		}
 
 		type request struct {
-			Surname     *string  `json:"empl_surname"`
-			Name        *string  `json:"empl_name"`
-			Patronymic  *string  `json:"empl_patronymic"`
-			Role        *string  `json:"empl_role"`
-			Salary      *float64 `json:"salary" binding:"omitempty,gte=0"`
-			DateOfBirth *string  `json:"date_of_birth"`
-			DateOfStart *string  `json:"date_of_start"`
-			PhoneNumber *string  `json:"phone_number" binding:"omitempty,len=13,startswith=+380"`
-			City        *string  `json:"city"`
-			Street      *string  `json:"street"`
-			ZipCode     *string  `json:"zip_code"`
+			Surname     *string       `json:"empl_surname"`
+			Name        *string       `json:"empl_name"`
+			Patronymic  *string       `json:"empl_patronymic"`
+			Role        *string       `json:"empl_role"`
+			Salary      *models.Money `json:"salary" binding:"omitempty,gte=0"`
+			DateOfBirth *string       `json:"date_of_birth"`
+			DateOfStart *string       `json:"date_of_start"`
+			PhoneNumber *string       `json:"phone_number" binding:"omitempty,len=13,startswith=+380"`
+			City        *string       `json:"city"`
+			Street      *string       `json:"street"`
+			ZipCode     *string       `json:"zip_code"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -350,19 +350,19 @@ type employeeCreatorWithAuth interface {
 func NewEmployeeCreateWithAuthPOSTHandler(service employeeCreatorWithAuth) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type request struct {
-			Login       string   `json:"login" binding:"required"`
-			Password    string   `json:"password" binding:"required,min=6"`
-			Surname     *string  `json:"empl_surname" binding:"omitempty,required,max=50"`
-			Name        *string  `json:"empl_name" binding:"omitempty,required,max=50"`
-			Patronymic  *string  `json:"empl_patronymic" binding:"omitempty,max=50"`
-			Role        *string  `json:"empl_role" binding:"omitempty,required,max=10"`
-			Salary      *float64 `json:"salary" binding:"omitempty,required,gte=0"`
-			DateOfBirth *string  `json:"date_of_birth" binding:"omitempty,required"`
-			DateOfStart *string  `json:"date_of_start" binding:"omitempty,required"`
-			PhoneNumber *string  `json:"phone_number" binding:"omitempty,required,len=13,startswith=+380"`
-			City        *string  `json:"city" binding:"omitempty,required,max=50"`
-			Street      *string  `json:"street" binding:"omitempty,required,max=50"`
-			ZipCode     *string  `json:"zip_code" binding:"omitempty,required,max=9"`
+			Login       string        `json:"login" binding:"required"`
+			Password    string        `json:"password" binding:"required,min=6"`
+			Surname     *string       `json:"empl_surname" binding:"omitempty,required,max=50"`
+			Name        *string       `json:"empl_name" binding:"omitempty,required,max=50"`
+			Patronymic  *string       `json:"empl_patronymic" binding:"omitempty,max=50"`
+			Role        *string       `json:"empl_role" binding:"omitempty,required,max=10"`
+			Salary      *models.Money `json:"salary" binding:"omitempty,required,gte=0"`
+			DateOfBirth *string       `json:"date_of_birth" binding:"omitempty,required"`
+			DateOfStart *string       `json:"date_of_start" binding:"omitempty,required"`
+			PhoneNumber *string       `json:"phone_number" binding:"omitempty,required,len=13,startswith=+380"`
+			City        *string       `json:"city" binding:"omitempty,required,max=50"`
+			Street      *string       `json:"street" binding:"omitempty,required,max=50"`
+			ZipCode     *string       `json:"zip_code" binding:"omitempty,required,max=9"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+	"github.com/velosypedno/zlagoda/internal/utils"
+)
+
+type goodsReceivedService interface {
+	PostGoodsReceived(c models.GoodsReceivedCreate) (models.GoodsReceivedView, error)
+	ReverseGoodsReceived(documentID int, rv models.GoodsReceivedReverse) (models.GoodsReceivedView, error)
+	GetGoodsReceived(documentID int) (models.GoodsReceivedView, error)
+	GetGoodsReceivedList(f models.GoodsReceivedFilter) ([]models.GoodsReceivedView, error)
+}
+
+// goodsReceivedErrorStatus maps goods-received service errors to HTTP
+// status codes.
+func goodsReceivedErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrGoodsReceivedUnknownSupplier),
+		errors.Is(err, services.ErrGoodsReceivedDuplicateInvoice),
+		errors.Is(err, services.ErrUnknownUPC),
+		errors.Is(err, services.ErrGoodsReceivedNoRegularUPC),
+		errors.Is(err, services.ErrGoodsReceivedDuplicateUPC),
+		errors.Is(err, services.ErrGoodsReceivedReversed),
+		errors.Is(err, services.ErrGoodsReceivedStockSpent):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func goodsReceivedIDParam(c *gin.Context) (int, bool) {
+	documentID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid document ID"})
+		return 0, false
+	}
+	return documentID, true
+}
+
+// NewGoodsReceivedCreatePOSTHandler posts a supplier's delivery note,
+// received by the authenticated employee. Each line names either a UPC or a
+// product.
+func NewGoodsReceivedCreatePOSTHandler(service goodsReceivedService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type line struct {
+			UPC        *string       `json:"upc" binding:"required_without=ProductID,excluded_with=ProductID,omitempty,len=12"`
+			ProductID  *int          `json:"product_id" binding:"required_without=UPC"`
+			Quantity   *int          `json:"quantity" binding:"required,gte=1"`
+			CostPrice  *models.Money `json:"cost_price" binding:"required,gte=0"`
+			NewPrice   *models.Money `json:"new_price" binding:"omitempty,gte=0"`
+			ExpiryDate *string       `json:"expiry_date" binding:"omitempty,datetime=2006-01-02"`
+		}
+		type request struct {
+			SupplierID    *int    `json:"supplier_id" binding:"required"`
+			InvoiceNumber *string `json:"invoice_number" binding:"required,min=1,max=50"`
+			InvoiceDate   *string `json:"invoice_date" binding:"required"`
+			Lines         []line  `json:"lines" binding:"required,min=1,max=500,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[GoodsReceivedCreatePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		if _, err := time.Parse(time.DateOnly, *req.InvoiceDate); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice_date, use YYYY-MM-DD"})
+			return
+		}
+
+		document := models.GoodsReceivedCreate{
+			SupplierID:    *req.SupplierID,
+			InvoiceNumber: *req.InvoiceNumber,
+			InvoiceDate:   *req.InvoiceDate,
+		}
+		for i, l := range req.Lines {
+			if !utils.IsDecimalValid(*l.CostPrice) || (l.NewPrice != nil && !utils.IsDecimalValid(*l.NewPrice)) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid price on line " + strconv.Itoa(i+1)})
+				return
+			}
+			document.Lines = append(document.Lines, models.GoodsReceivedLineCreate{
+				UPC:        l.UPC,
+				ProductID:  l.ProductID,
+				Quantity:   *l.Quantity,
+				CostPrice:  *l.CostPrice,
+				NewPrice:   l.NewPrice,
+				ExpiryDate: l.ExpiryDate,
+			})
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+		document.EmployeeId = employeeID
+
+		view, err := service.PostGoodsReceived(document)
+		if err != nil {
+			log.Printf("[GoodsReceivedCreatePOST] Service error: %v", err)
+			c.JSON(goodsReceivedErrorStatus(err), gin.H{"error": "Failed to post goods-received document: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, view)
+	}
+}
+
+// NewGoodsReceivedListGETHandler lists documents with their lines, latest
+// invoice first, optionally narrowed to a supplier, a status and a range of
+// invoice dates.
+func NewGoodsReceivedListGETHandler(service goodsReceivedService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := models.GoodsReceivedFilter{
+			Status: c.Query("status"),
+			From:   c.Query("from"),
+			To:     c.Query("to"),
+		}
+		if filter.Status != "" && filter.Status != models.GoodsReceivedStatusPosted && filter.Status != models.GoodsReceivedStatusReversed {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status, use posted or reversed"})
+			return
+		}
+		for _, day := range []string{filter.From, filter.To} {
+			if day == "" {
+				continue
+			}
+			if _, err := time.Parse(time.DateOnly, day); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date " + day + ", use YYYY-MM-DD"})
+				return
+			}
+		}
+		if value := c.Query("supplier_id"); value != "" {
+			supplierID, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier ID"})
+				return
+			}
+			filter.SupplierID = &supplierID
+		}
+
+		documents, err := service.GetGoodsReceivedList(filter)
+		if err != nil {
+			log.Printf("[GoodsReceivedListGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve goods-received documents: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, documents)
+	}
+}
+
+func NewGoodsReceivedRetrieveGETHandler(service goodsReceivedService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		documentID, ok := goodsReceivedIDParam(c)
+		if !ok {
+			return
+		}
+
+		document, err := service.GetGoodsReceived(documentID)
+		if err != nil {
+			c.JSON(goodsReceivedErrorStatus(err), gin.H{"error": "Failed to retrieve goods-received document: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, document)
+	}
+}
+
+// NewGoodsReceivedReversePOSTHandler reverses a posted document on behalf of
+// the authenticated employee.
+func NewGoodsReceivedReversePOSTHandler(service goodsReceivedService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		documentID, ok := goodsReceivedIDParam(c)
+		if !ok {
+			return
+		}
+
+		type request struct {
+			Reason *string `json:"reason" binding:"required,min=1,max=255"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[GoodsReceivedReversePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		document, err := service.ReverseGoodsReceived(documentID, models.GoodsReceivedReverse{
+			EmployeeId: employeeID,
+			Reason:     *req.Reason,
+		})
+		if err != nil {
+			log.Printf("[GoodsReceivedReversePOST] Service error: %v", err)
+			c.JSON(goodsReceivedErrorStatus(err), gin.H{"error": "Failed to reverse goods-received document: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, document)
+	}
+}
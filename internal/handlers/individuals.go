@@ -18,7 +18,7 @@ type individualsService interface {
 }
 
 // Vlad1 - Most sold product in a category within a time period
-func NewVlad1GETHandler(service individualsService) gin.HandlerFunc {
+func NewVlad1GETHandler(service individualsService, pdf tablePDFRenderer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		categoryIDStr := c.Query("category_id")
 		monthsStr := c.DefaultQuery("months", "1")
@@ -46,6 +46,14 @@ func NewVlad1GETHandler(service individualsService) gin.HandlerFunc {
 			return
 		}
 
+		if wantsPDF(c) {
+			writeTablePDF(c, pdf, "vlad1", "Most sold products in category within time period", gin.H{
+				"category_id": categoryID,
+				"months":      months,
+			}, results)
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"description": "Most sold products in category within time period",
 			"parameters": gin.H{
@@ -58,7 +66,7 @@ func NewVlad1GETHandler(service individualsService) gin.HandlerFunc {
 }
 
 // Vlad2 - Employees who never sold promotional products
-func NewVlad2GETHandler(service individualsService) gin.HandlerFunc {
+func NewVlad2GETHandler(service individualsService, pdf tablePDFRenderer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		results, err := service.QueryVlad2()
 		if err != nil {
@@ -66,6 +74,11 @@ func NewVlad2GETHandler(service individualsService) gin.HandlerFunc {
 			return
 		}
 
+		if wantsPDF(c) {
+			writeTablePDF(c, pdf, "vlad2", "Employees who never sold promotional products", nil, results)
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"description": "Employees who never sold promotional products",
 			"results":     results,
@@ -74,7 +87,7 @@ func NewVlad2GETHandler(service individualsService) gin.HandlerFunc {
 }
 
 // Arthur1 - Category sales statistics within date range
-func NewArthur1GETHandler(service individualsService) gin.HandlerFunc {
+func NewArthur1GETHandler(service individualsService, pdf tablePDFRenderer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		startDate := c.Query("start_date")
 		endDate := c.Query("end_date")
@@ -96,6 +109,14 @@ func NewArthur1GETHandler(service individualsService) gin.HandlerFunc {
 			return
 		}
 
+		if wantsPDF(c) {
+			writeTablePDF(c, pdf, "arthur1", "Category sales statistics within date range", gin.H{
+				"start_date": startDate,
+				"end_date":   endDate,
+			}, results)
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"description": "Category sales statistics within date range",
 			"parameters": gin.H{
@@ -108,7 +129,7 @@ func NewArthur1GETHandler(service individualsService) gin.HandlerFunc {
 }
 
 // Arthur2 - Products in store that have never been sold and are not promotional
-func NewArthur2GETHandler(service individualsService) gin.HandlerFunc {
+func NewArthur2GETHandler(service individualsService, pdf tablePDFRenderer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		results, err := service.QueryArthur2()
 		if err != nil {
@@ -116,6 +137,11 @@ func NewArthur2GETHandler(service individualsService) gin.HandlerFunc {
 			return
 		}
 
+		if wantsPDF(c) {
+			writeTablePDF(c, pdf, "arthur2", "Products in store that have never been sold and are not promotional", nil, results)
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"description": "Products in store that have never been sold and are not promotional",
 			"results":     results,
@@ -124,7 +150,7 @@ func NewArthur2GETHandler(service individualsService) gin.HandlerFunc {
 }
 
 // Oleksii1 - Cashiers who served customers with high discount
-func NewOleksii1GETHandler(service individualsService) gin.HandlerFunc {
+func NewOleksii1GETHandler(service individualsService, pdf tablePDFRenderer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		discountThresholdStr := c.DefaultQuery("discount_threshold", "10")
 
@@ -140,6 +166,13 @@ func NewOleksii1GETHandler(service individualsService) gin.HandlerFunc {
 			return
 		}
 
+		if wantsPDF(c) {
+			writeTablePDF(c, pdf, "oleksii1", "Cashiers who served customers with high discount", gin.H{
+				"discount_threshold": discountThreshold,
+			}, results)
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"description": "Cashiers who served customers with high discount",
 			"parameters": gin.H{
@@ -151,7 +184,7 @@ func NewOleksii1GETHandler(service individualsService) gin.HandlerFunc {
 }
 
 // Oleksii2 - Customers who bought from all categories in the last month
-func NewOleksii2GETHandler(service individualsService) gin.HandlerFunc {
+func NewOleksii2GETHandler(service individualsService, pdf tablePDFRenderer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		results, err := service.QueryOleksii2()
 		if err != nil {
@@ -159,6 +192,11 @@ func NewOleksii2GETHandler(service individualsService) gin.HandlerFunc {
 			return
 		}
 
+		if wantsPDF(c) {
+			writeTablePDF(c, pdf, "oleksii2", "Customers who bought from all categories in the last month", nil, results)
+			return
+		}
+
 		c.JSON(http.StatusOK, gin.H{
 			"description": "Customers who bought from all categories in the last month",
 			"results":     results,
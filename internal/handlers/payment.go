@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+// paymentRequest is a tender as sent by the till when finalizing a receipt.
+type paymentRequest struct {
+	Tender    *string       `json:"tender" binding:"required,oneof=cash card voucher"`
+	Tendered  *models.Money `json:"tendered" binding:"required,gt=0"`
+	Reference *string       `json:"reference" binding:"omitempty,max=50"`
+}
+
+func paymentModels(payments []paymentRequest) []models.PaymentCreate {
+	var result []models.PaymentCreate
+	for _, payment := range payments {
+		result = append(result, models.PaymentCreate{
+			Tender:    payment.Tender,
+			Tendered:  payment.Tendered,
+			Reference: payment.Reference,
+		})
+	}
+	return result
+}
+
+type paymentReader interface {
+	GetPaymentsByReceipt(receiptNumber string) ([]models.PaymentRetrieve, error)
+	GetTenderTotals(fromDay, toDay time.Time, employeeID string) ([]models.TenderTotal, error)
+}
+
+func NewPaymentsByReceiptGETHandler(service paymentReader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receiptNumber := c.Param("receipt_number")
+		if len(receiptNumber) != 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid receipt number"})
+			return
+		}
+
+		payments, err := service.GetPaymentsByReceipt(receiptNumber)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve payments: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, payments)
+	}
+}
+
+// NewTenderTotalsGETHandler reports per-tender totals per cashier per day
+// for reconciling the cash drawer. from and to default to today.
+func NewTenderTotalsGETHandler(service paymentReader, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		today := time.Now().In(cfg.STORE_LOCATION).Format(time.DateOnly)
+		fromDay, err := storeDay(c.DefaultQuery("from", today), cfg.STORE_LOCATION)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from parameter, use YYYY-MM-DD"})
+			return
+		}
+		toDay, err := storeDay(c.DefaultQuery("to", today), cfg.STORE_LOCATION)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to parameter, use YYYY-MM-DD"})
+			return
+		}
+		if toDay.Before(fromDay) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+			return
+		}
+
+		employeeID := c.Query("employee_id")
+		if employeeID != "" && len(employeeID) != 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+			return
+		}
+
+		totals, err := service.GetTenderTotals(fromDay, toDay, employeeID)
+		if err != nil {
+			log.Printf("[TenderTotalsGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tender totals: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, totals)
+	}
+}
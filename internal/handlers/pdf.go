@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+const pdfContentType = "application/pdf"
+
+type tablePDFRenderer interface {
+	RenderTablePDF(title string, parameters map[string]any, rows any) ([]byte, error)
+}
+
+type receiptPDFRenderer interface {
+	RenderReceiptPDF(receiptNumber string) ([]byte, error)
+}
+
+// wantsPDF reports whether the request came in on a /pdf sub-route or asks
+// for a PDF through the Accept header.
+func wantsPDF(c *gin.Context) bool {
+	return strings.HasSuffix(c.FullPath(), "/pdf") || strings.Contains(c.GetHeader("Accept"), pdfContentType)
+}
+
+func writePDF(c *gin.Context, filename string, body []byte, err error) {
+	if errors.Is(err, services.ErrPDFFontMissing) {
+		log.Printf("[PDF] %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "PDF export is not available: " + err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("[PDF] Render error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render PDF: " + err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+filename+`.pdf"`)
+	c.Data(http.StatusOK, pdfContentType, body)
+}
+
+func writeTablePDF(c *gin.Context, renderer tablePDFRenderer, filename, title string, parameters gin.H, rows any) {
+	body, err := renderer.RenderTablePDF(title, parameters, rows)
+	writePDF(c, filename, body, err)
+}
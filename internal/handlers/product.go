@@ -18,9 +18,10 @@ func NewProductCreatePOSTHandler(service productCreator) gin.HandlerFunc {
 		log.Printf("[ProductCreatePOST] Starting product creation request")
 
 		var req struct {
-			CategoryID      int    `json:"category_id"     binding:"required"`
-			Name            string `json:"name"            binding:"required"`
-			Characteristics string `json:"characteristics" binding:"required"`
+			CategoryID      int      `json:"category_id"     binding:"required"`
+			Name            string   `json:"name"            binding:"required"`
+			Characteristics string   `json:"characteristics" binding:"required"`
+			VATRate         *float64 `json:"vat_rate"        binding:"omitempty,gte=0,lt=1"`
 		}
 		if err := c.ShouldBindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
@@ -56,6 +57,7 @@ func NewProductCreatePOSTHandler(service productCreator) gin.HandlerFunc {
 			CategoryID:      req.CategoryID,
 			Name:            req.Name,
 			Characteristics: req.Characteristics,
+			VATRate:         req.VATRate,
 		}
 
 		log.Printf("[ProductCreatePOST] Calling service.CreateProduct with model: %+v", model)
@@ -84,10 +86,11 @@ type productReader interface {
 func NewProductRetrieveGETHandler(service productReader) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type response struct {
-			ID              int    `json:"product_id"`
-			CategoryID      int    `json:"category_id"`
-			Name            string `json:"name"`
-			Characteristics string `json:"characteristics"`
+			ID              int      `json:"product_id"`
+			CategoryID      int      `json:"category_id"`
+			Name            string   `json:"name"`
+			Characteristics string   `json:"characteristics"`
+			VATRate         *float64 `json:"vat_rate"`
 		}
 
 		id, err := strconv.Atoi(c.Param("id"))
@@ -109,6 +112,7 @@ func NewProductRetrieveGETHandler(service productReader) gin.HandlerFunc {
 			CategoryID:      p.CategoryID,
 			Name:            p.Name,
 			Characteristics: p.Characteristics,
+			VATRate:         p.VATRate,
 		}
 
 		c.JSON(http.StatusOK, resp)
@@ -117,10 +121,11 @@ func NewProductRetrieveGETHandler(service productReader) gin.HandlerFunc {
 
 func NewProductsListGETHandler(service productReader) gin.HandlerFunc {
 	type responseItem struct {
-		ID              int    `json:"product_id"`
-		CategoryID      int    `json:"category_id"`
-		Name            string `json:"name"`
-		Characteristics string `json:"characteristics"`
+		ID              int      `json:"product_id"`
+		CategoryID      int      `json:"category_id"`
+		Name            string   `json:"name"`
+		Characteristics string   `json:"characteristics"`
+		VATRate         *float64 `json:"vat_rate"`
 	}
 
 	return func(c *gin.Context) {
@@ -138,6 +143,7 @@ func NewProductsListGETHandler(service productReader) gin.HandlerFunc {
 				CategoryID:      p.CategoryID,
 				Name:            p.Name,
 				Characteristics: p.Characteristics,
+				VATRate:         p.VATRate,
 			})
 		}
 		c.JSON(http.StatusOK, resp)
@@ -168,6 +174,7 @@ func NewProductsByCategoryGETHandler(service productReader) gin.HandlerFunc {
 				"name":            p.Name,
 				"characteristics": p.Characteristics,
 				"category_id":     p.CategoryID,
+				"vat_rate":        p.VATRate,
 			})
 		}
 		c.JSON(http.StatusOK, resp)
@@ -197,6 +204,7 @@ func NewProductsByNameGETHandler(service productReader) gin.HandlerFunc {
 				"name":            p.Name,
 				"characteristics": p.Characteristics,
 				"category_id":     p.CategoryID,
+				"vat_rate":        p.VATRate,
 			})
 		}
 		c.JSON(http.StatusOK, resp)
@@ -242,6 +250,69 @@ func NewProductUpdatePATCHHandler(service productUpdater) gin.HandlerFunc {
 	}
 }
 
+type productVATRateSetter interface {
+	SetProductVATRate(id int, rate *float64) error
+	GetProductByID(id int) (models.ProductRetrieve, error)
+}
+
+// NewProductVATRatePUTHandler overrides the category VAT rate for one product,
+// e.g. a 7% medicine in a 20% category.
+func NewProductVATRatePUTHandler(service productVATRateSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Printf("[ProductVATRatePUT] Invalid ID parameter: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		var req struct {
+			VATRate *float64 `json:"vat_rate" binding:"required,gte=0,lt=1"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		if _, err := service.GetProductByID(id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + err.Error()})
+			return
+		}
+
+		if err := service.SetProductVATRate(id, req.VATRate); err != nil {
+			log.Printf("[ProductVATRatePUT] Service error for ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set VAT rate: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Product VAT rate updated successfully"})
+	}
+}
+
+// NewProductVATRateDELETEHandler removes the override so the product is taxed
+// at its category rate again.
+func NewProductVATRateDELETEHandler(service productVATRateSetter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			log.Printf("[ProductVATRateDELETE] Invalid ID parameter: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if _, err := service.GetProductByID(id); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product not found: " + err.Error()})
+			return
+		}
+
+		if err := service.SetProductVATRate(id, nil); err != nil {
+			log.Printf("[ProductVATRateDELETE] Service error for ID %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear VAT rate: " + err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Product VAT rate cleared successfully"})
+	}
+}
+
 type productRemover interface {
 	DeleteProduct(id int) error
 }
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+	"github.com/velosypedno/zlagoda/internal/utils"
+)
+
+type purchaseOrderService interface {
+	CreatePurchaseOrder(c models.PurchaseOrderCreate) (models.PurchaseOrderView, error)
+	GetPurchaseOrder(orderID int) (models.PurchaseOrderView, error)
+	GetPurchaseOrders(f models.PurchaseOrderFilter) ([]models.PurchaseOrderRetrieve, error)
+	UpdatePurchaseOrder(orderID int, u models.PurchaseOrderUpdate) (models.PurchaseOrderView, error)
+	SendPurchaseOrder(orderID int) (models.PurchaseOrderView, error)
+	CancelPurchaseOrder(orderID int) (models.PurchaseOrderView, error)
+	ReceivePurchaseOrder(orderID int, r models.PurchaseOrderReceive) (models.PurchaseOrderView, error)
+}
+
+// purchaseOrderErrorStatus maps purchase order service errors to HTTP status
+// codes.
+func purchaseOrderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrPurchaseOrderNotDraft),
+		errors.Is(err, services.ErrPurchaseOrderNotReceivable),
+		errors.Is(err, services.ErrPurchaseOrderNotCancellable),
+		errors.Is(err, services.ErrPurchaseOrderUnknownSupplier),
+		errors.Is(err, services.ErrPurchaseOrderUnknownProduct),
+		errors.Is(err, services.ErrPurchaseOrderDuplicateLine),
+		errors.Is(err, services.ErrUnknownUPC),
+		errors.Is(err, services.ErrPurchaseOrderNotOrdered),
+		errors.Is(err, services.ErrPurchaseOrderOverReceived):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func purchaseOrderIDParam(c *gin.Context) (int, bool) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid purchase order ID"})
+		return 0, false
+	}
+	return orderID, true
+}
+
+type purchaseOrderLineRequest struct {
+	ProductID    *int          `json:"product_id" binding:"required"`
+	Quantity     *int          `json:"quantity" binding:"required,gte=1"`
+	ExpectedCost *models.Money `json:"expected_cost" binding:"required,gte=0"`
+}
+
+// validPurchaseOrderLines reports whether every expected cost fits the
+// DECIMAL(13,4) column.
+func validPurchaseOrderLines(lines []purchaseOrderLineRequest) bool {
+	for _, line := range lines {
+		if !utils.IsDecimalValid(*line.ExpectedCost) {
+			return false
+		}
+	}
+	return true
+}
+
+func purchaseOrderLines(lines []purchaseOrderLineRequest) []models.PurchaseOrderLineCreate {
+	result := make([]models.PurchaseOrderLineCreate, 0, len(lines))
+	for _, line := range lines {
+		result = append(result, models.PurchaseOrderLineCreate{
+			ProductID:    *line.ProductID,
+			Quantity:     *line.Quantity,
+			ExpectedCost: *line.ExpectedCost,
+		})
+	}
+	return result
+}
+
+// NewPurchaseOrderCreatePOSTHandler drafts an order with a supplier on
+// behalf of the authenticated employee.
+func NewPurchaseOrderCreatePOSTHandler(service purchaseOrderService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type request struct {
+			SupplierID *int                       `json:"supplier_id" binding:"required"`
+			Note       *string                    `json:"note" binding:"omitempty,max=255"`
+			Lines      []purchaseOrderLineRequest `json:"lines" binding:"required,min=1,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[PurchaseOrderCreatePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		if !validPurchaseOrderLines(req.Lines) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid expected cost"})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		order, err := service.CreatePurchaseOrder(models.PurchaseOrderCreate{
+			SupplierID: *req.SupplierID,
+			EmployeeId: employeeID,
+			Note:       req.Note,
+			Lines:      purchaseOrderLines(req.Lines),
+		})
+		if err != nil {
+			log.Printf("[PurchaseOrderCreatePOST] Service error: %v", err)
+			c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": "Failed to create purchase order: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, order)
+	}
+}
+
+// NewPurchaseOrdersListGETHandler lists orders, latest first, optionally
+// narrowed to a supplier and a status.
+func NewPurchaseOrdersListGETHandler(service purchaseOrderService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := models.PurchaseOrderFilter{
+			Status: c.Query("status"),
+		}
+		switch filter.Status {
+		case "",
+			models.PurchaseOrderStatusDraft,
+			models.PurchaseOrderStatusSent,
+			models.PurchaseOrderStatusPartiallyReceived,
+			models.PurchaseOrderStatusReceived,
+			models.PurchaseOrderStatusCancelled:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+		if value := c.Query("supplier_id"); value != "" {
+			supplierID, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid supplier ID"})
+				return
+			}
+			filter.SupplierID = &supplierID
+		}
+
+		orders, err := service.GetPurchaseOrders(filter)
+		if err != nil {
+			log.Printf("[PurchaseOrdersListGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve purchase orders: " + err.Error()})
+			return
+		}
+		if orders == nil {
+			orders = []models.PurchaseOrderRetrieve{}
+		}
+
+		c.JSON(http.StatusOK, orders)
+	}
+}
+
+// NewPurchaseOrderRetrieveGETHandler returns an order with its lines and the
+// deliveries received against it.
+func NewPurchaseOrderRetrieveGETHandler(service purchaseOrderService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, ok := purchaseOrderIDParam(c)
+		if !ok {
+			return
+		}
+
+		order, err := service.GetPurchaseOrder(orderID)
+		if err != nil {
+			c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": "Failed to retrieve purchase order: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// NewPurchaseOrderUpdatePATCHHandler changes a draft order. Lines, when
+// given, replace all lines of the order.
+func NewPurchaseOrderUpdatePATCHHandler(service purchaseOrderService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, ok := purchaseOrderIDParam(c)
+		if !ok {
+			return
+		}
+
+		type request struct {
+			SupplierID *int                       `json:"supplier_id"`
+			Note       *string                    `json:"note" binding:"omitempty,max=255"`
+			Lines      []purchaseOrderLineRequest `json:"lines" binding:"omitempty,min=1,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[PurchaseOrderUpdatePATCH] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		if !validPurchaseOrderLines(req.Lines) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid expected cost"})
+			return
+		}
+
+		update := models.PurchaseOrderUpdate{
+			SupplierID: req.SupplierID,
+			Note:       req.Note,
+		}
+		if req.Lines != nil {
+			update.Lines = purchaseOrderLines(req.Lines)
+		}
+
+		order, err := service.UpdatePurchaseOrder(orderID, update)
+		if err != nil {
+			log.Printf("[PurchaseOrderUpdatePATCH] Service error: %v", err)
+			c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": "Failed to update purchase order: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// NewPurchaseOrderSendPOSTHandler marks a draft as sent to the supplier.
+func NewPurchaseOrderSendPOSTHandler(service purchaseOrderService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, ok := purchaseOrderIDParam(c)
+		if !ok {
+			return
+		}
+
+		order, err := service.SendPurchaseOrder(orderID)
+		if err != nil {
+			log.Printf("[PurchaseOrderSendPOST] Service error: %v", err)
+			c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": "Failed to send purchase order: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// NewPurchaseOrderCancelPOSTHandler cancels an order that is not fully
+// received.
+func NewPurchaseOrderCancelPOSTHandler(service purchaseOrderService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, ok := purchaseOrderIDParam(c)
+		if !ok {
+			return
+		}
+
+		order, err := service.CancelPurchaseOrder(orderID)
+		if err != nil {
+			log.Printf("[PurchaseOrderCancelPOST] Service error: %v", err)
+			c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": "Failed to cancel purchase order: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
+
+// NewPurchaseOrderReceivePOSTHandler books goods delivered against an order
+// into stock, received by the authenticated employee.
+func NewPurchaseOrderReceivePOSTHandler(service purchaseOrderService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orderID, ok := purchaseOrderIDParam(c)
+		if !ok {
+			return
+		}
+
+		type line struct {
+			UPC          *string       `json:"upc" binding:"required,len=12"`
+			Quantity     *int          `json:"quantity" binding:"required,gte=1"`
+			SellingPrice *models.Money `json:"new_price" binding:"omitempty,gte=0"`
+			ExpiryDate   *string       `json:"expiry_date" binding:"omitempty,datetime=2006-01-02"`
+		}
+		type request struct {
+			Lines []line `json:"lines" binding:"required,min=1,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[PurchaseOrderReceivePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		for _, l := range req.Lines {
+			if l.SellingPrice != nil && !utils.IsDecimalValid(*l.SellingPrice) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid new price for UPC " + *l.UPC})
+				return
+			}
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		receive := models.PurchaseOrderReceive{EmployeeId: employeeID}
+		for _, l := range req.Lines {
+			receive.Lines = append(receive.Lines, models.PurchaseOrderReceiveLine{
+				UPC:          *l.UPC,
+				Quantity:     *l.Quantity,
+				SellingPrice: l.SellingPrice,
+				ExpiryDate:   l.ExpiryDate,
+			})
+		}
+
+		order, err := service.ReceivePurchaseOrder(orderID, receive)
+		if err != nil {
+			log.Printf("[PurchaseOrderReceivePOST] Service error: %v", err)
+			c.JSON(purchaseOrderErrorStatus(err), gin.H{"error": "Failed to receive purchase order: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, order)
+	}
+}
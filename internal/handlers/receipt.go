@@ -1,28 +1,32 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/velosypedno/zlagoda/internal/config"
 	"github.com/velosypedno/zlagoda/internal/models"
-	"github.com/velosypedno/zlagoda/internal/utils"
+	"github.com/velosypedno/zlagoda/internal/services"
 )
 
 type receiptCreator interface {
 	CreateReceipt(c models.ReceiptCreate) (string, error)
 }
 
-func NewReceiptCreatePOSTHandler(service receiptCreator, cfg *config.Config) gin.HandlerFunc {
+// NewReceiptCreatePOSTHandler creates an empty receipt. Its totals are
+// derived from the sale lines added to it with POST /sales.
+func NewReceiptCreatePOSTHandler(service receiptCreator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type request struct {
-			EmployeeId *string  `json:"employee_id" binding:"required,len=10"`
-			CardNumber *string  `json:"card_number" binding:"omitempty,len=13"`
-			PrintDate  *string  `json:"print_date" binding:"required"`
-			TotalSum   *float64 `json:"sum_total" binding:"required,gte=0"`
+			EmployeeId *string    `json:"employee_id" binding:"required,len=10"`
+			CardNumber *string    `json:"card_number" binding:"omitempty,len=13"`
+			PrintDate  *time.Time `json:"print_date" binding:"required"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -31,49 +35,23 @@ func NewReceiptCreatePOSTHandler(service receiptCreator, cfg *config.Config) gin
 			return
 		}
 
-		var printDate time.Time
-		var parseError error = fmt.Errorf("no valid date format found")
-
-		// Try multiple date formats
-		formats := []string{
-			"2006-01-02",           // Date only
-			"2006-01-02T15:04:05",  // ISO format without timezone
-			"2006-01-02T15:04:05Z", // ISO format with Z
-			"2006-01-02 15:04:05",  // YYYY-MM-DD HH:MM:SS
-			"2006-01-02 15:04",     // YYYY-MM-DD HH:MM
-		}
-
-		for _, format := range formats {
-			if parsedTime, parseErr := time.Parse(format, *req.PrintDate); parseErr == nil {
-				printDate = parsedTime
-				parseError = nil
-				break
-			}
-		}
-
-		if parseError != nil {
-			log.Printf("[ReceiptCreatePOST] Failed to parse print_date '%s'", *req.PrintDate)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid print date format"})
-			return
-		}
-
-		if !utils.IsDecimalValid(*req.TotalSum) {
-			log.Printf("[ReceiptCreatePOST] Invalid total sum: %v", *req.TotalSum)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid total sum"})
-			return
-		}
-
-		var VAT float64 = cfg.VAT_RATE * *req.TotalSum
-
 		model := models.ReceiptCreate{
 			EmployeeId: req.EmployeeId,
 			CardNumber: req.CardNumber,
-			PrintDate:  &printDate,
-			TotalSum:   req.TotalSum,
-			VAT:        &VAT,
+			PrintDate:  req.PrintDate,
 		}
 
 		id, err := service.CreateReceipt(model)
+		if errors.Is(err, services.ErrUnknownCustomerCard) {
+			log.Printf("[ReceiptCreatePOST] Unknown customer card: %v", err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Failed to create receipt: " + err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrNoOpenShift) || errors.Is(err, services.ErrReceiptNumbersExhausted) {
+			log.Printf("[ReceiptCreatePOST] Rejected receipt: %v", err)
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to create receipt: " + err.Error()})
+			return
+		}
 		if err != nil {
 			log.Printf("[ReceiptCreatePOST] Service error: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create receipt: " + err.Error()})
@@ -85,20 +63,20 @@ func NewReceiptCreatePOSTHandler(service receiptCreator, cfg *config.Config) gin
 }
 
 type receiptCompleteCreator interface {
-	CreateReceiptComplete(c models.ReceiptCreateComplete, vatRate float64) (string, error)
+	CreateReceiptComplete(c models.ReceiptCreateComplete, vatRate models.Rate) (models.ReceiptCompleteResult, error)
 }
 
 func NewReceiptCreateCompletePOSTHandler(service receiptCompleteCreator, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type request struct {
-			EmployeeId *string `json:"employee_id" binding:"required,len=10"`
-			CardNumber *string `json:"card_number" binding:"omitempty,len=13"`
-			PrintDate  *string `json:"print_date" binding:"required"`
+			EmployeeId *string    `json:"employee_id" binding:"required,len=10"`
+			CardNumber *string    `json:"card_number" binding:"omitempty,len=13"`
+			PrintDate  *time.Time `json:"print_date" binding:"required"`
 			Items      []struct {
-				UPC           *string  `json:"upc" binding:"required,len=12"`
-				ProductNumber *int     `json:"product_number" binding:"required,gte=1"`
-				SellingPrice  *float64 `json:"selling_price" binding:"required,gte=0"`
-			} `json:"items" binding:"required,dive"`
+				UPC           *string `json:"upc" binding:"required,len=12"`
+				ProductNumber *int    `json:"product_number" binding:"required,gte=1"`
+			} `json:"items" binding:"required,min=1,dive"`
+			Payments []paymentRequest `json:"payments" binding:"required,min=1,dive"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -107,78 +85,90 @@ func NewReceiptCreateCompletePOSTHandler(service receiptCompleteCreator, cfg *co
 			return
 		}
 
-		var printDate time.Time
-		var parseError error = fmt.Errorf("no valid date format found")
-
-		// Try multiple date formats
-		formats := []string{
-			"2006-01-02",           // Date only
-			"2006-01-02T15:04:05",  // ISO format without timezone
-			"2006-01-02T15:04:05Z", // ISO format with Z
-			"2006-01-02 15:04:05",  // YYYY-MM-DD HH:MM:SS
-			"2006-01-02 15:04",     // YYYY-MM-DD HH:MM
-		}
-
-		for _, format := range formats {
-			if parsedTime, parseErr := time.Parse(format, *req.PrintDate); parseErr == nil {
-				printDate = parsedTime
-				parseError = nil
-				break
-			}
-		}
-
-		if parseError != nil {
-			log.Printf("[ReceiptCreateCompletePOST] Failed to parse print_date '%s'", *req.PrintDate)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid print date format"})
-			return
-		}
-
 		var items []models.ReceiptItem
 		for _, item := range req.Items {
-			if !utils.IsDecimalValid(*item.SellingPrice) {
-				log.Printf("[ReceiptCreateCompletePOST] Invalid selling price: %v", *item.SellingPrice)
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid selling price"})
-				return
-			}
 			items = append(items, models.ReceiptItem{
 				UPC:           item.UPC,
 				ProductNumber: item.ProductNumber,
-				SellingPrice:  item.SellingPrice,
 			})
 		}
 
 		model := models.ReceiptCreateComplete{
 			EmployeeId: req.EmployeeId,
 			CardNumber: req.CardNumber,
-			PrintDate:  &printDate,
+			PrintDate:  req.PrintDate,
 			Items:      items,
+			Payments:   paymentModels(req.Payments),
 		}
 
-		id, err := service.CreateReceiptComplete(model, cfg.VAT_RATE)
+		result, err := service.CreateReceiptComplete(model, cfg.VAT_RATE)
+		if errors.Is(err, services.ErrUnknownCustomerCard) {
+			log.Printf("[ReceiptCreateCompletePOST] Unknown customer card: %v", err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "Failed to create receipt: " + err.Error()})
+			return
+		}
+		var stockErr *services.InsufficientStockError
+		if errors.As(err, &stockErr) {
+			log.Printf("[ReceiptCreateCompletePOST] Insufficient stock: %v", err)
+			c.JSON(http.StatusConflict, gin.H{
+				"error":     "Failed to create receipt: " + err.Error(),
+				"shortages": stockErr.Shortages,
+			})
+			return
+		}
+		if errors.Is(err, services.ErrNoOpenShift) || errors.Is(err, services.ErrReceiptNumbersExhausted) {
+			log.Printf("[ReceiptCreateCompletePOST] Rejected receipt: %v", err)
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to create receipt: " + err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrTendersDoNotCover) || errors.Is(err, services.ErrNonCashOverpaid) {
+			log.Printf("[ReceiptCreateCompletePOST] Rejected payment: %v", err)
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to create receipt: " + err.Error()})
+			return
+		}
 		if err != nil {
 			log.Printf("[ReceiptCreateCompletePOST] Service error: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create receipt: " + err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusCreated, gin.H{"id": id})
+		c.JSON(http.StatusCreated, gin.H{
+			"id":               result.ReceiptNumber,
+			"subtotal":         result.Subtotal,
+			"discount_percent": result.DiscountPercent,
+			"discount_sum":     result.DiscountSum,
+			"sum_total":        result.TotalSum,
+			"vat":              result.VAT,
+			"vat_breakdown":    result.VATBreakdown,
+			"items":            result.Lines,
+			"payments":         result.Payments,
+			"change":           result.Change,
+		})
 	}
 }
 
 type receiptReader interface {
 	GetReceiptByReceiptNumber(receiptNumber string) (models.ReceiptRetrieve, error)
-	GetReceipts() ([]models.ReceiptRetrieve, error)
+	GetReceiptVAT(receiptNumber string) ([]models.ReceiptVATLine, error)
+	GetReceipts(f models.ReceiptFilter, sort string, desc bool, limit int, cursor string) ([]models.ReceiptRetrieve, string, error)
 }
 
-func NewReceiptRetrieveGETHandler(service receiptReader) gin.HandlerFunc {
+func NewReceiptRetrieveGETHandler(service receiptReader, pdf receiptPDFRenderer) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type response struct {
-			ReceiptNumber *string  `json:"receipt_number"`
-			EmployeeId    *string  `json:"employee_id"`
-			CardNumber    *string  `json:"card_number"`
-			PrintDate     *string  `json:"print_date"`
-			TotalSum      *float64 `json:"sum_total"`
-			VAT           *float64 `json:"vat"`
+			ReceiptNumber   *string                 `json:"receipt_number"`
+			EmployeeId      *string                 `json:"employee_id"`
+			CardNumber      *string                 `json:"card_number"`
+			PrintDate       *time.Time              `json:"print_date"`
+			TotalSum        *models.Money           `json:"sum_total"`
+			VAT             *models.Money           `json:"vat"`
+			DiscountPercent *int                    `json:"discount_percent"`
+			DiscountSum     *models.Money           `json:"discount_sum"`
+			VoidedAt        *time.Time              `json:"voided_at"`
+			VoidedBy        *string                 `json:"voided_by"`
+			VoidReason      *string                 `json:"void_reason"`
+			ShiftID         *int                    `json:"shift_id"`
+			VATBreakdown    []models.ReceiptVATLine `json:"vat_breakdown"`
 		}
 
 		receiptNumber := c.Param("receipt_number")
@@ -187,54 +177,188 @@ func NewReceiptRetrieveGETHandler(service receiptReader) gin.HandlerFunc {
 			return
 		}
 
+		if wantsPDF(c) {
+			body, err := pdf.RenderReceiptPDF(receiptNumber)
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
+				return
+			}
+			writePDF(c, "receipt-"+receiptNumber, body, err)
+			return
+		}
+
 		receipt, err := service.GetReceiptByReceiptNumber(receiptNumber)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found: " + err.Error()})
 			return
 		}
 
-		printDate := receipt.PrintDate.Format("2006-01-02 15:04:05")
+		breakdown, err := service.GetReceiptVAT(receiptNumber)
+		if err != nil {
+			log.Printf("[ReceiptRetrieveGET] Failed to retrieve VAT breakdown: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve VAT breakdown: " + err.Error()})
+			return
+		}
+		if breakdown == nil {
+			breakdown = []models.ReceiptVATLine{}
+		}
 
 		resp := response{
-			ReceiptNumber: receipt.ReceiptNumber,
-			EmployeeId:    receipt.EmployeeId,
-			CardNumber:    receipt.CardNumber,
-			PrintDate:     &printDate,
-			TotalSum:      receipt.TotalSum,
-			VAT:           receipt.VAT,
+			ReceiptNumber:   receipt.ReceiptNumber,
+			EmployeeId:      receipt.EmployeeId,
+			CardNumber:      receipt.CardNumber,
+			PrintDate:       receipt.PrintDate,
+			TotalSum:        receipt.TotalSum,
+			VAT:             receipt.VAT,
+			DiscountPercent: receipt.DiscountPercent,
+			DiscountSum:     receipt.DiscountSum,
+			VoidedAt:        receipt.VoidedAt,
+			VoidedBy:        receipt.VoidedBy,
+			VoidReason:      receipt.VoidReason,
+			ShiftID:         receipt.ShiftID,
+			VATBreakdown:    breakdown,
 		}
 
 		c.JSON(http.StatusOK, resp)
 	}
 }
 
-func NewReceiptsListGETHandler(service receiptReader) gin.HandlerFunc {
+// NewReceiptsListGETHandler lists receipts with optional filters, a sort
+// field and keyset pagination. The next cursor is null on the last page.
+func NewReceiptsListGETHandler(service receiptReader, cfg *config.Config) gin.HandlerFunc {
 	type responseItem struct {
-		ReceiptNumber *string  `json:"receipt_number"`
-		EmployeeId    *string  `json:"employee_id"`
-		CardNumber    *string  `json:"card_number"`
-		PrintDate     *string  `json:"print_date"`
-		TotalSum      *float64 `json:"sum_total"`
-		VAT           *float64 `json:"vat"`
+		ReceiptNumber   *string       `json:"receipt_number"`
+		EmployeeId      *string       `json:"employee_id"`
+		CardNumber      *string       `json:"card_number"`
+		PrintDate       *time.Time    `json:"print_date"`
+		TotalSum        *models.Money `json:"sum_total"`
+		VAT             *models.Money `json:"vat"`
+		DiscountPercent *int          `json:"discount_percent"`
+		DiscountSum     *models.Money `json:"discount_sum"`
+		VoidedAt        *time.Time    `json:"voided_at"`
+		VoidedBy        *string       `json:"voided_by"`
+		VoidReason      *string       `json:"void_reason"`
+		ShiftID         *int          `json:"shift_id"`
+	}
+	type response struct {
+		Receipts []responseItem `json:"receipts"`
+		Next     *string        `json:"next"`
 	}
 
 	return func(c *gin.Context) {
-		receipts, err := service.GetReceipts()
+		var filter models.ReceiptFilter
+
+		if employeeID := c.Query("employee_id"); employeeID != "" {
+			if len(employeeID) != 10 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+				return
+			}
+			filter.EmployeeId = &employeeID
+		}
+		if cardNumber := c.Query("card_number"); cardNumber != "" {
+			if len(cardNumber) != 13 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid card number"})
+				return
+			}
+			filter.CardNumber = &cardNumber
+		}
+
+		if from := c.Query("from"); from != "" {
+			t, err := timeFilter(from, false, cfg.STORE_LOCATION)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from parameter, use YYYY-MM-DD or an RFC 3339 timestamp"})
+				return
+			}
+			filter.PrintDateFrom = &t
+		}
+		if to := c.Query("to"); to != "" {
+			t, err := timeFilter(to, true, cfg.STORE_LOCATION)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to parameter, use YYYY-MM-DD or an RFC 3339 timestamp"})
+				return
+			}
+			filter.PrintDateTo = &t
+		}
+
+		for _, bound := range []struct {
+			name string
+			dst  **models.Money
+		}{{"min_total", &filter.MinTotal}, {"max_total", &filter.MaxTotal}} {
+			value := c.Query(bound.name)
+			if value == "" {
+				continue
+			}
+			total, err := models.ParseMoney(value)
+			if err != nil || total.IsNegative() {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + bound.name + " parameter"})
+				return
+			}
+			*bound.dst = &total
+		}
+
+		voided := c.DefaultQuery("voided", "false")
+		if c.Query("include_voided") == "true" {
+			voided = "all"
+		}
+		switch voided {
+		case "true", "false":
+			v := voided == "true"
+			filter.Voided = &v
+		case "all":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid voided parameter, use true, false or all"})
+			return
+		}
+
+		var desc bool
+		switch c.DefaultQuery("order", "desc") {
+		case "desc":
+			desc = true
+		case "asc":
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order parameter, use asc or desc"})
+			return
+		}
+
+		limit := services.DefaultReceiptPageSize
+		if value := c.Query("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > services.MaxReceiptPageSize {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid limit parameter, use 1..%d", services.MaxReceiptPageSize)})
+				return
+			}
+			limit = n
+		}
+
+		receipts, next, err := service.GetReceipts(filter, c.DefaultQuery("sort", "print_date"), desc, limit, c.Query("cursor"))
+		if errors.Is(err, services.ErrInvalidReceiptSort) || errors.Is(err, services.ErrInvalidReceiptCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		if err != nil {
+			log.Printf("[ReceiptsListGET] Service error: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve receipts: " + err.Error()})
 			return
 		}
 
-		var resp []responseItem
+		resp := response{Receipts: []responseItem{}}
+		if next != "" {
+			resp.Next = &next
+		}
 		for _, receipt := range receipts {
-			printDate := receipt.PrintDate.Format("2006-01-02 15:04:05")
-			resp = append(resp, responseItem{
-				ReceiptNumber: receipt.ReceiptNumber,
-				EmployeeId:    receipt.EmployeeId,
-				CardNumber:    receipt.CardNumber,
-				PrintDate:     &printDate,
-				TotalSum:      receipt.TotalSum,
-				VAT:           receipt.VAT,
+			resp.Receipts = append(resp.Receipts, responseItem{
+				ReceiptNumber:   receipt.ReceiptNumber,
+				EmployeeId:      receipt.EmployeeId,
+				CardNumber:      receipt.CardNumber,
+				PrintDate:       receipt.PrintDate,
+				TotalSum:        receipt.TotalSum,
+				VAT:             receipt.VAT,
+				DiscountPercent: receipt.DiscountPercent,
+				DiscountSum:     receipt.DiscountSum,
+				VoidedAt:        receipt.VoidedAt,
+				VoidedBy:        receipt.VoidedBy,
+				VoidReason:      receipt.VoidReason,
+				ShiftID:         receipt.ShiftID,
 			})
 		}
 
@@ -255,6 +379,10 @@ func NewReceiptDeleteDELETEHandler(service receiptRemover) gin.HandlerFunc {
 		}
 
 		err := service.DeleteReceipt(receiptNumber)
+		if errors.Is(err, services.ErrReceiptHasSales) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to delete receipt: " + err.Error()})
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete receipt: " + err.Error()})
 			return
@@ -269,7 +397,9 @@ type receiptUpdater interface {
 	GetReceiptByReceiptNumber(receiptNumber string) (models.ReceiptRetrieve, error)
 }
 
-func NewReceiptUpdatePATCHHandler(service receiptUpdater, cfg *config.Config) gin.HandlerFunc {
+// NewReceiptUpdatePATCHHandler changes the receipt header. sum_total and vat
+// are derived from the sale lines and cannot be set.
+func NewReceiptUpdatePATCHHandler(service receiptUpdater) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		receiptNumber := c.Param("receipt_number")
 		if len(receiptNumber) != 10 {
@@ -278,10 +408,9 @@ func NewReceiptUpdatePATCHHandler(service receiptUpdater, cfg *config.Config) gi
 		}
 
 		type request struct {
-			EmployeeId *string  `json:"employee_id" binding:"omitempty,len=10"`
-			CardNumber *string  `json:"card_number" binding:"omitempty,len=13"`
-			PrintDate  *string  `json:"print_date"`
-			TotalSum   *float64 `json:"sum_total" binding:"omitempty,gte=0"`
+			EmployeeId *string    `json:"employee_id" binding:"omitempty,len=10"`
+			CardNumber *string    `json:"card_number" binding:"omitempty,len=13"`
+			PrintDate  *time.Time `json:"print_date"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -295,7 +424,6 @@ func NewReceiptUpdatePATCHHandler(service receiptUpdater, cfg *config.Config) gi
 			return
 		}
 
-		currentPrintDateStr := receiptCurrentState.PrintDate.Format("2006-01-02 15:04:05")
 		if req.EmployeeId == nil {
 			req.EmployeeId = receiptCurrentState.EmployeeId
 		}
@@ -303,59 +431,78 @@ func NewReceiptUpdatePATCHHandler(service receiptUpdater, cfg *config.Config) gi
 			req.CardNumber = receiptCurrentState.CardNumber
 		}
 		if req.PrintDate == nil {
-			req.PrintDate = &currentPrintDateStr
+			req.PrintDate = receiptCurrentState.PrintDate
 		}
-		if req.TotalSum == nil {
-			req.TotalSum = receiptCurrentState.TotalSum
-		}
-
-		var printDate time.Time
-		var parseError error = fmt.Errorf("no valid date format found")
 
-		// Try multiple date formats
-		formats := []string{
-			"2006-01-02",           // Date only
-			"2006-01-02T15:04:05",  // ISO format without timezone
-			"2006-01-02T15:04:05Z", // ISO format with Z
-			"2006-01-02 15:04:05",  // YYYY-MM-DD HH:MM:SS
-			"2006-01-02 15:04",     // YYYY-MM-DD HH:MM
+		model := models.ReceiptUpdate{
+			EmployeeId: req.EmployeeId,
+			CardNumber: req.CardNumber,
+			PrintDate:  req.PrintDate,
 		}
 
-		for _, format := range formats {
-			if parsedTime, parseErr := time.Parse(format, *req.PrintDate); parseErr == nil {
-				printDate = parsedTime
-				parseError = nil
-				break
-			}
+		err = service.UpdateReceipt(receiptNumber, model)
+		if errors.Is(err, sql.ErrNoRows) || errors.Is(err, services.ErrUnknownCustomerCard) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Failed to update receipt: " + err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrReceiptVoided) || errors.Is(err, services.ErrReceiptPaidMismatch) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to update receipt: " + err.Error()})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update receipt: " + err.Error()})
+			return
 		}
 
-		if parseError != nil {
-			log.Printf("[ReceiptUpdatePATCH] Failed to parse print_date '%s'", *req.PrintDate)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid print date format"})
+		c.JSON(http.StatusOK, gin.H{"message": "Receipt updated successfully"})
+	}
+}
+
+type receiptVoider interface {
+	VoidReceipt(receiptNumber string, v models.ReceiptVoid) error
+}
+
+func NewReceiptVoidPOSTHandler(service receiptVoider) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receiptNumber := c.Param("receipt_number")
+		if len(receiptNumber) != 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid receipt number"})
 			return
 		}
 
-		if !utils.IsDecimalValid(*req.TotalSum) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: invalid total sum"})
+		type request struct {
+			Reason *string `json:"reason" binding:"required,min=1,max=255"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
 			return
 		}
 
-		var VAT float64 = cfg.VAT_RATE * *req.TotalSum
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
 
-		model := models.ReceiptUpdate{
-			EmployeeId: req.EmployeeId,
-			CardNumber: req.CardNumber,
-			PrintDate:  &printDate,
-			TotalSum:   req.TotalSum,
-			VAT:        &VAT,
+		voidedAt := time.Now()
+		model := models.ReceiptVoid{
+			VoidedBy: &employeeID,
+			VoidedAt: &voidedAt,
+			Reason:   req.Reason,
 		}
 
-		err = service.UpdateReceipt(receiptNumber, model)
+		err := service.VoidReceipt(receiptNumber, model)
+		if errors.Is(err, services.ErrReceiptVoided) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to void receipt: " + err.Error()})
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update receipt: " + err.Error()})
+			log.Printf("[ReceiptVoidPOST] Service error for receipt %s: %v", receiptNumber, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to void receipt: " + err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{"message": "Receipt updated successfully"})
+		c.JSON(http.StatusOK, gin.H{"message": "Receipt voided successfully"})
 	}
 }
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+type receiptRenderer interface {
+	RenderReceiptText(receiptNumber string, width int) (string, error)
+	RenderReceiptESCPOS(receiptNumber string, width int) ([]byte, error)
+}
+
+// NewReceiptPrintGETHandler renders a receipt for a till printer. format is
+// "text" (default) or "escpos"; width overrides RECEIPT_WIDTH in columns.
+func NewReceiptPrintGETHandler(service receiptRenderer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receiptNumber := c.Param("receipt_number")
+		if len(receiptNumber) != 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid receipt number"})
+			return
+		}
+
+		width := 0
+		if widthStr := c.Query("width"); widthStr != "" {
+			parsed, err := strconv.Atoi(widthStr)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid width parameter"})
+				return
+			}
+			width = parsed
+		}
+
+		var (
+			body        []byte
+			contentType string
+			err         error
+		)
+		switch c.DefaultQuery("format", "text") {
+		case "text":
+			var text string
+			text, err = service.RenderReceiptText(receiptNumber, width)
+			body, contentType = []byte(text), "text/plain; charset=utf-8"
+		case "escpos":
+			body, err = service.RenderReceiptESCPOS(receiptNumber, width)
+			contentType = "application/octet-stream"
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format parameter, expected text or escpos"})
+			return
+		}
+
+		if errors.Is(err, services.ErrInvalidReceiptWidth) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
+			return
+		}
+		if err != nil {
+			log.Printf("[ReceiptPrintGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render receipt: " + err.Error()})
+			return
+		}
+
+		c.Data(http.StatusOK, contentType, body)
+	}
+}
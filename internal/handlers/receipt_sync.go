@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type receiptSyncer interface {
+	SyncReceipts(items []models.ReceiptSyncItem) []models.ReceiptSyncResult
+	GetConflicts() ([]models.ReceiptSyncConflict, error)
+	GetTenderMismatches() ([]models.ReceiptSyncTenderMismatch, error)
+}
+
+// NewReceiptSyncPOSTHandler uploads the receipts a till sold while offline.
+// Every receipt gets its own result, so the response is 200 even when some
+// of them failed.
+func NewReceiptSyncPOSTHandler(service receiptSyncer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type receipt struct {
+			ClientID   *string    `json:"client_id" binding:"required,min=1,max=64"`
+			EmployeeId *string    `json:"employee_id" binding:"required,len=10"`
+			CardNumber *string    `json:"card_number" binding:"omitempty,len=13"`
+			PrintDate  *time.Time `json:"print_date" binding:"required"`
+			Items      []struct {
+				UPC           *string `json:"upc" binding:"required,len=12"`
+				ProductNumber *int    `json:"product_number" binding:"required,gte=1"`
+			} `json:"items" binding:"required,min=1,dive"`
+			Payments []paymentRequest `json:"payments" binding:"required,min=1,dive"`
+		}
+		type request struct {
+			Receipts []receipt `json:"receipts" binding:"required,min=1,max=500,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[ReceiptSyncPOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		items := make([]models.ReceiptSyncItem, 0, len(req.Receipts))
+		for _, r := range req.Receipts {
+			var receiptItems []models.ReceiptItem
+			for _, item := range r.Items {
+				receiptItems = append(receiptItems, models.ReceiptItem{
+					UPC:           item.UPC,
+					ProductNumber: item.ProductNumber,
+				})
+			}
+			items = append(items, models.ReceiptSyncItem{
+				ClientID: *r.ClientID,
+				Receipt: models.ReceiptCreateComplete{
+					EmployeeId: r.EmployeeId,
+					CardNumber: r.CardNumber,
+					PrintDate:  r.PrintDate,
+					Items:      receiptItems,
+					Payments:   paymentModels(r.Payments),
+				},
+			})
+		}
+
+		results := service.SyncReceipts(items)
+		for _, result := range results {
+			if result.Status == models.ReceiptSyncStatusFailed {
+				log.Printf("[ReceiptSyncPOST] Receipt %s failed: %s", result.ClientID, *result.Error)
+			}
+		}
+
+		c.JSON(http.StatusOK, gin.H{"results": results})
+	}
+}
+
+// NewReceiptSyncConflictsGETHandler lists the UPCs offline receipts sold
+// beyond the stock on hand, for a stock check.
+func NewReceiptSyncConflictsGETHandler(service receiptSyncer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conflicts, err := service.GetConflicts()
+		if err != nil {
+			log.Printf("[ReceiptSyncConflictsGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sync conflicts: " + err.Error()})
+			return
+		}
+		if conflicts == nil {
+			conflicts = []models.ReceiptSyncConflict{}
+		}
+
+		c.JSON(http.StatusOK, conflicts)
+	}
+}
+
+// NewReceiptSyncTenderMismatchesGETHandler lists the offline receipts whose
+// payments did not match their total at the prices they were synced at.
+func NewReceiptSyncTenderMismatchesGETHandler(service receiptSyncer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mismatches, err := service.GetTenderMismatches()
+		if err != nil {
+			log.Printf("[ReceiptSyncTenderMismatchesGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tender mismatches: " + err.Error()})
+			return
+		}
+		if mismatches == nil {
+			mismatches = []models.ReceiptSyncTenderMismatch{}
+		}
+
+		c.JSON(http.StatusOK, mismatches)
+	}
+}
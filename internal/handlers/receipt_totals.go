@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+type receiptTotalsAuditor interface {
+	FindMismatches() ([]models.ReceiptTotalsMismatch, error)
+	RepairMismatches() ([]models.ReceiptTotalsMismatch, error)
+}
+
+// NewReceiptTotalsMismatchesGETHandler lists the receipts whose stored totals
+// or line VAT differ from the ones derived from their sale lines.
+func NewReceiptTotalsMismatchesGETHandler(service receiptTotalsAuditor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mismatches, err := service.FindMismatches()
+		if err != nil {
+			log.Printf("[ReceiptTotalsMismatchesGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check receipt totals: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"count":      len(mismatches),
+			"mismatches": mismatches,
+		})
+	}
+}
+
+// NewReceiptTotalsRepairPOSTHandler recalculates every mismatched receipt from
+// its sale lines.
+func NewReceiptTotalsRepairPOSTHandler(service receiptTotalsAuditor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repaired, err := service.RepairMismatches()
+		if err != nil {
+			log.Printf("[ReceiptTotalsRepairPOST] Service error after %d receipts: %v", len(repaired), err)
+			status := http.StatusInternalServerError
+			if errors.Is(err, services.ErrReceiptPaidMismatch) {
+				status = http.StatusConflict
+			}
+			c.JSON(status, gin.H{
+				"error":    "Failed to repair receipt totals: " + err.Error(),
+				"repaired": repaired,
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"count":    len(repaired),
+			"repaired": repaired,
+		})
+	}
+}
@@ -16,7 +16,7 @@ type RegisterPayload struct {
 	Name          string  `json:"name" binding:"required"`
 	Patronymic    *string `json:"patronymic"`
 	Role          string  `json:"role" binding:"required"`
-	Salary        float64 `json:"salary" binding:"required,min=0"`
+	Salary        models.Money `json:"salary" binding:"required,min=0"`
 	DateOfBirth   string  `json:"date_of_birth" binding:"required"`
 	DateOfStart   string  `json:"date_of_start" binding:"required"`
 	PhoneNumber   string  `json:"phone_number" binding:"required"`
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+type returnCreator interface {
+	CreateReturn(c models.ReturnCreate) (models.ReturnRetrieve, error)
+}
+
+func NewReturnCreatePOSTHandler(service returnCreator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type request struct {
+			ReceiptNumber *string `json:"receipt_number" binding:"required,len=10"`
+			Reason        *string `json:"reason" binding:"required,min=1,max=255"`
+			Items         []struct {
+				UPC           *string `json:"upc" binding:"required,len=12"`
+				ProductNumber *int    `json:"product_number" binding:"required,gte=1"`
+			} `json:"items" binding:"required,min=1,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[ReturnCreatePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		var items []models.ReturnItem
+		for _, item := range req.Items {
+			items = append(items, models.ReturnItem{
+				UPC:           item.UPC,
+				ProductNumber: item.ProductNumber,
+			})
+		}
+
+		returnDate := time.Now()
+		model := models.ReturnCreate{
+			ReceiptNumber: req.ReceiptNumber,
+			EmployeeId:    &employeeID,
+			ReturnDate:    &returnDate,
+			Reason:        req.Reason,
+			Items:         items,
+		}
+
+		ret, err := service.CreateReturn(model)
+		if errors.Is(err, services.ErrReturnExceedsSold) || errors.Is(err, services.ErrReceiptVoided) {
+			log.Printf("[ReturnCreatePOST] Rejected return: %v", err)
+			c.JSON(http.StatusConflict, gin.H{"error": "Failed to create return: " + err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("[ReturnCreatePOST] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create return: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, ret)
+	}
+}
+
+type returnReader interface {
+	GetReturnByReturnNumber(returnNumber string) (models.ReturnRetrieve, error)
+	GetReturns() ([]models.ReturnRetrieve, error)
+	GetReturnsByReceipt(receiptNumber string) ([]models.ReturnRetrieve, error)
+}
+
+func NewReturnRetrieveGETHandler(service returnReader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		returnNumber := c.Param("return_number")
+		if len(returnNumber) != 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return number"})
+			return
+		}
+
+		ret, err := service.GetReturnByReturnNumber(returnNumber)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Return not found: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, ret)
+	}
+}
+
+func NewReturnsListGETHandler(service returnReader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		returns, err := service.GetReturns()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve returns: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, returns)
+	}
+}
+
+func NewReturnsByReceiptGETHandler(service returnReader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receiptNumber := c.Param("receipt_number")
+		if len(receiptNumber) != 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid receipt number"})
+			return
+		}
+
+		returns, err := service.GetReturnsByReceipt(receiptNumber)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve returns: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, returns)
+	}
+}
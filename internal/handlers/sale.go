@@ -1,14 +1,34 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
 	"github.com/velosypedno/zlagoda/internal/utils"
 )
 
+// saleErrorStatus maps errors of line changes, which also recalculate the
+// receipt, to HTTP status codes.
+func saleErrorStatus(err error) int {
+	var stockErr *services.InsufficientStockError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrReceiptVoided),
+		errors.Is(err, services.ErrReceiptLinesFinal),
+		errors.Is(err, services.ErrReceiptPaidMismatch),
+		errors.As(err, &stockErr):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 type saleCreator interface {
 	CreateSale(s models.SaleCreate) error
 }
@@ -16,10 +36,10 @@ type saleCreator interface {
 func NewSaleCreatePOSTHandler(service saleCreator) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type request struct {
-			UPC           string  `json:"upc" binding:"required,len=12"`
-			ReceiptNumber string  `json:"receipt_number" binding:"required,len=10"`
-			ProductNumber int     `json:"product_number" binding:"required,gte=1"`
-			SellingPrice  float64 `json:"selling_price" binding:"required,gte=0"`
+			UPC           string       `json:"upc" binding:"required,len=12"`
+			ReceiptNumber string       `json:"receipt_number" binding:"required,len=10"`
+			ProductNumber int          `json:"product_number" binding:"required,gte=1"`
+			SellingPrice  models.Money `json:"selling_price" binding:"required,gte=0"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -41,7 +61,7 @@ func NewSaleCreatePOSTHandler(service saleCreator) gin.HandlerFunc {
 
 		err := service.CreateSale(model)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create sale: " + err.Error()})
+			c.JSON(saleErrorStatus(err), gin.H{"error": "Failed to create sale: " + err.Error()})
 			return
 		}
 
@@ -61,10 +81,12 @@ type saleReader interface {
 func NewSaleRetrieveGETHandler(service saleReader) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type response struct {
-			UPC           string  `json:"upc"`
-			ReceiptNumber string  `json:"receipt_number"`
-			ProductNumber int     `json:"product_number"`
-			SellingPrice  float64 `json:"selling_price"`
+			UPC           string       `json:"upc"`
+			ReceiptNumber string       `json:"receipt_number"`
+			ProductNumber int          `json:"product_number"`
+			SellingPrice  models.Money `json:"selling_price"`
+			VATRate       models.Rate  `json:"vat_rate"`
+			VATSum        models.Money `json:"vat_sum"`
 		}
 
 		upc := c.Param("upc")
@@ -91,6 +113,8 @@ func NewSaleRetrieveGETHandler(service saleReader) gin.HandlerFunc {
 			ReceiptNumber: sale.ReceiptNumber,
 			ProductNumber: sale.ProductNumber,
 			SellingPrice:  sale.SellingPrice,
+			VATRate:       sale.VATRate,
+			VATSum:        sale.VATSum,
 		}
 
 		c.JSON(http.StatusOK, resp)
@@ -99,10 +123,12 @@ func NewSaleRetrieveGETHandler(service saleReader) gin.HandlerFunc {
 
 func NewSalesByReceiptGETHandler(service saleReader) gin.HandlerFunc {
 	type responseItem struct {
-		UPC           string  `json:"upc"`
-		ReceiptNumber string  `json:"receipt_number"`
-		ProductNumber int     `json:"product_number"`
-		SellingPrice  float64 `json:"selling_price"`
+		UPC           string       `json:"upc"`
+		ReceiptNumber string       `json:"receipt_number"`
+		ProductNumber int          `json:"product_number"`
+		SellingPrice  models.Money `json:"selling_price"`
+		VATRate       models.Rate  `json:"vat_rate"`
+		VATSum        models.Money `json:"vat_sum"`
 	}
 
 	return func(c *gin.Context) {
@@ -125,6 +151,8 @@ func NewSalesByReceiptGETHandler(service saleReader) gin.HandlerFunc {
 				ReceiptNumber: sale.ReceiptNumber,
 				ProductNumber: sale.ProductNumber,
 				SellingPrice:  sale.SellingPrice,
+				VATRate:       sale.VATRate,
+				VATSum:        sale.VATSum,
 			})
 		}
 
@@ -134,10 +162,12 @@ func NewSalesByReceiptGETHandler(service saleReader) gin.HandlerFunc {
 
 func NewSalesByUPCGETHandler(service saleReader) gin.HandlerFunc {
 	type responseItem struct {
-		UPC           string  `json:"upc"`
-		ReceiptNumber string  `json:"receipt_number"`
-		ProductNumber int     `json:"product_number"`
-		SellingPrice  float64 `json:"selling_price"`
+		UPC           string       `json:"upc"`
+		ReceiptNumber string       `json:"receipt_number"`
+		ProductNumber int          `json:"product_number"`
+		SellingPrice  models.Money `json:"selling_price"`
+		VATRate       models.Rate  `json:"vat_rate"`
+		VATSum        models.Money `json:"vat_sum"`
 	}
 
 	return func(c *gin.Context) {
@@ -160,6 +190,8 @@ func NewSalesByUPCGETHandler(service saleReader) gin.HandlerFunc {
 				ReceiptNumber: sale.ReceiptNumber,
 				ProductNumber: sale.ProductNumber,
 				SellingPrice:  sale.SellingPrice,
+				VATRate:       sale.VATRate,
+				VATSum:        sale.VATSum,
 			})
 		}
 
@@ -169,10 +201,12 @@ func NewSalesByUPCGETHandler(service saleReader) gin.HandlerFunc {
 
 func NewSalesListGETHandler(service saleReader) gin.HandlerFunc {
 	type responseItem struct {
-		UPC           string  `json:"upc"`
-		ReceiptNumber string  `json:"receipt_number"`
-		ProductNumber int     `json:"product_number"`
-		SellingPrice  float64 `json:"selling_price"`
+		UPC           string       `json:"upc"`
+		ReceiptNumber string       `json:"receipt_number"`
+		ProductNumber int          `json:"product_number"`
+		SellingPrice  models.Money `json:"selling_price"`
+		VATRate       models.Rate  `json:"vat_rate"`
+		VATSum        models.Money `json:"vat_sum"`
 	}
 
 	return func(c *gin.Context) {
@@ -189,6 +223,8 @@ func NewSalesListGETHandler(service saleReader) gin.HandlerFunc {
 				ReceiptNumber: sale.ReceiptNumber,
 				ProductNumber: sale.ProductNumber,
 				SellingPrice:  sale.SellingPrice,
+				VATRate:       sale.VATRate,
+				VATSum:        sale.VATSum,
 			})
 		}
 
@@ -247,8 +283,8 @@ func NewSaleUpdatePATCHHandler(service saleUpdater) gin.HandlerFunc {
 		}
 
 		type request struct {
-			ProductNumber *int     `json:"product_number" binding:"omitempty,gte=1"`
-			SellingPrice  *float64 `json:"selling_price" binding:"omitempty,gte=0"`
+			ProductNumber *int          `json:"product_number" binding:"omitempty,gte=1"`
+			SellingPrice  *models.Money `json:"selling_price" binding:"omitempty,gte=0"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -274,7 +310,7 @@ func NewSaleUpdatePATCHHandler(service saleUpdater) gin.HandlerFunc {
 
 		err = service.UpdateSale(upc, receiptNumber, model)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update sale: " + err.Error()})
+			c.JSON(saleErrorStatus(err), gin.H{"error": "Failed to update sale: " + err.Error()})
 			return
 		}
 
@@ -304,7 +340,7 @@ func NewSaleDeleteDELETEHandler(service saleRemover) gin.HandlerFunc {
 
 		err := service.DeleteSale(upc, receiptNumber)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sale: " + err.Error()})
+			c.JSON(saleErrorStatus(err), gin.H{"error": "Failed to delete sale: " + err.Error()})
 			return
 		}
 
@@ -322,7 +358,7 @@ func NewSalesByReceiptDeleteDELETEHandler(service saleRemover) gin.HandlerFunc {
 
 		err := service.DeleteSalesByReceipt(receiptNumber)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete sales: " + err.Error()})
+			c.JSON(saleErrorStatus(err), gin.H{"error": "Failed to delete sales: " + err.Error()})
 			return
 		}
 
@@ -331,13 +367,13 @@ func NewSalesByReceiptDeleteDELETEHandler(service saleRemover) gin.HandlerFunc {
 }
 
 type saleAnalytics interface {
-	GetReceiptTotal(receiptNumber string) (float64, error)
-	GetSalesStatsByProduct(productID int, startDate, endDate string) (int, float64, error)
-	GetTopSellingProducts(limit int) ([]struct {
-		ProductID    int     `json:"product_id"`
-		ProductName  string  `json:"product_name"`
-		TotalSold    int     `json:"total_sold"`
-		TotalRevenue float64 `json:"total_revenue"`
+	GetReceiptTotal(receiptNumber string) (models.Money, error)
+	GetSalesStatsByProduct(productID int, startDate, endDate string) (int, models.Money, error)
+	GetTopSellingProducts(limit int, includeVoided bool) ([]struct {
+		ProductID    int          `json:"product_id"`
+		ProductName  string       `json:"product_name"`
+		TotalSold    int          `json:"total_sold"`
+		TotalRevenue models.Money `json:"total_revenue"`
 	}, error)
 }
 
@@ -404,7 +440,9 @@ func NewTopSellingProductsGETHandler(service saleAnalytics) gin.HandlerFunc {
 			return
 		}
 
-		products, err := service.GetTopSellingProducts(limit)
+		includeVoided := c.Query("include_voided") == "true"
+
+		products, err := service.GetTopSellingProducts(limit, includeVoided)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get top selling products: " + err.Error()})
 			return
@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+type shiftService interface {
+	OpenShift(c models.ShiftCreate) (models.ShiftRetrieve, error)
+	GetShiftByID(shiftID int) (models.ShiftRetrieve, error)
+	GetOpenShift(employeeID string) (models.ShiftRetrieve, error)
+	GetShifts(f models.ShiftFilter) ([]models.ShiftRetrieve, error)
+	GetXReport(shiftID int) (models.ShiftReport, error)
+	GetZReport(shiftID int) (models.ShiftReport, error)
+	CloseShift(shiftID int, closedBy string) (models.ShiftReport, error)
+}
+
+// shiftErrorStatus maps shift service errors to HTTP status codes.
+func shiftErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, services.ErrNoOpenShift):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrRegisterIDTooLong):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrShiftAlreadyOpen),
+		errors.Is(err, services.ErrShiftClosed),
+		errors.Is(err, services.ErrShiftNotClosed):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func shiftIDParam(c *gin.Context) (int, bool) {
+	shiftID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift ID"})
+		return 0, false
+	}
+	return shiftID, true
+}
+
+// NewShiftOpenPOSTHandler opens a shift for the authenticated cashier.
+func NewShiftOpenPOSTHandler(service shiftService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type request struct {
+			RegisterId   *string       `json:"register_id" binding:"required,alphanum,max=10"`
+			OpeningFloat *models.Money `json:"opening_float" binding:"required,gte=0"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[ShiftOpenPOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		shift, err := service.OpenShift(models.ShiftCreate{
+			RegisterId:   req.RegisterId,
+			EmployeeId:   &employeeID,
+			OpeningFloat: req.OpeningFloat,
+		})
+		if err != nil {
+			log.Printf("[ShiftOpenPOST] Service error: %v", err)
+			c.JSON(shiftErrorStatus(err), gin.H{"error": "Failed to open shift: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, shift)
+	}
+}
+
+// NewShiftCurrentGETHandler returns the authenticated cashier's open shift.
+func NewShiftCurrentGETHandler(service shiftService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		shift, err := service.GetOpenShift(employeeID)
+		if err != nil {
+			c.JSON(shiftErrorStatus(err), gin.H{"error": "Failed to retrieve shift: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, shift)
+	}
+}
+
+// NewShiftsListGETHandler lists shifts, latest first, optionally narrowed to
+// a register, a cashier, and open or closed shifts.
+func NewShiftsListGETHandler(service shiftService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := models.ShiftFilter{
+			RegisterId: c.Query("register_id"),
+			EmployeeId: c.Query("employee_id"),
+		}
+		if filter.EmployeeId != "" && len(filter.EmployeeId) != 10 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid employee ID"})
+			return
+		}
+		if value := c.Query("open"); value != "" {
+			open, err := strconv.ParseBool(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid open parameter, use true or false"})
+				return
+			}
+			filter.Open = &open
+		}
+
+		shifts, err := service.GetShifts(filter)
+		if err != nil {
+			log.Printf("[ShiftsListGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve shifts: " + err.Error()})
+			return
+		}
+		if shifts == nil {
+			shifts = []models.ShiftRetrieve{}
+		}
+
+		c.JSON(http.StatusOK, shifts)
+	}
+}
+
+func NewShiftRetrieveGETHandler(service shiftService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shiftID, ok := shiftIDParam(c)
+		if !ok {
+			return
+		}
+
+		shift, err := service.GetShiftByID(shiftID)
+		if err != nil {
+			c.JSON(shiftErrorStatus(err), gin.H{"error": "Failed to retrieve shift: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, shift)
+	}
+}
+
+// NewShiftXReportGETHandler reports the running totals of an open shift.
+func NewShiftXReportGETHandler(service shiftService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shiftID, ok := shiftIDParam(c)
+		if !ok {
+			return
+		}
+
+		report, err := service.GetXReport(shiftID)
+		if err != nil {
+			log.Printf("[ShiftXReportGET] Service error: %v", err)
+			c.JSON(shiftErrorStatus(err), gin.H{"error": "Failed to build X-report: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// NewShiftClosePOSTHandler closes the shift and returns its Z-report.
+func NewShiftClosePOSTHandler(service shiftService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shiftID, ok := shiftIDParam(c)
+		if !ok {
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		report, err := service.CloseShift(shiftID, employeeID)
+		if err != nil {
+			log.Printf("[ShiftClosePOST] Service error: %v", err)
+			c.JSON(shiftErrorStatus(err), gin.H{"error": "Failed to close shift: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// NewShiftZReportGETHandler reprints the Z-report of a closed shift.
+func NewShiftZReportGETHandler(service shiftService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shiftID, ok := shiftIDParam(c)
+		if !ok {
+			return
+		}
+
+		report, err := service.GetZReport(shiftID)
+		if err != nil {
+			log.Printf("[ShiftZReportGET] Service error: %v", err)
+			c.JSON(shiftErrorStatus(err), gin.H{"error": "Failed to build Z-report: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
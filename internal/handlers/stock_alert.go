@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+type stockAlertService interface {
+	GetReorderLevels(productID int) (models.ReorderLevels, error)
+	SetReorderLevels(productID int, levels *models.ReorderLevels) error
+	GetLowStock(f models.LowStockFilter) ([]models.LowStockProduct, error)
+	GetStockAlerts(status string) ([]models.StockAlert, error)
+	ResolveStockAlert(alertID int, employeeID string) (models.StockAlert, error)
+}
+
+// defaultVelocityDays is the window sales velocity is measured over in the
+// low-stock report when days is not given.
+const defaultVelocityDays = 30
+
+// stockAlertErrorStatus maps stock alert service errors to HTTP status
+// codes.
+func stockAlertErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrStockAlertResolved):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func reorderLevelsProductIDParam(c *gin.Context) (int, bool) {
+	productID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return 0, false
+	}
+	return productID, true
+}
+
+func NewReorderLevelsGETHandler(service stockAlertService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, ok := reorderLevelsProductIDParam(c)
+		if !ok {
+			return
+		}
+
+		levels, err := service.GetReorderLevels(productID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Product not found or has no reorder levels"})
+				return
+			}
+			log.Printf("[ReorderLevelsGET] Service error for product %d: %v", productID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve reorder levels: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, levels)
+	}
+}
+
+// NewReorderLevelsPUTHandler sets the minimum stock each UPC of the product
+// should keep and how many units to reorder when one falls below it.
+func NewReorderLevelsPUTHandler(service stockAlertService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, ok := reorderLevelsProductIDParam(c)
+		if !ok {
+			return
+		}
+
+		type request struct {
+			MinStock        *int `json:"min_stock" binding:"required,gte=0"`
+			ReorderQuantity *int `json:"reorder_quantity" binding:"required,gte=1"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[ReorderLevelsPUT] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		levels := models.ReorderLevels{
+			ProductID:       productID,
+			MinStock:        *req.MinStock,
+			ReorderQuantity: *req.ReorderQuantity,
+		}
+		if err := service.SetReorderLevels(productID, &levels); err != nil {
+			log.Printf("[ReorderLevelsPUT] Service error for product %d: %v", productID, err)
+			c.JSON(stockAlertErrorStatus(err), gin.H{"error": "Failed to set reorder levels: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, levels)
+	}
+}
+
+func NewReorderLevelsDELETEHandler(service stockAlertService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		productID, ok := reorderLevelsProductIDParam(c)
+		if !ok {
+			return
+		}
+
+		if err := service.SetReorderLevels(productID, nil); err != nil {
+			log.Printf("[ReorderLevelsDELETE] Service error for product %d: %v", productID, err)
+			c.JSON(stockAlertErrorStatus(err), gin.H{"error": "Failed to clear reorder levels: " + err.Error()})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// NewLowStockGETHandler lists the store products below their product's
+// minimum stock with their category and sales velocity over the last days,
+// 30 by default, optionally narrowed to a category.
+func NewLowStockGETHandler(service stockAlertService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := models.LowStockFilter{Days: defaultVelocityDays}
+		if value := c.Query("days"); value != "" {
+			days, err := strconv.Atoi(value)
+			if err != nil || days < 1 || days > 365 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days parameter, use 1 to 365"})
+				return
+			}
+			filter.Days = days
+		}
+		if value := c.Query("category_id"); value != "" {
+			categoryID, err := strconv.Atoi(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+				return
+			}
+			filter.CategoryID = &categoryID
+		}
+
+		products, err := service.GetLowStock(filter)
+		if err != nil {
+			log.Printf("[LowStockGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve low-stock products: " + err.Error()})
+			return
+		}
+		if products == nil {
+			products = []models.LowStockProduct{}
+		}
+
+		c.JSON(http.StatusOK, products)
+	}
+}
+
+// NewStockAlertsGETHandler lists the open low-stock alerts oldest first, or
+// the alerts with the given status; status=all lists every alert.
+func NewStockAlertsGETHandler(service stockAlertService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", models.StockAlertStatusOpen)
+		switch status {
+		case models.StockAlertStatusOpen, models.StockAlertStatusResolved:
+		case "all":
+			status = ""
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+
+		alerts, err := service.GetStockAlerts(status)
+		if err != nil {
+			log.Printf("[StockAlertsGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stock alerts: " + err.Error()})
+			return
+		}
+		if alerts == nil {
+			alerts = []models.StockAlert{}
+		}
+
+		c.JSON(http.StatusOK, alerts)
+	}
+}
+
+// NewStockAlertResolvePOSTHandler closes an open alert on behalf of the
+// authenticated employee.
+func NewStockAlertResolvePOSTHandler(service stockAlertService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		alertID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid alert ID"})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		alert, err := service.ResolveStockAlert(alertID, employeeID)
+		if err != nil {
+			log.Printf("[StockAlertResolvePOST] Service error: %v", err)
+			c.JSON(stockAlertErrorStatus(err), gin.H{"error": "Failed to resolve stock alert: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, alert)
+	}
+}
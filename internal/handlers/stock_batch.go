@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type stockBatchReader interface {
+	GetBatchesByUPC(upc string) ([]models.StockBatch, error)
+	GetExpiringBatches(days int) ([]models.ExpiringBatch, error)
+}
+
+// defaultExpiryDays is the window of the expiry report when days is not
+// given.
+const defaultExpiryDays = 7
+
+// NewStockBatchesByUPCGETHandler lists the batches of a UPC on hand in the
+// order sales take them.
+func NewStockBatchesByUPCGETHandler(service stockBatchReader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		upc := c.Param("upc")
+		if len(upc) != 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UPC format"})
+			return
+		}
+
+		batches, err := service.GetBatchesByUPC(upc)
+		if err != nil {
+			log.Printf("[StockBatchesByUPCGET] Service error for UPC %s: %v", upc, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stock batches: " + err.Error()})
+			return
+		}
+		if batches == nil {
+			batches = []models.StockBatch{}
+		}
+
+		c.JSON(http.StatusOK, batches)
+	}
+}
+
+// NewExpiringBatchesGETHandler reports the batches on hand that expire
+// within days, 7 by default, or have expired.
+func NewExpiringBatchesGETHandler(service stockBatchReader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		days := defaultExpiryDays
+		if value := c.Query("days"); value != "" {
+			var err error
+			days, err = strconv.Atoi(value)
+			if err != nil || days < 0 || days > 365 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid days parameter, use 0 to 365"})
+				return
+			}
+		}
+
+		batches, err := service.GetExpiringBatches(days)
+		if err != nil {
+			log.Printf("[ExpiringBatchesGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve expiring batches: " + err.Error()})
+			return
+		}
+		if batches == nil {
+			batches = []models.ExpiringBatch{}
+		}
+
+		c.JSON(http.StatusOK, batches)
+	}
+}
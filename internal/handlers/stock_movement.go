@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type stockMovementReader interface {
+	GetMovementsByUPC(upc string) ([]models.StockMovementRetrieve, error)
+	VerifyStock(mismatchedOnly bool) ([]models.StockLedgerCheck, error)
+}
+
+// NewStockMovementsByUPCGETHandler lists the stock ledger of a UPC, oldest
+// movement first.
+func NewStockMovementsByUPCGETHandler(service stockMovementReader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		upc := c.Param("upc")
+		if len(upc) != 12 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UPC format"})
+			return
+		}
+
+		movements, err := service.GetMovementsByUPC(upc)
+		if err != nil {
+			log.Printf("[StockMovementsByUPCGET] Service error for UPC %s: %v", upc, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stock movements: " + err.Error()})
+			return
+		}
+		if movements == nil {
+			movements = []models.StockMovementRetrieve{}
+		}
+
+		c.JSON(http.StatusOK, movements)
+	}
+}
+
+// NewStockVerifyGETHandler rebuilds every UPC's stock from the ledger and
+// compares it with products_number.
+func NewStockVerifyGETHandler(service stockMovementReader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mismatchedOnly := false
+		if value := c.Query("mismatched"); value != "" {
+			var err error
+			mismatchedOnly, err = strconv.ParseBool(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mismatched parameter, use true or false"})
+				return
+			}
+		}
+
+		checks, err := service.VerifyStock(mismatchedOnly)
+		if err != nil {
+			log.Printf("[StockVerifyGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify stock: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, checks)
+	}
+}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+type stocktakeService interface {
+	OpenStocktake(c models.StocktakeCreate) (models.StocktakeView, error)
+	AddCounts(stocktakeID int, employeeID string, counts []models.StocktakeCountCreate) (models.StocktakeView, error)
+	DeleteCount(stocktakeID int, countID int) error
+	ApproveStocktake(stocktakeID int, employeeID string) (models.StocktakeView, error)
+	CancelStocktake(stocktakeID int, employeeID string) (models.StocktakeView, error)
+	GetStocktake(stocktakeID int) (models.StocktakeView, error)
+	GetStocktakes(status string) ([]models.StocktakeRetrieve, error)
+}
+
+// stocktakeErrorStatus maps stocktake service errors to HTTP status codes.
+func stocktakeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrStocktakeUnknownCategory),
+		errors.Is(err, services.ErrStocktakeNotOpen),
+		errors.Is(err, services.ErrStocktakeOutOfScope),
+		errors.Is(err, services.ErrStocktakeNoCounts),
+		errors.Is(err, services.ErrUnknownUPC):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func stocktakeIDParam(c *gin.Context) (int, bool) {
+	stocktakeID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid stocktake ID"})
+		return 0, false
+	}
+	return stocktakeID, true
+}
+
+// NewStocktakeCreatePOSTHandler opens a stocktake of a category, or of the
+// whole store when no category_id is given, for the authenticated employee.
+func NewStocktakeCreatePOSTHandler(service stocktakeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type request struct {
+			CategoryID *int    `json:"category_id"`
+			Note       *string `json:"note" binding:"omitempty,max=255"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[StocktakeCreatePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		stocktake, err := service.OpenStocktake(models.StocktakeCreate{
+			CategoryID: req.CategoryID,
+			Note:       req.Note,
+			EmployeeId: employeeID,
+		})
+		if err != nil {
+			log.Printf("[StocktakeCreatePOST] Service error: %v", err)
+			c.JSON(stocktakeErrorStatus(err), gin.H{"error": "Failed to open stocktake: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, stocktake)
+	}
+}
+
+// NewStocktakesListGETHandler lists stocktakes, latest first, optionally
+// narrowed to a status.
+func NewStocktakesListGETHandler(service stocktakeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.Query("status")
+		switch status {
+		case "",
+			models.StocktakeStatusOpen,
+			models.StocktakeStatusApproved,
+			models.StocktakeStatusCancelled:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+			return
+		}
+
+		stocktakes, err := service.GetStocktakes(status)
+		if err != nil {
+			log.Printf("[StocktakesListGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve stocktakes: " + err.Error()})
+			return
+		}
+		if stocktakes == nil {
+			stocktakes = []models.StocktakeRetrieve{}
+		}
+
+		c.JSON(http.StatusOK, stocktakes)
+	}
+}
+
+func NewStocktakeRetrieveGETHandler(service stocktakeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stocktakeID, ok := stocktakeIDParam(c)
+		if !ok {
+			return
+		}
+
+		stocktake, err := service.GetStocktake(stocktakeID)
+		if err != nil {
+			c.JSON(stocktakeErrorStatus(err), gin.H{"error": "Failed to retrieve stocktake: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, stocktake)
+	}
+}
+
+// NewStocktakeCountsPOSTHandler records counts made by the authenticated
+// employee in an open stocktake.
+func NewStocktakeCountsPOSTHandler(service stocktakeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stocktakeID, ok := stocktakeIDParam(c)
+		if !ok {
+			return
+		}
+
+		type count struct {
+			UPC      *string `json:"upc" binding:"required,len=12"`
+			Quantity *int    `json:"quantity" binding:"required,gte=0"`
+			Location *string `json:"location" binding:"omitempty,max=50"`
+		}
+		type request struct {
+			Counts []count `json:"counts" binding:"required,min=1,max=500,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[StocktakeCountsPOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		counts := make([]models.StocktakeCountCreate, 0, len(req.Counts))
+		for _, count := range req.Counts {
+			counts = append(counts, models.StocktakeCountCreate{
+				UPC:      *count.UPC,
+				Quantity: *count.Quantity,
+				Location: count.Location,
+			})
+		}
+
+		stocktake, err := service.AddCounts(stocktakeID, employeeID, counts)
+		if err != nil {
+			log.Printf("[StocktakeCountsPOST] Service error: %v", err)
+			c.JSON(stocktakeErrorStatus(err), gin.H{"error": "Failed to add counts: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, stocktake)
+	}
+}
+
+func NewStocktakeCountDELETEHandler(service stocktakeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stocktakeID, ok := stocktakeIDParam(c)
+		if !ok {
+			return
+		}
+		countID, err := strconv.Atoi(c.Param("count_id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid count ID"})
+			return
+		}
+
+		if err := service.DeleteCount(stocktakeID, countID); err != nil {
+			log.Printf("[StocktakeCountDELETE] Service error: %v", err)
+			c.JSON(stocktakeErrorStatus(err), gin.H{"error": "Failed to delete count: " + err.Error()})
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// NewStocktakeApprovePOSTHandler posts the variances of an open stocktake
+// as stock adjustments on behalf of the authenticated employee.
+func NewStocktakeApprovePOSTHandler(service stocktakeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stocktakeID, ok := stocktakeIDParam(c)
+		if !ok {
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		stocktake, err := service.ApproveStocktake(stocktakeID, employeeID)
+		if err != nil {
+			log.Printf("[StocktakeApprovePOST] Service error: %v", err)
+			c.JSON(stocktakeErrorStatus(err), gin.H{"error": "Failed to approve stocktake: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, stocktake)
+	}
+}
+
+func NewStocktakeCancelPOSTHandler(service stocktakeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		stocktakeID, ok := stocktakeIDParam(c)
+		if !ok {
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		stocktake, err := service.CancelStocktake(stocktakeID, employeeID)
+		if err != nil {
+			log.Printf("[StocktakeCancelPOST] Service error: %v", err)
+			c.JSON(stocktakeErrorStatus(err), gin.H{"error": "Failed to cancel stocktake: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, stocktake)
+	}
+}
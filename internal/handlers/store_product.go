@@ -11,7 +11,7 @@ import (
 )
 
 type storeProductCreator interface {
-	CreateStoreProduct(sp models.StoreProductCreate) (string, error)
+	CreateStoreProduct(sp models.StoreProductCreate, employeeID string) (string, error)
 }
 
 func NewStoreProductCreatePOSTHandler(service storeProductCreator) gin.HandlerFunc {
@@ -19,11 +19,11 @@ func NewStoreProductCreatePOSTHandler(service storeProductCreator) gin.HandlerFu
 		log.Printf("[StoreProductCreatePOST] Starting store product creation request")
 
 		type request struct {
-			UPCProm            *string `json:"upc_prom" binding:"omitempty,len=12"`
-			ProductID          int     `json:"product_id" binding:"required,gte=1"`
-			SellingPrice       float64 `json:"selling_price" binding:"required,gte=0"`
-			ProductsNumber     int     `json:"products_number" binding:"required,gte=0"`
-			PromotionalProduct bool    `json:"promotional_product"`
+			UPCProm            *string      `json:"upc_prom" binding:"omitempty,len=12"`
+			ProductID          int          `json:"product_id" binding:"required,gte=1"`
+			SellingPrice       models.Money `json:"selling_price" binding:"required,gte=0"`
+			ProductsNumber     int          `json:"products_number" binding:"required,gte=0"`
+			PromotionalProduct bool         `json:"promotional_product"`
 		}
 		var req request
 
@@ -37,7 +37,7 @@ func NewStoreProductCreatePOSTHandler(service storeProductCreator) gin.HandlerFu
 		}
 
 		// Log the parsed request data
-		log.Printf("[StoreProductCreatePOST] Parsed request data: ProductID=%d, SellingPrice=%f, ProductsNumber=%d, PromotionalProduct=%t",
+		log.Printf("[StoreProductCreatePOST] Parsed request data: ProductID=%d, SellingPrice=%s, ProductsNumber=%d, PromotionalProduct=%t",
 			req.ProductID, req.SellingPrice, req.ProductsNumber, req.PromotionalProduct)
 		if req.UPCProm != nil {
 			log.Printf("[StoreProductCreatePOST] Promotional UPC: %s", *req.UPCProm)
@@ -62,7 +62,13 @@ func NewStoreProductCreatePOSTHandler(service storeProductCreator) gin.HandlerFu
 
 		log.Printf("[StoreProductCreatePOST] Calling service.CreateStoreProduct with model: %+v", model)
 
-		upc, err := service.CreateStoreProduct(model)
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		upc, err := service.CreateStoreProduct(model, employeeID)
 		if err != nil {
 			log.Printf("[StoreProductCreatePOST] Service error: %v", err)
 			log.Printf("[StoreProductCreatePOST] Service error details - ProductID: %d, Error: %s", req.ProductID, err.Error())
@@ -88,12 +94,12 @@ type storeProductReader interface {
 func NewStoreProductRetrieveGETHandler(service storeProductReader) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		type response struct {
-			UPC                string  `json:"upc"`
-			UPCProm            *string `json:"upc_prom"`
-			ProductID          int     `json:"product_id"`
-			SellingPrice       float64 `json:"selling_price"`
-			ProductsNumber     int     `json:"products_number"`
-			PromotionalProduct bool    `json:"promotional_product"`
+			UPC                string       `json:"upc"`
+			UPCProm            *string      `json:"upc_prom"`
+			ProductID          int          `json:"product_id"`
+			SellingPrice       models.Money `json:"selling_price"`
+			ProductsNumber     int          `json:"products_number"`
+			PromotionalProduct bool         `json:"promotional_product"`
 		}
 
 		upc := c.Param("upc")
@@ -125,12 +131,12 @@ func NewStoreProductRetrieveGETHandler(service storeProductReader) gin.HandlerFu
 
 func NewStoreProductsListGETHandler(service storeProductReader) gin.HandlerFunc {
 	type responseItem struct {
-		UPC                string  `json:"upc"`
-		UPCProm            *string `json:"upc_prom"`
-		ProductID          int     `json:"product_id"`
-		SellingPrice       float64 `json:"selling_price"`
-		ProductsNumber     int     `json:"products_number"`
-		PromotionalProduct bool    `json:"promotional_product"`
+		UPC                string       `json:"upc"`
+		UPCProm            *string      `json:"upc_prom"`
+		ProductID          int          `json:"product_id"`
+		SellingPrice       models.Money `json:"selling_price"`
+		ProductsNumber     int          `json:"products_number"`
+		PromotionalProduct bool         `json:"promotional_product"`
 	}
 
 	return func(c *gin.Context) {
@@ -172,12 +178,12 @@ func NewStoreProductsWithDetailsListGETHandler(service storeProductReader) gin.H
 
 func NewStoreProductsByProductIDGETHandler(service storeProductReader) gin.HandlerFunc {
 	type responseItem struct {
-		UPC                string  `json:"upc"`
-		UPCProm            *string `json:"upc_prom"`
-		ProductID          int     `json:"product_id"`
-		SellingPrice       float64 `json:"selling_price"`
-		ProductsNumber     int     `json:"products_number"`
-		PromotionalProduct bool    `json:"promotional_product"`
+		UPC                string       `json:"upc"`
+		UPCProm            *string      `json:"upc_prom"`
+		ProductID          int          `json:"product_id"`
+		SellingPrice       models.Money `json:"selling_price"`
+		ProductsNumber     int          `json:"products_number"`
+		PromotionalProduct bool         `json:"promotional_product"`
 	}
 
 	return func(c *gin.Context) {
@@ -214,12 +220,12 @@ func NewStoreProductsByProductIDGETHandler(service storeProductReader) gin.Handl
 
 func NewPromotionalProductsGETHandler(service storeProductReader) gin.HandlerFunc {
 	type responseItem struct {
-		UPC                string  `json:"upc"`
-		UPCProm            *string `json:"upc_prom"`
-		ProductID          int     `json:"product_id"`
-		SellingPrice       float64 `json:"selling_price"`
-		ProductsNumber     int     `json:"products_number"`
-		PromotionalProduct bool    `json:"promotional_product"`
+		UPC                string       `json:"upc"`
+		UPCProm            *string      `json:"upc_prom"`
+		ProductID          int          `json:"product_id"`
+		SellingPrice       models.Money `json:"selling_price"`
+		ProductsNumber     int          `json:"products_number"`
+		PromotionalProduct bool         `json:"promotional_product"`
 	}
 
 	return func(c *gin.Context) {
@@ -288,7 +294,7 @@ func NewStoreProductsByNameGETHandler(service storeProductReader) gin.HandlerFun
 }
 
 type storeProductUpdater interface {
-	UpdateStoreProduct(upc string, sp models.StoreProductUpdate) error
+	UpdateStoreProduct(upc string, sp models.StoreProductUpdate, employeeID string) error
 	GetStoreProductByUPC(upc string) (models.StoreProductRetrieve, error)
 }
 
@@ -302,11 +308,11 @@ func NewStoreProductUpdatePATCHHandler(service storeProductUpdater) gin.HandlerF
 		}
 
 		type request struct {
-			UPCProm            *string  `json:"upc_prom" binding:"omitempty,len=12"`
-			ProductID          *int     `json:"product_id" binding:"omitempty,gte=1"`
-			SellingPrice       *float64 `json:"selling_price" binding:"omitempty,gte=0"`
-			ProductsNumber     *int     `json:"products_number" binding:"omitempty,gte=0"`
-			PromotionalProduct *bool    `json:"promotional_product"`
+			UPCProm            *string       `json:"upc_prom" binding:"omitempty,len=12"`
+			ProductID          *int          `json:"product_id" binding:"omitempty,gte=1"`
+			SellingPrice       *models.Money `json:"selling_price" binding:"omitempty,gte=0"`
+			ProductsNumber     *int          `json:"products_number" binding:"omitempty,gte=0"`
+			PromotionalProduct *bool         `json:"promotional_product"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -348,7 +354,13 @@ func NewStoreProductUpdatePATCHHandler(service storeProductUpdater) gin.HandlerF
 			PromotionalProduct: req.PromotionalProduct,
 		}
 
-		err = service.UpdateStoreProduct(upc, model)
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		err = service.UpdateStoreProduct(upc, model, employeeID)
 		if err != nil {
 			log.Printf("[StoreProductUpdatePATCH] Service error for UPC %s: %v", upc, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update store product: " + err.Error()})
@@ -384,7 +396,7 @@ func NewStoreProductDeleteDELETEHandler(service storeProductRemover) gin.Handler
 }
 
 type storeProductInventoryManager interface {
-	UpdateProductQuantity(upc string, quantityChange int) error
+	UpdateProductQuantity(upc string, quantityChange int, employeeID string, reason *string) error
 	CheckStockAvailability(upc string, requiredQuantity int) (bool, error)
 }
 
@@ -398,7 +410,8 @@ func NewStoreProductQuantityUpdatePATCHHandler(service storeProductInventoryMana
 		}
 
 		type request struct {
-			QuantityChange int `json:"quantity_change" binding:"required"`
+			QuantityChange int     `json:"quantity_change" binding:"required"`
+			Reason         *string `json:"reason" binding:"omitempty,max=255"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -407,7 +420,13 @@ func NewStoreProductQuantityUpdatePATCHHandler(service storeProductInventoryMana
 			return
 		}
 
-		err := service.UpdateProductQuantity(upc, req.QuantityChange)
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		err := service.UpdateProductQuantity(upc, req.QuantityChange, employeeID, req.Reason)
 		if err != nil {
 			log.Printf("[StoreProductQuantityUpdatePATCH] Service error for UPC %s, quantity change %d: %v", upc, req.QuantityChange, err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update quantity: " + err.Error()})
@@ -457,7 +476,7 @@ func NewStoreProductStockCheckGETHandler(service storeProductInventoryManager) g
 }
 
 type storeProductDeliveryUpdater interface {
-	UpdateProductDelivery(upc string, quantityChange int, newPrice *float64) error
+	UpdateProductDelivery(upc string, quantityChange int, newPrice *models.Money, expiryDate *string, employeeID string, reference *string) error
 }
 
 func NewStoreProductDeliveryPATCHHandler(service storeProductDeliveryUpdater) gin.HandlerFunc {
@@ -470,8 +489,10 @@ func NewStoreProductDeliveryPATCHHandler(service storeProductDeliveryUpdater) gi
 		}
 
 		type request struct {
-			QuantityChange int      `json:"quantity_change" binding:"required"`
-			NewPrice       *float64 `json:"new_price" binding:"omitempty,gte=0"`
+			QuantityChange int           `json:"quantity_change" binding:"required"`
+			NewPrice       *models.Money `json:"new_price" binding:"omitempty,gte=0"`
+			Reference      *string       `json:"reference" binding:"omitempty,max=50"`
+			ExpiryDate     *string       `json:"expiry_date" binding:"omitempty,datetime=2006-01-02"`
 		}
 		var req request
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -486,7 +507,13 @@ func NewStoreProductDeliveryPATCHHandler(service storeProductDeliveryUpdater) gi
 			return
 		}
 
-		err := service.UpdateProductDelivery(upc, req.QuantityChange, req.NewPrice)
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		err := service.UpdateProductDelivery(upc, req.QuantityChange, req.NewPrice, req.ExpiryDate, employeeID, req.Reference)
 		if err != nil {
 			log.Printf("[StoreProductDeliveryPATCH] Service error for UPC %s, quantity change %d, new price %v: %v", upc, req.QuantityChange, req.NewPrice, err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to update delivery: " + err.Error()})
@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type supplierService interface {
+	CreateSupplier(c models.SupplierCreate) (int, error)
+	GetSupplierByID(supplierID int) (models.SupplierRetrieve, error)
+	GetSuppliers() ([]models.SupplierRetrieve, error)
+	UpdateSupplier(supplierID int, u models.SupplierUpdate) error
+	DeleteSupplier(supplierID int) error
+}
+
+func NewSupplierCreatePOSTHandler(service supplierService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type request struct {
+			Name        *string `json:"supplier_name" binding:"required,min=1,max=100"`
+			ContactName *string `json:"contact_name" binding:"omitempty,max=100"`
+			PhoneNumber *string `json:"phone_number" binding:"omitempty,len=13,startswith=+380"`
+			Email       *string `json:"email" binding:"omitempty,email,max=100"`
+			City        *string `json:"city" binding:"omitempty,max=50"`
+			Street      *string `json:"street" binding:"omitempty,max=50"`
+			ZipCode     *string `json:"zip_code" binding:"omitempty,max=9"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		id, err := service.CreateSupplier(models.SupplierCreate{
+			Name:        req.Name,
+			ContactName: req.ContactName,
+			PhoneNumber: req.PhoneNumber,
+			Email:       req.Email,
+			City:        req.City,
+			Street:      req.Street,
+			ZipCode:     req.ZipCode,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create supplier: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"id": id})
+	}
+}
+
+func NewSupplierRetrieveGETHandler(service supplierService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		supplier, err := service.GetSupplierByID(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, supplier)
+	}
+}
+
+func NewSuppliersListGETHandler(service supplierService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		suppliers, err := service.GetSuppliers()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve suppliers: " + err.Error()})
+			return
+		}
+		if suppliers == nil {
+			suppliers = []models.SupplierRetrieve{}
+		}
+
+		c.JSON(http.StatusOK, suppliers)
+	}
+}
+
+func NewSupplierUpdatePATCHHandler(service supplierService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		type request struct {
+			Name        *string `json:"supplier_name" binding:"omitempty,min=1,max=100"`
+			ContactName *string `json:"contact_name" binding:"omitempty,max=100"`
+			PhoneNumber *string `json:"phone_number" binding:"omitempty,len=13,startswith=+380"`
+			Email       *string `json:"email" binding:"omitempty,email,max=100"`
+			City        *string `json:"city" binding:"omitempty,max=50"`
+			Street      *string `json:"street" binding:"omitempty,max=50"`
+			ZipCode     *string `json:"zip_code" binding:"omitempty,max=9"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		supplierCurrentState, err := service.GetSupplierByID(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found: " + err.Error()})
+			return
+		}
+		if req.Name == nil {
+			req.Name = &supplierCurrentState.Name
+		}
+		if req.ContactName == nil {
+			req.ContactName = supplierCurrentState.ContactName
+		}
+		if req.PhoneNumber == nil {
+			req.PhoneNumber = supplierCurrentState.PhoneNumber
+		}
+		if req.Email == nil {
+			req.Email = supplierCurrentState.Email
+		}
+		if req.City == nil {
+			req.City = supplierCurrentState.City
+		}
+		if req.Street == nil {
+			req.Street = supplierCurrentState.Street
+		}
+		if req.ZipCode == nil {
+			req.ZipCode = supplierCurrentState.ZipCode
+		}
+
+		err = service.UpdateSupplier(id, models.SupplierUpdate{
+			Name:        req.Name,
+			ContactName: req.ContactName,
+			PhoneNumber: req.PhoneNumber,
+			Email:       req.Email,
+			City:        req.City,
+			Street:      req.Street,
+			ZipCode:     req.ZipCode,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update supplier: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Supplier updated successfully"})
+	}
+}
+
+func NewSupplierDeleteDELETEHandler(service supplierService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+
+		if _, err := service.GetSupplierByID(id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Supplier not found: " + err.Error()})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve supplier: " + err.Error()})
+			return
+		}
+
+		err = service.DeleteSupplier(id)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete supplier: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"message": "Supplier deleted successfully"})
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+)
+
+// Timestamps are read and written as RFC 3339. Dates without a time are days
+// in the store's time zone.
+
+// storeDay parses a YYYY-MM-DD date as the start of that day in loc.
+func storeDay(value string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation(time.DateOnly, value, loc)
+}
+
+// timeFilter parses a query parameter bounding a range of timestamps. It is
+// either an RFC 3339 timestamp or a store day; a day used as an upper bound
+// covers the whole day.
+func timeFilter(value string, upper bool, loc *time.Location) (time.Time, error) {
+	if t, err := storeDay(value, loc); err == nil {
+		if upper {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
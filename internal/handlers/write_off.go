@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+type writeOffService interface {
+	CreateWriteOff(c models.WriteOffCreate) (models.WriteOffView, error)
+	GetWriteOff(writeOffID int) (models.WriteOffView, error)
+	GetWriteOffs(f models.WriteOffFilter) ([]models.WriteOffView, error)
+	GetWriteOffReport(month time.Time) (models.WriteOffReport, error)
+}
+
+// writeOffErrorStatus maps write-off service errors to HTTP status codes.
+func writeOffErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrWriteOffDuplicateUPC),
+		errors.Is(err, services.ErrUnknownUPC),
+		errors.Is(err, services.ErrWriteOffInsufficientStock):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// NewWriteOffCreatePOSTHandler writes goods off stock for one reason on
+// behalf of the authenticated employee.
+func NewWriteOffCreatePOSTHandler(service writeOffService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		type line struct {
+			UPC      *string `json:"upc" binding:"required,len=12"`
+			Quantity *int    `json:"quantity" binding:"required,gte=1"`
+		}
+		type request struct {
+			Reason *string `json:"reason" binding:"required,oneof=expired damaged theft internal_use"`
+			Note   *string `json:"note" binding:"omitempty,max=255"`
+			Lines  []line  `json:"lines" binding:"required,min=1,max=500,dive"`
+		}
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			log.Printf("[WriteOffCreatePOST] BindJSON error: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+
+		employeeID, ok := currentEmployeeID(c)
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		writeOff := models.WriteOffCreate{
+			Reason:     *req.Reason,
+			Note:       req.Note,
+			EmployeeId: employeeID,
+		}
+		for _, l := range req.Lines {
+			writeOff.Lines = append(writeOff.Lines, models.WriteOffLineCreate{
+				UPC:      *l.UPC,
+				Quantity: *l.Quantity,
+			})
+		}
+
+		view, err := service.CreateWriteOff(writeOff)
+		if err != nil {
+			log.Printf("[WriteOffCreatePOST] Service error: %v", err)
+			c.JSON(writeOffErrorStatus(err), gin.H{"error": "Failed to write off goods: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, view)
+	}
+}
+
+// NewWriteOffsListGETHandler lists write-offs with their lines, latest
+// first, optionally narrowed to a reason and a period.
+func NewWriteOffsListGETHandler(service writeOffService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := models.WriteOffFilter{
+			Reason: c.Query("reason"),
+		}
+		switch filter.Reason {
+		case "",
+			models.WriteOffReasonExpired,
+			models.WriteOffReasonDamaged,
+			models.WriteOffReasonTheft,
+			models.WriteOffReasonInternalUse:
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reason"})
+			return
+		}
+		if from := c.Query("from"); from != "" {
+			t, err := timeFilter(from, false, cfg.STORE_LOCATION)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from parameter, use YYYY-MM-DD or an RFC 3339 timestamp"})
+				return
+			}
+			filter.From = &t
+		}
+		if to := c.Query("to"); to != "" {
+			t, err := timeFilter(to, true, cfg.STORE_LOCATION)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to parameter, use YYYY-MM-DD or an RFC 3339 timestamp"})
+				return
+			}
+			filter.To = &t
+		}
+
+		writeOffs, err := service.GetWriteOffs(filter)
+		if err != nil {
+			log.Printf("[WriteOffsListGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve write-offs: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, writeOffs)
+	}
+}
+
+func NewWriteOffRetrieveGETHandler(service writeOffService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeOffID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid write-off ID"})
+			return
+		}
+
+		writeOff, err := service.GetWriteOff(writeOffID)
+		if err != nil {
+			c.JSON(writeOffErrorStatus(err), gin.H{"error": "Failed to retrieve write-off: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, writeOff)
+	}
+}
+
+// NewWriteOffReportGETHandler sums a month's write-offs by category and
+// reason. month is YYYY-MM in the store's time zone and defaults to the
+// current month.
+func NewWriteOffReportGETHandler(service writeOffService, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		currentMonth := time.Now().In(cfg.STORE_LOCATION).Format("2006-01")
+		month, err := time.ParseInLocation("2006-01", c.DefaultQuery("month", currentMonth), cfg.STORE_LOCATION)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid month parameter, use YYYY-MM"})
+			return
+		}
+
+		report, err := service.GetWriteOffReport(month)
+		if err != nil {
+			log.Printf("[WriteOffReportGET] Service error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve write-off report: " + err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, report)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/velosypedno/zlagoda/internal/config"
 	"github.com/velosypedno/zlagoda/internal/handlers"
+	"github.com/velosypedno/zlagoda/internal/middleware"
 	"github.com/velosypedno/zlagoda/internal/repos"
 	"github.com/velosypedno/zlagoda/internal/services"
 )
@@ -26,6 +27,9 @@ type HandlerContainer struct {
 	CategoryDeleteDELETEHandler gin.HandlerFunc
 	CategoryUpdatePATCHHandler  gin.HandlerFunc
 
+	CategoryVATRatePUTHandler    gin.HandlerFunc
+	CategoryVATRateDELETEHandler gin.HandlerFunc
+
 	CustomerCardCreatePOSTHandler   gin.HandlerFunc
 	CustomerCardRetrieveGETHandler  gin.HandlerFunc
 	CustomerCardsListGETHandler     gin.HandlerFunc
@@ -39,12 +43,48 @@ type HandlerContainer struct {
 	EmployeeDeleteDELETEHandler       gin.HandlerFunc
 	EmployeeUpdatePATCHHandler        gin.HandlerFunc
 
-	ReceiptCreatePOSTHandler         gin.HandlerFunc
-	ReceiptCreateCompletePOSTHandler gin.HandlerFunc
-	ReceiptRetrieveGETHandler        gin.HandlerFunc
-	ReceiptsListGETHandler           gin.HandlerFunc
-	ReceiptDeleteDELETEHandler       gin.HandlerFunc
-	ReceiptUpdatePATCHHandler        gin.HandlerFunc
+	ReceiptCreatePOSTHandler              gin.HandlerFunc
+	ReceiptCreateCompletePOSTHandler      gin.HandlerFunc
+	ReceiptRetrieveGETHandler             gin.HandlerFunc
+	ReceiptsListGETHandler                gin.HandlerFunc
+	ReceiptSyncPOSTHandler                gin.HandlerFunc
+	ReceiptSyncConflictsGETHandler        gin.HandlerFunc
+	ReceiptSyncTenderMismatchesGETHandler gin.HandlerFunc
+	ReceiptDeleteDELETEHandler            gin.HandlerFunc
+	ReceiptUpdatePATCHHandler             gin.HandlerFunc
+	ReceiptVoidPOSTHandler                gin.HandlerFunc
+	ReceiptPrintGETHandler                gin.HandlerFunc
+
+	ReceiptTotalsMismatchesGETHandler gin.HandlerFunc
+	ReceiptTotalsRepairPOSTHandler    gin.HandlerFunc
+
+	PaymentsByReceiptGETHandler gin.HandlerFunc
+	TenderTotalsGETHandler      gin.HandlerFunc
+
+	ShiftOpenPOSTHandler    gin.HandlerFunc
+	ShiftCurrentGETHandler  gin.HandlerFunc
+	ShiftsListGETHandler    gin.HandlerFunc
+	ShiftRetrieveGETHandler gin.HandlerFunc
+	ShiftXReportGETHandler  gin.HandlerFunc
+	ShiftClosePOSTHandler   gin.HandlerFunc
+	ShiftZReportGETHandler  gin.HandlerFunc
+
+	ReturnCreatePOSTHandler    gin.HandlerFunc
+	ReturnRetrieveGETHandler   gin.HandlerFunc
+	ReturnsListGETHandler      gin.HandlerFunc
+	ReturnsByReceiptGETHandler gin.HandlerFunc
+
+	IdempotencyMiddleware gin.HandlerFunc
+
+	CartCreatePOSTHandler       gin.HandlerFunc
+	CartRetrieveGETHandler      gin.HandlerFunc
+	CartCancelDELETEHandler     gin.HandlerFunc
+	CartItemAddPOSTHandler      gin.HandlerFunc
+	CartItemUpdatePUTHandler    gin.HandlerFunc
+	CartItemDeleteDELETEHandler gin.HandlerFunc
+	CartCardPUTHandler          gin.HandlerFunc
+	CartCardDeleteDELETEHandler gin.HandlerFunc
+	CartFinalizePOSTHandler     gin.HandlerFunc
 
 	ProductCreatePOSTHandler     gin.HandlerFunc
 	ProductRetrieveGETHandler    gin.HandlerFunc
@@ -54,6 +94,9 @@ type HandlerContainer struct {
 	ProductDeleteDELETEHandler   gin.HandlerFunc
 	ProductUpdatePATCHHandler    gin.HandlerFunc
 
+	ProductVATRatePUTHandler    gin.HandlerFunc
+	ProductVATRateDELETEHandler gin.HandlerFunc
+
 	StoreProductCreatePOSTHandler          gin.HandlerFunc
 	StoreProductRetrieveGETHandler         gin.HandlerFunc
 	StoreProductsListGETHandler            gin.HandlerFunc
@@ -67,6 +110,46 @@ type HandlerContainer struct {
 	StoreProductQuantityUpdatePATCHHandler gin.HandlerFunc
 	StoreProductStockCheckGETHandler       gin.HandlerFunc
 	StoreProductDeliveryPATCHHandler       gin.HandlerFunc
+	StockMovementsByUPCGETHandler          gin.HandlerFunc
+	StockVerifyGETHandler                  gin.HandlerFunc
+	StockBatchesByUPCGETHandler            gin.HandlerFunc
+	ExpiringBatchesGETHandler              gin.HandlerFunc
+	ReorderLevelsGETHandler                gin.HandlerFunc
+	ReorderLevelsPUTHandler                gin.HandlerFunc
+	ReorderLevelsDELETEHandler             gin.HandlerFunc
+	LowStockGETHandler                     gin.HandlerFunc
+	StockAlertsGETHandler                  gin.HandlerFunc
+	StockAlertResolvePOSTHandler           gin.HandlerFunc
+
+	SupplierCreatePOSTHandler       gin.HandlerFunc
+	SuppliersListGETHandler         gin.HandlerFunc
+	SupplierRetrieveGETHandler      gin.HandlerFunc
+	SupplierUpdatePATCHHandler      gin.HandlerFunc
+	SupplierDeleteDELETEHandler     gin.HandlerFunc
+	PurchaseOrderCreatePOSTHandler  gin.HandlerFunc
+	PurchaseOrdersListGETHandler    gin.HandlerFunc
+	PurchaseOrderRetrieveGETHandler gin.HandlerFunc
+	PurchaseOrderUpdatePATCHHandler gin.HandlerFunc
+	PurchaseOrderSendPOSTHandler    gin.HandlerFunc
+	PurchaseOrderCancelPOSTHandler  gin.HandlerFunc
+	PurchaseOrderReceivePOSTHandler gin.HandlerFunc
+	GoodsReceivedCreatePOSTHandler  gin.HandlerFunc
+	GoodsReceivedListGETHandler     gin.HandlerFunc
+	GoodsReceivedRetrieveGETHandler gin.HandlerFunc
+	GoodsReceivedReversePOSTHandler gin.HandlerFunc
+
+	StocktakeCreatePOSTHandler  gin.HandlerFunc
+	StocktakesListGETHandler    gin.HandlerFunc
+	StocktakeRetrieveGETHandler gin.HandlerFunc
+	StocktakeCountsPOSTHandler  gin.HandlerFunc
+	StocktakeCountDELETEHandler gin.HandlerFunc
+	StocktakeApprovePOSTHandler gin.HandlerFunc
+	StocktakeCancelPOSTHandler  gin.HandlerFunc
+
+	WriteOffCreatePOSTHandler  gin.HandlerFunc
+	WriteOffsListGETHandler    gin.HandlerFunc
+	WriteOffRetrieveGETHandler gin.HandlerFunc
+	WriteOffReportGETHandler   gin.HandlerFunc
 
 	SaleCreatePOSTHandler               gin.HandlerFunc
 	SaleRetrieveGETHandler              gin.HandlerFunc
@@ -101,7 +184,8 @@ func (hc *HandlerContainer) Close() error {
 	return nil
 }
 
-func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
+// OpenDB connects to the configured database and checks the connection.
+func OpenDB(c *config.Config) (*sql.DB, error) {
 	db, err := sql.Open(c.DB_DRIVER, c.DB_DSN)
 	if err != nil {
 		return nil, err
@@ -119,6 +203,14 @@ func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
 	}
 
 	log.Println("Database connection established successfully")
+	return db, nil
+}
+
+func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
+	db, err := OpenDB(c)
+	if err != nil {
+		return nil, err
+	}
 
 	categoryRepo := repos.NewCategoryRepo(db)
 	categoryService := services.NewCategoryService(categoryRepo)
@@ -134,12 +226,43 @@ func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
 
 	storeProductRepo := repos.NewStoreProductRepo(db)
 	storeProductService := services.NewStoreProductService(storeProductRepo)
+	stockMovementService := services.NewStockMovementService(repos.NewStockMovementRepo(db))
+	stockBatchService := services.NewStockBatchService(repos.NewStockBatchRepo(db))
+	stockAlertService := services.NewStockAlertService(repos.NewStockAlertRepo(db), productRepo)
 
-	saleRepo := repos.NewSaleRepo(db)
-	saleService := services.NewSaleService(saleRepo)
+	supplierRepo := repos.NewSupplierRepo(db)
+	supplierService := services.NewSupplierService(supplierRepo)
+	purchaseOrderService := services.NewPurchaseOrderService(repos.NewPurchaseOrderRepo(db), supplierRepo, productRepo, storeProductRepo, storeProductService)
+	goodsReceivedService := services.NewGoodsReceivedService(repos.NewGoodsReceivedRepo(db), supplierRepo, storeProductRepo)
+	stocktakeService := services.NewStocktakeService(repos.NewStocktakeRepo(db), categoryRepo, storeProductRepo)
+	writeOffService := services.NewWriteOffService(repos.NewWriteOffRepo(db), storeProductRepo)
 
 	receiptRepo := repos.NewReceiptRepo(db)
-	receiptService := services.NewReceiptService(receiptRepo, saleRepo, storeProductRepo)
+
+	saleRepo := repos.NewSaleRepo(db)
+	saleService := services.NewSaleService(saleRepo, storeProductRepo, receiptRepo, c)
+
+	shiftRepo := repos.NewShiftRepo(db)
+	shiftService := services.NewShiftService(shiftRepo, c)
+
+	returnRepo := repos.NewReturnRepo(db)
+	paymentRepo := repos.NewPaymentRepo(db)
+	paymentService := services.NewPaymentService(paymentRepo)
+	receiptService := services.NewReceiptService(receiptRepo, saleRepo, storeProductRepo, customerCardRepo, returnRepo, paymentRepo, shiftRepo, c)
+
+	receiptTotalsService := services.NewReceiptTotalsService(receiptRepo, saleRepo)
+	receiptSyncService := services.NewReceiptSyncService(repos.NewReceiptSyncRepo(db), receiptService, c)
+
+	receiptPrintService := services.NewReceiptPrintService(receiptRepo, saleRepo, employeeRepo, paymentRepo, c)
+	pdfService := services.NewPDFService(receiptPrintService, c)
+
+	returnService := services.NewReturnService(returnRepo, receiptRepo, storeProductRepo, shiftRepo)
+
+	idempotencyRepo := repos.NewIdempotencyRepo(db)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, c)
+
+	cartRepo := repos.NewCartRepo(db)
+	cartService := services.NewCartService(cartRepo, customerCardRepo, storeProductRepo, receiptService, c)
 
 	loginService := services.NewLoginService(employeeRepo, c)
 	registerService := services.NewRegisterService(employeeRepo, c)
@@ -161,6 +284,9 @@ func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
 		CategoryDeleteDELETEHandler: handlers.NewCategoryDeleteDELETEHandler(categoryService),
 		CategoryUpdatePATCHHandler:  handlers.NewCategoryUpdatePATCHHandler(categoryService),
 
+		CategoryVATRatePUTHandler:    handlers.NewCategoryVATRatePUTHandler(categoryService),
+		CategoryVATRateDELETEHandler: handlers.NewCategoryVATRateDELETEHandler(categoryService),
+
 		CustomerCardCreatePOSTHandler:   handlers.NewCustomerCardCreatePOSTHandler(customerCardService),
 		CustomerCardRetrieveGETHandler:  handlers.NewCustomerCardRetrieveGETHandler(customerCardService),
 		CustomerCardsListGETHandler:     handlers.NewCustomerCardsListGETHandler(customerCardService),
@@ -174,12 +300,48 @@ func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
 		EmployeeDeleteDELETEHandler:       handlers.NewEmployeeDeleteDELETEHandler(employeeService),
 		EmployeeUpdatePATCHHandler:        handlers.NewEmployeeUpdatePATCHHandler(employeeService),
 
-		ReceiptCreatePOSTHandler:         handlers.NewReceiptCreatePOSTHandler(receiptService, c),
-		ReceiptCreateCompletePOSTHandler: handlers.NewReceiptCreateCompletePOSTHandler(receiptService, c),
-		ReceiptRetrieveGETHandler:        handlers.NewReceiptRetrieveGETHandler(receiptService),
-		ReceiptsListGETHandler:           handlers.NewReceiptsListGETHandler(receiptService),
-		ReceiptDeleteDELETEHandler:       handlers.NewReceiptDeleteDELETEHandler(receiptService),
-		ReceiptUpdatePATCHHandler:        handlers.NewReceiptUpdatePATCHHandler(receiptService, c),
+		ReceiptCreatePOSTHandler:              handlers.NewReceiptCreatePOSTHandler(receiptService),
+		ReceiptCreateCompletePOSTHandler:      handlers.NewReceiptCreateCompletePOSTHandler(receiptService, c),
+		ReceiptRetrieveGETHandler:             handlers.NewReceiptRetrieveGETHandler(receiptService, pdfService),
+		ReceiptsListGETHandler:                handlers.NewReceiptsListGETHandler(receiptService, c),
+		ReceiptSyncPOSTHandler:                handlers.NewReceiptSyncPOSTHandler(receiptSyncService),
+		ReceiptSyncConflictsGETHandler:        handlers.NewReceiptSyncConflictsGETHandler(receiptSyncService),
+		ReceiptSyncTenderMismatchesGETHandler: handlers.NewReceiptSyncTenderMismatchesGETHandler(receiptSyncService),
+		ReceiptDeleteDELETEHandler:            handlers.NewReceiptDeleteDELETEHandler(receiptService),
+		ReceiptUpdatePATCHHandler:             handlers.NewReceiptUpdatePATCHHandler(receiptService),
+		ReceiptVoidPOSTHandler:                handlers.NewReceiptVoidPOSTHandler(receiptService),
+		ReceiptPrintGETHandler:                handlers.NewReceiptPrintGETHandler(receiptPrintService),
+
+		ReceiptTotalsMismatchesGETHandler: handlers.NewReceiptTotalsMismatchesGETHandler(receiptTotalsService),
+		ReceiptTotalsRepairPOSTHandler:    handlers.NewReceiptTotalsRepairPOSTHandler(receiptTotalsService),
+
+		PaymentsByReceiptGETHandler: handlers.NewPaymentsByReceiptGETHandler(paymentService),
+		TenderTotalsGETHandler:      handlers.NewTenderTotalsGETHandler(paymentService, c),
+
+		ShiftOpenPOSTHandler:    handlers.NewShiftOpenPOSTHandler(shiftService),
+		ShiftCurrentGETHandler:  handlers.NewShiftCurrentGETHandler(shiftService),
+		ShiftsListGETHandler:    handlers.NewShiftsListGETHandler(shiftService),
+		ShiftRetrieveGETHandler: handlers.NewShiftRetrieveGETHandler(shiftService),
+		ShiftXReportGETHandler:  handlers.NewShiftXReportGETHandler(shiftService),
+		ShiftClosePOSTHandler:   handlers.NewShiftClosePOSTHandler(shiftService),
+		ShiftZReportGETHandler:  handlers.NewShiftZReportGETHandler(shiftService),
+
+		ReturnCreatePOSTHandler:    handlers.NewReturnCreatePOSTHandler(returnService),
+		ReturnRetrieveGETHandler:   handlers.NewReturnRetrieveGETHandler(returnService),
+		ReturnsListGETHandler:      handlers.NewReturnsListGETHandler(returnService),
+		ReturnsByReceiptGETHandler: handlers.NewReturnsByReceiptGETHandler(returnService),
+
+		IdempotencyMiddleware: middleware.Idempotency(idempotencyService),
+
+		CartCreatePOSTHandler:       handlers.NewCartCreatePOSTHandler(cartService),
+		CartRetrieveGETHandler:      handlers.NewCartRetrieveGETHandler(cartService),
+		CartCancelDELETEHandler:     handlers.NewCartCancelDELETEHandler(cartService),
+		CartItemAddPOSTHandler:      handlers.NewCartItemAddPOSTHandler(cartService),
+		CartItemUpdatePUTHandler:    handlers.NewCartItemUpdatePUTHandler(cartService),
+		CartItemDeleteDELETEHandler: handlers.NewCartItemDeleteDELETEHandler(cartService),
+		CartCardPUTHandler:          handlers.NewCartCardPUTHandler(cartService),
+		CartCardDeleteDELETEHandler: handlers.NewCartCardDeleteDELETEHandler(cartService),
+		CartFinalizePOSTHandler:     handlers.NewCartFinalizePOSTHandler(cartService),
 
 		ProductCreatePOSTHandler:     handlers.NewProductCreatePOSTHandler(productService),
 		ProductRetrieveGETHandler:    handlers.NewProductRetrieveGETHandler(productService),
@@ -189,6 +351,9 @@ func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
 		ProductDeleteDELETEHandler:   handlers.NewProductDeleteDELETEHandler(productService),
 		ProductUpdatePATCHHandler:    handlers.NewProductUpdatePATCHHandler(productService),
 
+		ProductVATRatePUTHandler:    handlers.NewProductVATRatePUTHandler(productService),
+		ProductVATRateDELETEHandler: handlers.NewProductVATRateDELETEHandler(productService),
+
 		StoreProductCreatePOSTHandler:          handlers.NewStoreProductCreatePOSTHandler(storeProductService),
 		StoreProductRetrieveGETHandler:         handlers.NewStoreProductRetrieveGETHandler(storeProductService),
 		StoreProductsListGETHandler:            handlers.NewStoreProductsListGETHandler(storeProductService),
@@ -202,6 +367,46 @@ func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
 		StoreProductQuantityUpdatePATCHHandler: handlers.NewStoreProductQuantityUpdatePATCHHandler(storeProductService),
 		StoreProductStockCheckGETHandler:       handlers.NewStoreProductStockCheckGETHandler(storeProductService),
 		StoreProductDeliveryPATCHHandler:       handlers.NewStoreProductDeliveryPATCHHandler(storeProductService),
+		StockMovementsByUPCGETHandler:          handlers.NewStockMovementsByUPCGETHandler(stockMovementService),
+		StockVerifyGETHandler:                  handlers.NewStockVerifyGETHandler(stockMovementService),
+		StockBatchesByUPCGETHandler:            handlers.NewStockBatchesByUPCGETHandler(stockBatchService),
+		ExpiringBatchesGETHandler:              handlers.NewExpiringBatchesGETHandler(stockBatchService),
+		ReorderLevelsGETHandler:                handlers.NewReorderLevelsGETHandler(stockAlertService),
+		ReorderLevelsPUTHandler:                handlers.NewReorderLevelsPUTHandler(stockAlertService),
+		ReorderLevelsDELETEHandler:             handlers.NewReorderLevelsDELETEHandler(stockAlertService),
+		LowStockGETHandler:                     handlers.NewLowStockGETHandler(stockAlertService),
+		StockAlertsGETHandler:                  handlers.NewStockAlertsGETHandler(stockAlertService),
+		StockAlertResolvePOSTHandler:           handlers.NewStockAlertResolvePOSTHandler(stockAlertService),
+
+		SupplierCreatePOSTHandler:       handlers.NewSupplierCreatePOSTHandler(supplierService),
+		SuppliersListGETHandler:         handlers.NewSuppliersListGETHandler(supplierService),
+		SupplierRetrieveGETHandler:      handlers.NewSupplierRetrieveGETHandler(supplierService),
+		SupplierUpdatePATCHHandler:      handlers.NewSupplierUpdatePATCHHandler(supplierService),
+		SupplierDeleteDELETEHandler:     handlers.NewSupplierDeleteDELETEHandler(supplierService),
+		PurchaseOrderCreatePOSTHandler:  handlers.NewPurchaseOrderCreatePOSTHandler(purchaseOrderService),
+		PurchaseOrdersListGETHandler:    handlers.NewPurchaseOrdersListGETHandler(purchaseOrderService),
+		PurchaseOrderRetrieveGETHandler: handlers.NewPurchaseOrderRetrieveGETHandler(purchaseOrderService),
+		PurchaseOrderUpdatePATCHHandler: handlers.NewPurchaseOrderUpdatePATCHHandler(purchaseOrderService),
+		PurchaseOrderSendPOSTHandler:    handlers.NewPurchaseOrderSendPOSTHandler(purchaseOrderService),
+		PurchaseOrderCancelPOSTHandler:  handlers.NewPurchaseOrderCancelPOSTHandler(purchaseOrderService),
+		PurchaseOrderReceivePOSTHandler: handlers.NewPurchaseOrderReceivePOSTHandler(purchaseOrderService),
+		GoodsReceivedCreatePOSTHandler:  handlers.NewGoodsReceivedCreatePOSTHandler(goodsReceivedService),
+		GoodsReceivedListGETHandler:     handlers.NewGoodsReceivedListGETHandler(goodsReceivedService),
+		GoodsReceivedRetrieveGETHandler: handlers.NewGoodsReceivedRetrieveGETHandler(goodsReceivedService),
+		GoodsReceivedReversePOSTHandler: handlers.NewGoodsReceivedReversePOSTHandler(goodsReceivedService),
+
+		StocktakeCreatePOSTHandler:  handlers.NewStocktakeCreatePOSTHandler(stocktakeService),
+		StocktakesListGETHandler:    handlers.NewStocktakesListGETHandler(stocktakeService),
+		StocktakeRetrieveGETHandler: handlers.NewStocktakeRetrieveGETHandler(stocktakeService),
+		StocktakeCountsPOSTHandler:  handlers.NewStocktakeCountsPOSTHandler(stocktakeService),
+		StocktakeCountDELETEHandler: handlers.NewStocktakeCountDELETEHandler(stocktakeService),
+		StocktakeApprovePOSTHandler: handlers.NewStocktakeApprovePOSTHandler(stocktakeService),
+		StocktakeCancelPOSTHandler:  handlers.NewStocktakeCancelPOSTHandler(stocktakeService),
+
+		WriteOffCreatePOSTHandler:  handlers.NewWriteOffCreatePOSTHandler(writeOffService),
+		WriteOffsListGETHandler:    handlers.NewWriteOffsListGETHandler(writeOffService, c),
+		WriteOffRetrieveGETHandler: handlers.NewWriteOffRetrieveGETHandler(writeOffService),
+		WriteOffReportGETHandler:   handlers.NewWriteOffReportGETHandler(writeOffService, c),
 
 		SaleCreatePOSTHandler:               handlers.NewSaleCreatePOSTHandler(saleService),
 		SaleRetrieveGETHandler:              handlers.NewSaleRetrieveGETHandler(saleService),
@@ -217,11 +422,11 @@ func BuildHandlerContainer(c *config.Config) (*HandlerContainer, error) {
 		SalesStatsByProductGETHandler:       handlers.NewSalesStatsByProductGETHandler(saleService),
 		TopSellingProductsGETHandler:        handlers.NewTopSellingProductsGETHandler(saleService),
 
-		Vlad1GETHandler:    handlers.NewVlad1GETHandler(individualsService),
-		Vlad2GETHandler:    handlers.NewVlad2GETHandler(individualsService),
-		Arthur1GETHandler:  handlers.NewArthur1GETHandler(individualsService),
-		Arthur2GETHandler:  handlers.NewArthur2GETHandler(individualsService),
-		Oleksii1GETHandler: handlers.NewOleksii1GETHandler(individualsService),
-		Oleksii2GETHandler: handlers.NewOleksii2GETHandler(individualsService),
+		Vlad1GETHandler:    handlers.NewVlad1GETHandler(individualsService, pdfService),
+		Vlad2GETHandler:    handlers.NewVlad2GETHandler(individualsService, pdfService),
+		Arthur1GETHandler:  handlers.NewArthur1GETHandler(individualsService, pdfService),
+		Arthur2GETHandler:  handlers.NewArthur2GETHandler(individualsService, pdfService),
+		Oleksii1GETHandler: handlers.NewOleksii1GETHandler(individualsService, pdfService),
+		Oleksii2GETHandler: handlers.NewOleksii2GETHandler(individualsService, pdfService),
 	}, nil
 }
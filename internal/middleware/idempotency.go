@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/services"
+)
+
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyStore interface {
+	Reserve(employeeID, key, method, path, requestHash string) (*models.IdempotencyKeyRetrieve, error)
+	Complete(employeeID, key string, statusCode int, body []byte) error
+	Release(employeeID, key string) error
+}
+
+// responseRecorder keeps a copy of everything the handler writes.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency replays the stored response when a request is repeated with
+// the same Idempotency-Key header. Keys are scoped to the employee, and a key
+// sent again with another route or body is rejected. Requests without the
+// header are passed through untouched. Must run after AuthMiddleware.
+func Idempotency(store idempotencyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		employeeID := c.GetString("employee_id")
+		if employeeID == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Employee ID not found in context"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body: " + err.Error()})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		stored, err := store.Reserve(employeeID, key, c.Request.Method, c.FullPath(), requestHash)
+		if errors.Is(err, services.ErrIdempotencyKeyReused) {
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("[Idempotency] Reserve error: %v", err)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check idempotency key: " + err.Error()})
+			return
+		}
+		if stored != nil {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(*stored.StatusCode, "application/json; charset=utf-8", stored.ResponseBody)
+			c.Abort()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not kept, the client is expected to retry them
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(employeeID, key); err != nil {
+				log.Printf("[Idempotency] Release error: %v", err)
+			}
+			return
+		}
+		if err := store.Complete(employeeID, key, status, recorder.body.Bytes()); err != nil {
+			log.Printf("[Idempotency] Complete error: %v", err)
+		}
+	}
+}
@@ -71,8 +71,11 @@ func ValidateCardNumber() gin.HandlerFunc {
 	}
 }
 
-// ValidateReceiptNumber middleware validates receipt number format (10 characters)
+// ValidateReceiptNumber middleware validates receipt number format (10 characters,
+// random alphanumeric or sequential REGISTER-NUMBER)
 func ValidateReceiptNumber() gin.HandlerFunc {
+	receiptNumberRegex := regexp.MustCompile(`^[a-zA-Z0-9]+(-[0-9]+)?$`)
+
 	return func(c *gin.Context) {
 		receiptNumber := c.Param("receipt_number")
 		if len(receiptNumber) != 10 {
@@ -81,9 +84,9 @@ func ValidateReceiptNumber() gin.HandlerFunc {
 			return
 		}
 
-		// Check if receipt number contains only alphanumeric characters
-		if !isAlphanumeric(receiptNumber) {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Receipt number must contain only alphanumeric characters"})
+		// Check if receipt number contains only alphanumeric characters and the register separator
+		if !receiptNumberRegex.MatchString(receiptNumber) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Receipt number must be alphanumeric or REGISTER-NUMBER"})
 			c.Abort()
 			return
 		}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+const (
+	CartStatusOpen      = "open"
+	CartStatusFinalized = "finalized"
+	CartStatusCancelled = "cancelled"
+	CartStatusExpired   = "expired"
+)
+
+type CartCreate struct {
+	EmployeeId *string
+	CardNumber *string
+	CreatedAt  *time.Time
+	ExpiresAt  *time.Time
+}
+
+type CartRetrieve struct {
+	CartID        string    `json:"cart_id"`
+	EmployeeId    string    `json:"employee_id"`
+	CardNumber    *string   `json:"card_number"`
+	Status        string    `json:"status"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	ReceiptNumber *string   `json:"receipt_number"`
+}
+
+// CartItem is a scanned line joined with its current store_product row and
+// the row its upc_prom links to, if any; checkout prices it from one of them.
+type CartItem struct {
+	UPC           string
+	ProductNumber int
+	StoreProduct  StoreProductRetrieve
+	Promotion     *StoreProductRetrieve
+}
+
+// CartView is a cart with its lines and totals priced at the time of the
+// request.
+type CartView struct {
+	CartRetrieve
+	Items           []ReceiptLine    `json:"items"`
+	Subtotal        Money            `json:"subtotal"`
+	DiscountPercent int              `json:"discount_percent"`
+	DiscountSum     Money            `json:"discount_sum"`
+	TotalSum        Money            `json:"sum_total"`
+	VAT             Money            `json:"vat"`
+	VATBreakdown    []ReceiptVATLine `json:"vat_breakdown"`
+}
@@ -1,12 +1,16 @@
 package models
 
 type CategoryCreate struct {
-	Name string
+	Name    string
+	VATRate *float64
 }
 
+// CategoryRetrieve carries the category's own VAT rate; nil means the store
+// default VAT_RATE applies.
 type CategoryRetrieve struct {
-	ID   int
-	Name string
+	ID      int
+	Name    string
+	VATRate *float64
 }
 
 type CategoryUpdate struct {
@@ -7,7 +7,7 @@ type EmployeeCreate struct {
 	Name        *string
 	Patronymic  *string
 	Role        *string
-	Salary      *float64
+	Salary      *Money
 	DateOfBirth *time.Time
 	DateOfStart *time.Time
 	PhoneNumber *string
@@ -23,7 +23,7 @@ type EmployeeRetrieve struct {
 	Name        *string    `json:"empl_name"`
 	Patronymic  *string    `json:"empl_patronymic"`
 	Role        *string    `json:"empl_role"`
-	Salary      *float64   `json:"salary"`
+	Salary      *Money     `json:"salary"`
 	DateOfBirth *time.Time `json:"date_of_birth"`
 	DateOfStart *time.Time `json:"date_of_start"`
 	PhoneNumber *string    `json:"phone_number"`
@@ -42,7 +42,7 @@ type EmployeeUpdate struct {
 	Name        *string
 	Patronymic  *string
 	Role        *string
-	Salary      *float64
+	Salary      *Money
 	DateOfBirth *time.Time
 	DateOfStart *time.Time
 	PhoneNumber *string
@@ -0,0 +1,93 @@
+package models
+
+import "time"
+
+const (
+	GoodsReceivedStatusPosted   = "posted"
+	GoodsReceivedStatusReversed = "reversed"
+)
+
+// GoodsReceivedLineCreate receives Quantity units bought at CostPrice each.
+// The units go to UPC, or when only ProductID is set, to the product's
+// regular UPC. NewPrice, when set, reprices the UPC. ExpiryDate is the
+// YYYY-MM-DD day the units expire, when known.
+type GoodsReceivedLineCreate struct {
+	UPC        *string
+	ProductID  *int
+	Quantity   int
+	CostPrice  Money
+	NewPrice   *Money
+	ExpiryDate *string
+}
+
+// GoodsReceivedCreate is a supplier's delivery note. InvoiceDate is a
+// YYYY-MM-DD day.
+type GoodsReceivedCreate struct {
+	SupplierID    int
+	InvoiceNumber string
+	InvoiceDate   string
+	EmployeeId    string
+	Lines         []GoodsReceivedLineCreate
+	PostedAt      time.Time
+}
+
+// GoodsReceivedLineRecord is a line as it is booked, with the UPC resolved
+// and the selling price it replaced.
+type GoodsReceivedLineRecord struct {
+	LineNo        int
+	UPC           string
+	ProductID     int
+	Quantity      int
+	CostPrice     Money
+	NewPrice      *Money
+	PreviousPrice Money
+	ExpiryDate    *string
+}
+
+type GoodsReceivedRetrieve struct {
+	DocumentID     int        `json:"document_id"`
+	SupplierID     int        `json:"supplier_id"`
+	SupplierName   string     `json:"supplier_name"`
+	InvoiceNumber  string     `json:"invoice_number"`
+	InvoiceDate    string     `json:"invoice_date"`
+	EmployeeId     string     `json:"employee_id"`
+	Status         string     `json:"status"`
+	PostedAt       time.Time  `json:"posted_at"`
+	ReversedAt     *time.Time `json:"reversed_at"`
+	ReversedBy     *string    `json:"reversed_by"`
+	ReversalReason *string    `json:"reversal_reason"`
+	CostTotal      Money      `json:"cost_total"`
+}
+
+type GoodsReceivedLine struct {
+	DocumentID    int     `json:"-"`
+	LineNo        int     `json:"line_no"`
+	UPC           string  `json:"upc"`
+	ProductID     int     `json:"product_id"`
+	ProductName   string  `json:"product_name"`
+	Quantity      int     `json:"quantity"`
+	CostPrice     Money   `json:"cost_price"`
+	CostTotal     Money   `json:"cost_total"`
+	NewPrice      *Money  `json:"new_price"`
+	PreviousPrice Money   `json:"previous_price"`
+	ExpiryDate    *string `json:"expiry_date"`
+}
+
+type GoodsReceivedView struct {
+	GoodsReceivedRetrieve
+	Lines []GoodsReceivedLine `json:"lines"`
+}
+
+// GoodsReceivedFilter narrows GET /goods-received. From and To are
+// YYYY-MM-DD days bounding the invoice date; zero values do not filter.
+type GoodsReceivedFilter struct {
+	SupplierID *int
+	Status     string
+	From       string
+	To         string
+}
+
+type GoodsReceivedReverse struct {
+	EmployeeId string
+	Reason     string
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type IdempotencyKeyCreate struct {
+	Key         *string
+	EmployeeId  *string
+	Method      *string
+	Path        *string
+	RequestHash *string
+	CreatedAt   *time.Time
+	ExpiresAt   *time.Time
+}
+
+// IdempotencyKeyRetrieve is a stored request. StatusCode and ResponseBody
+// stay nil until the first request with the key has finished.
+type IdempotencyKeyRetrieve struct {
+	Key          string
+	EmployeeId   string
+	Method       string
+	Path         string
+	RequestHash  string
+	StatusCode   *int
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
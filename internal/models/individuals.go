@@ -2,13 +2,13 @@ package models
 
 // Vlad1 - Most sold product in a category within a time period
 type Vlad1Response struct {
-	CategoryID     int     `json:"category_id"`
-	CategoryName   string  `json:"category_name"`
-	ProductID      int     `json:"product_id"`
-	ProductName    string  `json:"product_name"`
-	TotalSales     int     `json:"total_sales"`
-	TotalUnitsSold int     `json:"total_units_sold"`
-	TotalRevenue   float64 `json:"total_revenue"`
+	CategoryID     int    `json:"category_id"`
+	CategoryName   string `json:"category_name"`
+	ProductID      int    `json:"product_id"`
+	ProductName    string `json:"product_name"`
+	TotalSales     int    `json:"total_sales"`
+	TotalUnitsSold int    `json:"total_units_sold"`
+	TotalRevenue   Money  `json:"total_revenue"`
 }
 
 // Vlad2 - Employees who never sold promotional products
@@ -20,9 +20,9 @@ type Vlad2Response struct {
 
 // Arthur1 - Category sales statistics within date range
 type Arthur1Response struct {
-	CategoryName string  `json:"category_name"`
-	UnitsSold    int     `json:"units_sold"`
-	Revenue      float64 `json:"revenue"`
+	CategoryName string `json:"category_name"`
+	UnitsSold    int    `json:"units_sold"`
+	Revenue      Money  `json:"revenue"`
 }
 
 // Arthur2 - Products in store that have never been sold and are not promotional
@@ -33,16 +33,21 @@ type Arthur2Response struct {
 	CategoryName   string `json:"category_name"`
 }
 
-// Oleksii1 - Cashiers who served customers with high discount
+// Oleksii1 - Cashiers who served customers with high discount.
+// AvgCustomerDiscount averages the card percents; AvgReceiptDiscount and
+// TotalDiscountHighDisc are the discounts stored on the receipts, which are 0
+// for receipts written before checkout applied card discounts.
 type Oleksii1Response struct {
 	EmployeeID            string  `json:"employee_id"`
 	EmployeeSurname       string  `json:"employee_surname"`
 	EmployeeName          string  `json:"employee_name"`
 	HighDiscountCustomers int     `json:"high_discount_customers"`
 	TotalReceiptsHighDisc int     `json:"total_receipts_high_discount"`
-	TotalRevenueHighDisc  float64 `json:"total_revenue_high_discount"`
-	AvgReceiptAmount      float64 `json:"avg_receipt_amount"`
+	TotalRevenueHighDisc  Money   `json:"total_revenue_high_discount"`
+	AvgReceiptAmount      Money   `json:"avg_receipt_amount"`
 	AvgCustomerDiscount   float64 `json:"avg_customer_discount"`
+	AvgReceiptDiscount    float64 `json:"avg_receipt_discount"`
+	TotalDiscountHighDisc Money   `json:"total_discount_high_discount"`
 }
 
 // Oleksii2 - Customers who bought from all categories in the last month
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// MoneyScale is the number of decimals amounts are rounded to on a receipt.
+const MoneyScale = 2
+
+// Money is an exact decimal amount. Prices and sums are stored as
+// DECIMAL(13,4), so a Money read from the database keeps all four decimals;
+// receipt level amounts are rounded to MoneyScale with Round.
+//
+// Money is encoded in JSON as a number with at least two decimals and is
+// decoded from either a number or a string.
+type Money struct {
+	d decimal.Decimal
+}
+
+func NewMoney(d decimal.Decimal) Money {
+	return Money{d: d}
+}
+
+func MoneyFromInt(value int64) Money {
+	return Money{d: decimal.NewFromInt(value)}
+}
+
+// ParseMoney reads an amount written in decimal notation, e.g. "12.50".
+func ParseMoney(value string) (Money, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", value, err)
+	}
+	return Money{d: d}, nil
+}
+
+func (m Money) Add(other Money) Money {
+	return Money{d: m.d.Add(other.d)}
+}
+
+func (m Money) Sub(other Money) Money {
+	return Money{d: m.d.Sub(other.d)}
+}
+
+// Mul multiplies the amount by a unit count.
+func (m Money) Mul(quantity int) Money {
+	return Money{d: m.d.Mul(decimal.NewFromInt(int64(quantity)))}
+}
+
+// MulRate multiplies the amount by a rate such as a VAT rate of 0.2.
+func (m Money) MulRate(rate Rate) Money {
+	return Money{d: m.d.Mul(rate.d)}
+}
+
+// Percent is percent hundredths of the amount, unrounded.
+func (m Money) Percent(percent int) Money {
+	return Money{d: m.d.Mul(decimal.NewFromInt(int64(percent))).Div(decimal.NewFromInt(100))}
+}
+
+// Round rounds to MoneyScale decimals, halves away from zero.
+func (m Money) Round() Money {
+	return m.RoundTo(MoneyScale)
+}
+
+// RoundTo rounds to places decimals, halves away from zero.
+func (m Money) RoundTo(places int32) Money {
+	return Money{d: m.d.Round(places)}
+}
+
+func (m Money) Cmp(other Money) int {
+	return m.d.Cmp(other.d)
+}
+
+func (m Money) Equal(other Money) bool {
+	return m.d.Equal(other.d)
+}
+
+func (m Money) IsZero() bool {
+	return m.d.IsZero()
+}
+
+func (m Money) IsNegative() bool {
+	return m.d.IsNegative()
+}
+
+func (m Money) IsPositive() bool {
+	return m.d.IsPositive()
+}
+
+// MinMoney returns the smaller of two amounts.
+func MinMoney(a, b Money) Money {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+	return b
+}
+
+func (m Money) Decimal() decimal.Decimal {
+	return m.d
+}
+
+// Float64 is the nearest float to the amount. It is meant for validation and
+// display only and must not be used for arithmetic.
+func (m Money) Float64() float64 {
+	f, _ := m.d.Float64()
+	return f
+}
+
+// String writes the amount with two decimals, or with all of its decimals
+// when it is not a whole number of kopecks.
+func (m Money) String() string {
+	if m.d.Equal(m.d.Round(MoneyScale)) {
+		return m.d.StringFixed(MoneyScale)
+	}
+	return m.d.String()
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	return m.d.UnmarshalJSON(data)
+}
+
+func (m *Money) Scan(value interface{}) error {
+	return m.d.Scan(value)
+}
+
+func (m Money) Value() (driver.Value, error) {
+	return m.d.Value()
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func mustMoney(t *testing.T, value string) Money {
+	t.Helper()
+	m, err := ParseMoney(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func mustRate(t *testing.T, value string) Rate {
+	t.Helper()
+	r, err := ParseRate(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestMoneyRound(t *testing.T) {
+	tests := []struct {
+		amount string
+		places int32
+		want   string
+	}{
+		{"0.005", 2, "0.01"},
+		{"-0.005", 2, "-0.01"},
+		{"0.0049", 2, "0.00"},
+		{"1.995", 2, "2.00"},
+		{"12.3450", 2, "12.35"},
+		{"0.00005", 4, "0.0001"},
+		{"0.00004", 4, "0"},
+	}
+	for _, tt := range tests {
+		got := mustMoney(t, tt.amount).RoundTo(tt.places)
+		if !got.Equal(mustMoney(t, tt.want)) {
+			t.Errorf("RoundTo(%s, %d) = %s, want %s", tt.amount, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want string
+	}{
+		{"mul", mustMoney(t, "33.33").Mul(3), "99.99"},
+		{"percent", mustMoney(t, "99.99").Percent(5), "4.9995"},
+		{"percent of zero", MoneyFromInt(0).Percent(50), "0"},
+		{"rate", mustMoney(t, "0.10").MulRate(mustRate(t, "0.2")), "0.02"},
+		{"rate with trailing zeros", mustMoney(t, "0.10").MulRate(mustRate(t, "0.2000")), "0.02"},
+		{"reduced rate", mustMoney(t, "19.99").MulRate(mustRate(t, "0.07")), "1.3993"},
+		{"sub", mustMoney(t, "0.3").Sub(mustMoney(t, "0.1")), "0.2"},
+	}
+	for _, tt := range tests {
+		if !tt.got.Equal(mustMoney(t, tt.want)) {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		amount string
+		want   string
+	}{
+		{"5", "5.00"},
+		{"12.5", "12.50"},
+		{"12.5000", "12.50"},
+		{"0.1234", "0.1234"},
+		{"-3.1", "-3.10"},
+	}
+	for _, tt := range tests {
+		if got := mustMoney(t, tt.amount).String(); got != tt.want {
+			t.Errorf("String(%s) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`12.5`, `12.50`},
+		{`"12.5"`, `12.50`},
+		{`0.1`, `0.10`},
+	}
+	for _, tt := range tests {
+		var m Money
+		if err := json.Unmarshal([]byte(tt.input), &m); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", tt.input, err)
+		}
+		got, err := json.Marshal(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("round trip of %s = %s, want %s", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRateString(t *testing.T) {
+	tests := []struct {
+		rate    string
+		want    string
+		percent string
+	}{
+		{"0.2", "0.2", "20"},
+		{"0.2000", "0.2", "20"},
+		{"0.065", "0.065", "6.5"},
+		{"0", "0", "0"},
+	}
+	for _, tt := range tests {
+		r := mustRate(t, tt.rate)
+		if got := r.String(); got != tt.want {
+			t.Errorf("String(%s) = %q, want %q", tt.rate, got, tt.want)
+		}
+		if got := r.Percent(); got != tt.percent {
+			t.Errorf("Percent(%s) = %q, want %q", tt.rate, got, tt.percent)
+		}
+	}
+}
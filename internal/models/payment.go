@@ -0,0 +1,39 @@
+package models
+
+const (
+	TenderCash    = "cash"
+	TenderCard    = "card"
+	TenderVoucher = "voucher"
+)
+
+// PaymentCreate is one tender handed over at the till. Tendered is what the
+// customer gave; for cash it may exceed what is left to pay.
+type PaymentCreate struct {
+	Tender    *string
+	Tendered  *Money
+	Reference *string
+}
+
+// PaymentRetrieve is a stored tender. Amount is the part applied to the
+// receipt total, Tendered minus Amount is the change given back.
+type PaymentRetrieve struct {
+	PaymentID     int     `json:"payment_id"`
+	ReceiptNumber string  `json:"receipt_number"`
+	Tender        string  `json:"tender"`
+	Amount        Money   `json:"amount"`
+	Tendered      Money   `json:"tendered"`
+	Reference     *string `json:"reference"`
+}
+
+// TenderTotal sums one tender for one cashier on one day.
+type TenderTotal struct {
+	EmployeeId      string `json:"employee_id"`
+	EmployeeSurname string `json:"empl_surname"`
+	EmployeeName    string `json:"empl_name"`
+	Day             string `json:"day"`
+	Tender          string `json:"tender"`
+	Receipts        int    `json:"receipts"`
+	Amount          Money  `json:"amount"`
+	Tendered        Money  `json:"tendered"`
+	Change          Money  `json:"change"`
+}
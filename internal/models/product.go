@@ -1,16 +1,20 @@
 package models
 
 type ProductCreate struct {
-	CategoryID      int    `json:"category_id" binding:"required"`
-	Name            string `json:"name" binding:"required"`
-	Characteristics string `json:"characteristics" binding:"required"`
+	CategoryID      int      `json:"category_id" binding:"required"`
+	Name            string   `json:"name" binding:"required"`
+	Characteristics string   `json:"characteristics" binding:"required"`
+	VATRate         *float64 `json:"vat_rate"`
 }
 
+// ProductRetrieve carries the product's VAT override; nil means the rate of
+// its category applies.
 type ProductRetrieve struct {
-	ID              int    `json:"id"`
-	CategoryID      int    `json:"category_id"`
-	Name            string `json:"name"`
-	Characteristics string `json:"characteristics"`
+	ID              int      `json:"id"`
+	CategoryID      int      `json:"category_id"`
+	Name            string   `json:"name"`
+	Characteristics string   `json:"characteristics"`
+	VATRate         *float64 `json:"vat_rate"`
 }
 
 type ProductUpdate struct {
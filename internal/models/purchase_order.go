@@ -0,0 +1,98 @@
+package models
+
+import "time"
+
+// A purchase order is drafted, sent to the supplier and then received,
+// possibly over several deliveries. Drafts and orders still awaiting goods
+// can be cancelled.
+const (
+	PurchaseOrderStatusDraft             = "draft"
+	PurchaseOrderStatusSent              = "sent"
+	PurchaseOrderStatusPartiallyReceived = "partially_received"
+	PurchaseOrderStatusReceived          = "received"
+	PurchaseOrderStatusCancelled         = "cancelled"
+)
+
+// PurchaseOrderLineCreate orders Quantity units of a product at the agreed
+// ExpectedCost per unit.
+type PurchaseOrderLineCreate struct {
+	ProductID    int
+	Quantity     int
+	ExpectedCost Money
+}
+
+type PurchaseOrderCreate struct {
+	SupplierID int
+	EmployeeId string
+	Note       *string
+	Lines      []PurchaseOrderLineCreate
+	CreatedAt  time.Time
+}
+
+// PurchaseOrderUpdate changes a draft. Nil fields are kept; Lines, when
+// set, replace all lines of the order.
+type PurchaseOrderUpdate struct {
+	SupplierID *int
+	Note       *string
+	Lines      []PurchaseOrderLineCreate
+}
+
+type PurchaseOrderRetrieve struct {
+	OrderID       int        `json:"order_id"`
+	SupplierID    int        `json:"supplier_id"`
+	SupplierName  string     `json:"supplier_name"`
+	EmployeeId    string     `json:"employee_id"`
+	Status        string     `json:"status"`
+	Note          *string    `json:"note"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	SentAt        *time.Time `json:"sent_at"`
+	ExpectedTotal Money      `json:"expected_total"`
+}
+
+type PurchaseOrderLine struct {
+	ProductID        int    `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	Quantity         int    `json:"quantity"`
+	ReceivedQuantity int    `json:"received_quantity"`
+	ExpectedCost     Money  `json:"expected_cost"`
+	ExpectedTotal    Money  `json:"expected_total"`
+}
+
+// PurchaseOrderDelivery is units of an order line booked to a UPC.
+type PurchaseOrderDelivery struct {
+	DeliveryID int       `json:"delivery_id"`
+	ProductID  int       `json:"product_id"`
+	UPC        string    `json:"upc"`
+	Quantity   int       `json:"quantity"`
+	ReceivedAt time.Time `json:"received_at"`
+	EmployeeId string    `json:"employee_id"`
+}
+
+type PurchaseOrderView struct {
+	PurchaseOrderRetrieve
+	Lines      []PurchaseOrderLine     `json:"lines"`
+	Deliveries []PurchaseOrderDelivery `json:"deliveries"`
+}
+
+// PurchaseOrderFilter narrows GET /purchase-orders. A nil SupplierID and an
+// empty Status do not filter.
+type PurchaseOrderFilter struct {
+	SupplierID *int
+	Status     string
+}
+
+// PurchaseOrderReceiveLine books Quantity units of an ordered product to
+// UPC, optionally repricing it. ExpiryDate is the YYYY-MM-DD day the units
+// expire, when known.
+type PurchaseOrderReceiveLine struct {
+	UPC          string
+	Quantity     int
+	SellingPrice *Money
+	ExpiryDate   *string
+}
+
+type PurchaseOrderReceive struct {
+	EmployeeId string
+	Lines      []PurchaseOrderReceiveLine
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Rate is an exact fraction such as a VAT rate of 0.2. Rates are stored as
+// DECIMAL(5,4) and read back without going through a float, so a rate taxes
+// and groups the same however many trailing zeros it was written with.
+//
+// Rate is encoded in JSON as a number and is decoded from either a number or
+// a string.
+type Rate struct {
+	d decimal.Decimal
+}
+
+func NewRate(d decimal.Decimal) Rate {
+	return Rate{d: d}
+}
+
+// ParseRate reads a rate written in decimal notation, e.g. "0.2".
+func ParseRate(value string) (Rate, error) {
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return Rate{}, fmt.Errorf("invalid rate %q: %w", value, err)
+	}
+	return Rate{d: d}, nil
+}
+
+func (r Rate) Cmp(other Rate) int {
+	return r.d.Cmp(other.d)
+}
+
+func (r Rate) Equal(other Rate) bool {
+	return r.d.Equal(other.d)
+}
+
+func (r Rate) Decimal() decimal.Decimal {
+	return r.d
+}
+
+// Percent writes the rate as a percentage without trailing zeros, e.g. "20"
+// for 0.2 or "6.5" for 0.065.
+func (r Rate) Percent() string {
+	return r.d.Shift(2).String()
+}
+
+// String writes the rate without trailing zeros, so equal rates have the
+// same string.
+func (r Rate) String() string {
+	return r.d.String()
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	return r.d.UnmarshalJSON(data)
+}
+
+func (r *Rate) Scan(value interface{}) error {
+	return r.d.Scan(value)
+}
+
+func (r Rate) Value() (driver.Value, error) {
+	return r.d.Value()
+}
@@ -2,29 +2,74 @@ package models
 
 import "time"
 
+// ReceiptCreate leaves ReceiptNumber nil to get a random number.
 type ReceiptCreate struct {
-	EmployeeId *string
-	CardNumber *string
-	PrintDate  *time.Time
-	TotalSum   *float64
-	VAT        *float64
+	ReceiptNumber   *string
+	EmployeeId      *string
+	CardNumber      *string
+	PrintDate       *time.Time
+	TotalSum        *Money
+	VAT             *Money
+	DiscountPercent *int
+	DiscountSum     *Money
+	ShiftID         *int
 }
 
 type ReceiptRetrieve struct {
-	ReceiptNumber *string
-	EmployeeId    *string
-	CardNumber    *string
-	PrintDate     *time.Time
-	TotalSum      *float64
-	VAT           *float64
+	ReceiptNumber   *string
+	EmployeeId      *string
+	CardNumber      *string
+	PrintDate       *time.Time
+	TotalSum        *Money
+	VAT             *Money
+	DiscountPercent *int
+	DiscountSum     *Money
+	VoidedAt        *time.Time
+	VoidedBy        *string
+	VoidReason      *string
+	ShiftID         *int
+}
+
+// ReceiptEditState is what settles whether the lines of a receipt may still
+// change: Paid is the sum of its payments' amounts, and ShiftClosed is false
+// for receipts written before shifts.
+type ReceiptEditState struct {
+	Payments    int
+	Paid        Money
+	Returned    bool
+	ShiftClosed bool
 }
 
+type ReceiptVoid struct {
+	VoidedBy *string
+	VoidedAt *time.Time
+	Reason   *string
+}
+
+// ReceiptUpdate changes the header of a receipt. The totals are not part of
+// it: they are derived from the sale lines.
 type ReceiptUpdate struct {
-	EmployeeId *string
-	CardNumber *string
-	PrintDate  *time.Time
-	TotalSum   *float64
-	VAT        *float64
+	EmployeeId      *string
+	CardNumber      *string
+	PrintDate       *time.Time
+	DiscountPercent *int
+}
+
+// ReceiptTotals are the amounts of a receipt that are derived from its sale
+// lines and discount.
+type ReceiptTotals struct {
+	TotalSum    Money `json:"sum_total"`
+	VAT         Money `json:"vat"`
+	DiscountSum Money `json:"discount_sum"`
+}
+
+// ReceiptTotalsMismatch is a receipt whose stored totals or line VAT differ
+// from the ones derived from its lines.
+type ReceiptTotalsMismatch struct {
+	ReceiptNumber string        `json:"receipt_number"`
+	Stored        ReceiptTotals `json:"stored"`
+	Derived       ReceiptTotals `json:"derived"`
+	LinesVAT      int           `json:"lines_vat_mismatched"`
 }
 
 type ReceiptCreateComplete struct {
@@ -32,10 +77,76 @@ type ReceiptCreateComplete struct {
 	CardNumber *string
 	PrintDate  *time.Time
 	Items      []ReceiptItem
+	Payments   []PaymentCreate
 }
 
 type ReceiptItem struct {
 	UPC           *string
 	ProductNumber *int
-	SellingPrice  *float64
+}
+
+// ReceiptLine is a checkout line priced by the server from store_product.
+// VATSum is charged on the line total after the receipt discount.
+type ReceiptLine struct {
+	UPC           string `json:"upc"`
+	ProductNumber int    `json:"product_number"`
+	UnitPrice     Money  `json:"unit_price"`
+	LineTotal     Money  `json:"line_total"`
+	Promotional   bool   `json:"promotional"`
+	VATRate       Rate   `json:"vat_rate"`
+	VATSum        Money  `json:"vat_sum"`
+}
+
+type ReceiptCompleteResult struct {
+	ReceiptNumber   string
+	Subtotal        Money
+	DiscountPercent int
+	DiscountSum     Money
+	TotalSum        Money
+	VAT             Money
+	VATBreakdown    []ReceiptVATLine
+	Lines           []ReceiptLine
+	Payments        []PaymentRetrieve
+	Change          Money
+	// TenderMismatch is set on an offline receipt whose tenders did not
+	// settle its total at the prices it was synced at.
+	TenderMismatch *TenderMismatch
+}
+
+// StockShortage describes a UPC whose stock could not cover the requested
+// quantity at checkout time.
+type StockShortage struct {
+	UPC       string `json:"upc"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+// ReceiptFilter narrows GET /receipts. Nil fields do not filter; a nil Voided
+// lists voided and active receipts alike.
+type ReceiptFilter struct {
+	EmployeeId    *string
+	CardNumber    *string
+	PrintDateFrom *time.Time
+	PrintDateTo   *time.Time
+	MinTotal      *Money
+	MaxTotal      *Money
+	Voided        *bool
+}
+
+// ReceiptPage selects one page of a sorted receipt listing. After is the
+// position of the last receipt of the previous page.
+type ReceiptPage struct {
+	Sort  string
+	Desc  bool
+	Limit int
+	After *ReceiptCursor
+}
+
+// ReceiptCursor is a position in a listing: the sort key of a receipt and its
+// number as a tie-breaker.
+type ReceiptCursor struct {
+	Sort          string `json:"s"`
+	Desc          bool   `json:"d"`
+	Value         string `json:"v"`
+	ReceiptNumber string `json:"r"`
 }
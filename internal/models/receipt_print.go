@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ReceiptPrint is everything the till receipt template can refer to.
+type ReceiptPrint struct {
+	StoreName       string
+	StoreAddress    string
+	StoreTaxID      string
+	ReceiptNumber   string
+	PrintDate       time.Time
+	Cashier         string
+	CardNumber      string
+	Lines           []ReceiptPrintLine
+	Subtotal        Money
+	DiscountPercent int
+	DiscountSum     Money
+	TotalSum        Money
+	VAT             []ReceiptVATLine
+	Payments        []PaymentRetrieve
+	Change          Money
+	Voided          bool
+	Width           int
+}
+
+type ReceiptPrintLine struct {
+	UPC          string
+	ProductName  string
+	Quantity     int
+	SellingPrice Money
+	TotalPrice   Money
+}
+
+// ReceiptVATLine is one rate of the receipt's VAT breakdown: Base is the
+// discounted amount of the lines sold at Rate and Amount the VAT on it.
+type ReceiptVATLine struct {
+	Rate   Rate  `json:"vat_rate"`
+	Base   Money `json:"taxable_sum"`
+	Amount Money `json:"vat_sum"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Outcomes of one offline receipt in a sync batch. A conflict is a receipt
+// that was written but sold more than the stock on hand, or whose payments
+// no longer matched its total at current prices.
+const (
+	ReceiptSyncStatusCreated   = "created"
+	ReceiptSyncStatusConflict  = "conflict"
+	ReceiptSyncStatusDuplicate = "duplicate"
+	ReceiptSyncStatusFailed    = "failed"
+)
+
+// ReceiptSyncItem is a receipt a till completed while offline. ClientID is
+// generated by the till and identifies the receipt across uploads; the
+// receipt's PrintDate is the time it was originally printed.
+type ReceiptSyncItem struct {
+	ClientID string
+	Receipt  ReceiptCreateComplete
+}
+
+type ReceiptSyncResult struct {
+	ClientID       string          `json:"client_id"`
+	Status         string          `json:"status"`
+	ReceiptNumber  *string         `json:"receipt_number"`
+	TotalSum       *Money          `json:"sum_total,omitempty"`
+	Conflicts      []StockShortage `json:"conflicts,omitempty"`
+	TenderMismatch *TenderMismatch `json:"tender_mismatch,omitempty"`
+	Error          *string         `json:"error,omitempty"`
+}
+
+// ReceiptSyncConflict is a UPC an offline receipt sold beyond the stock on
+// hand; the stock was left negative.
+type ReceiptSyncConflict struct {
+	ClientID      string    `json:"client_id"`
+	ReceiptNumber string    `json:"receipt_number"`
+	SyncedAt      time.Time `json:"synced_at"`
+	UPC           string    `json:"upc"`
+	Requested     int       `json:"requested"`
+	Available     int       `json:"available"`
+}
+
+// TenderMismatch is the difference between what an offline receipt was paid
+// and its total at the prices it was synced at.
+type TenderMismatch struct {
+	Due  Money `json:"due"`
+	Paid Money `json:"paid"`
+}
+
+// ReceiptSyncTenderMismatch is a synced receipt whose payments were kept as
+// paid although they did not match its total.
+type ReceiptSyncTenderMismatch struct {
+	ClientID      string    `json:"client_id"`
+	ReceiptNumber string    `json:"receipt_number"`
+	SyncedAt      time.Time `json:"synced_at"`
+	Due           Money     `json:"due"`
+	Paid          Money     `json:"paid"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+type ReturnCreate struct {
+	ReceiptNumber *string
+	EmployeeId    *string
+	ReturnDate    *time.Time
+	Reason        *string
+	TotalSum      *Money
+	VAT           *Money
+	ShiftID       *int
+	Items         []ReturnItem
+}
+
+type ReturnItem struct {
+	UPC           *string
+	ProductNumber *int
+	SellingPrice  *Money
+}
+
+type ReturnRetrieve struct {
+	ReturnNumber  string               `json:"return_number"`
+	ReceiptNumber string               `json:"receipt_number"`
+	EmployeeId    string               `json:"employee_id"`
+	ReturnDate    time.Time            `json:"return_date"`
+	Reason        string               `json:"reason"`
+	TotalSum      Money                `json:"sum_total"`
+	VAT           Money                `json:"vat"`
+	ShiftID       *int                 `json:"shift_id"`
+	Items         []ReturnItemRetrieve `json:"items,omitempty"`
+}
+
+type ReturnItemRetrieve struct {
+	UPC           string `json:"upc"`
+	ProductNumber int    `json:"product_number"`
+	SellingPrice  Money  `json:"selling_price"`
+	TotalPrice    Money  `json:"total_price"` // ProductNumber * SellingPrice
+}
+
+// ReturnableSale is a sale line together with the units already returned
+// against it.
+type ReturnableSale struct {
+	UPC           string
+	ProductNumber int
+	SellingPrice  Money
+	VATRate       Rate
+	Returned      int
+}
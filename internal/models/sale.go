@@ -1,34 +1,43 @@
 package models
 
 type SaleCreate struct {
-	UPC           string  `json:"upc" binding:"required,len=12"`
-	ReceiptNumber string  `json:"receipt_number" binding:"required,len=10"`
-	ProductNumber int     `json:"product_number" binding:"required,gte=1"`
-	SellingPrice  float64 `json:"selling_price" binding:"required,gte=0"`
+	UPC           string `json:"upc" binding:"required,len=12"`
+	ReceiptNumber string `json:"receipt_number" binding:"required,len=10"`
+	ProductNumber int    `json:"product_number" binding:"required,gte=1"`
+	SellingPrice  Money  `json:"selling_price" binding:"required,gte=0"`
+	VATRate       Rate   `json:"-"`
+	VATSum        Money  `json:"-"`
 }
 
+// SaleRetrieve includes the VAT rate resolved when the line was sold and the
+// VAT charged on it after the receipt discount.
 type SaleRetrieve struct {
-	UPC           string  `json:"upc"`
-	ReceiptNumber string  `json:"receipt_number"`
-	ProductNumber int     `json:"product_number"`
-	SellingPrice  float64 `json:"selling_price"`
+	UPC           string `json:"upc"`
+	ReceiptNumber string `json:"receipt_number"`
+	ProductNumber int    `json:"product_number"`
+	SellingPrice  Money  `json:"selling_price"`
+	VATRate       Rate   `json:"vat_rate"`
+	VATSum        Money  `json:"vat_sum"`
 }
 
 type SaleUpdate struct {
-	ProductNumber *int     `json:"product_number" binding:"omitempty,gte=1"`
-	SellingPrice  *float64 `json:"selling_price" binding:"omitempty,gte=0"`
+	ProductNumber *int   `json:"product_number" binding:"omitempty,gte=1"`
+	SellingPrice  *Money `json:"selling_price" binding:"omitempty,gte=0"`
+	VATSum        *Money `json:"-"`
 }
 
 // Extended model with product details for API responses
 type SaleWithDetails struct {
-	UPC             string  `json:"upc"`
-	ReceiptNumber   string  `json:"receipt_number"`
-	ProductNumber   int     `json:"product_number"`
-	SellingPrice    float64 `json:"selling_price"`
-	ProductName     string  `json:"product_name"`
-	CategoryName    string  `json:"category_name"`
-	Characteristics string  `json:"characteristics"`
-	TotalPrice      float64 `json:"total_price"` // ProductNumber * SellingPrice
+	UPC             string `json:"upc"`
+	ReceiptNumber   string `json:"receipt_number"`
+	ProductNumber   int    `json:"product_number"`
+	SellingPrice    Money  `json:"selling_price"`
+	ProductName     string `json:"product_name"`
+	CategoryName    string `json:"category_name"`
+	Characteristics string `json:"characteristics"`
+	TotalPrice      Money  `json:"total_price"` // ProductNumber * SellingPrice
+	VATRate         Rate   `json:"vat_rate"`
+	VATSum          Money  `json:"vat_sum"`
 }
 
 // Composite key for sale operations
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+const (
+	ShiftReportX = "X"
+	ShiftReportZ = "Z"
+)
+
+type ShiftCreate struct {
+	RegisterId   *string
+	EmployeeId   *string
+	OpenedAt     *time.Time
+	OpeningFloat *Money
+}
+
+// ShiftRetrieve is a cashier's working session on one register. A shift is
+// open until ClosedAt is set by its Z-report.
+type ShiftRetrieve struct {
+	ShiftID      int        `json:"shift_id"`
+	RegisterId   string     `json:"register_id"`
+	EmployeeId   string     `json:"employee_id"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	OpeningFloat Money      `json:"opening_float"`
+	ClosedAt     *time.Time `json:"closed_at"`
+	ClosedBy     *string    `json:"closed_by"`
+}
+
+// ShiftFilter narrows GET /shifts. Empty strings and a nil Open do not
+// filter.
+type ShiftFilter struct {
+	RegisterId string
+	EmployeeId string
+	Open       *bool
+}
+
+// ShiftReport sums the receipts and returns of a shift. An X-report is taken
+// while the shift is open, the Z-report is the one that closed it. Voided
+// receipts are counted but left out of every total. With sequential numbering
+// FirstReceipt and LastReceipt bound the receipt numbers the shift issued.
+type ShiftReport struct {
+	Kind           string             `json:"kind"`
+	GeneratedAt    time.Time          `json:"generated_at"`
+	Shift          ShiftRetrieve      `json:"shift"`
+	Receipts       int                `json:"receipts"`
+	VoidedReceipts int                `json:"voided_receipts"`
+	FirstReceipt   *string            `json:"first_receipt"`
+	LastReceipt    *string            `json:"last_receipt"`
+	SalesTotal     Money              `json:"sales_total"`
+	DiscountTotal  Money              `json:"discount_total"`
+	VATTotal       Money              `json:"vat_total"`
+	VAT            []ReceiptVATLine   `json:"vat"`
+	Tenders        []ShiftTenderTotal `json:"tenders"`
+	Returns        int                `json:"returns"`
+	ReturnsTotal   Money              `json:"returns_total"`
+	ReturnsVAT     Money              `json:"returns_vat"`
+	NetTotal       Money              `json:"net_total"`
+	ExpectedCash   Money              `json:"expected_cash"`
+}
+
+// ShiftTenderTotal sums one tender over the receipts of a shift.
+type ShiftTenderTotal struct {
+	Tender   string `json:"tender"`
+	Receipts int    `json:"receipts"`
+	Amount   Money  `json:"amount"`
+	Tendered Money  `json:"tendered"`
+	Change   Money  `json:"change"`
+}
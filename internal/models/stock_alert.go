@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+const (
+	StockAlertStatusOpen     = "open"
+	StockAlertStatusResolved = "resolved"
+)
+
+// ReorderLevels are a product's low-stock threshold: each of its UPCs
+// should keep at least MinStock units, and ReorderQuantity units are ordered
+// when one does not.
+type ReorderLevels struct {
+	ProductID       int `json:"product_id"`
+	MinStock        int `json:"min_stock"`
+	ReorderQuantity int `json:"reorder_quantity"`
+}
+
+// LowStockProduct is a UPC below its product's MinStock. UnitsSold are the
+// units sold net of returns over the last Days days, and DailyVelocity
+// their average per day; DaysOfCover is how long the stock lasts at that
+// rate, nil when nothing sold.
+type LowStockProduct struct {
+	UPC                string   `json:"upc"`
+	ProductID          int      `json:"product_id"`
+	ProductName        string   `json:"product_name"`
+	CategoryID         int      `json:"category_id"`
+	CategoryName       string   `json:"category_name"`
+	PromotionalProduct bool     `json:"promotional_product"`
+	ProductsNumber     int      `json:"products_number"`
+	MinStock           int      `json:"min_stock"`
+	ReorderQuantity    int      `json:"reorder_quantity"`
+	Shortfall          int      `json:"shortfall"`
+	Days               int      `json:"days"`
+	UnitsSold          int      `json:"units_sold"`
+	DailyVelocity      float64  `json:"daily_velocity"`
+	DaysOfCover        *float64 `json:"days_of_cover"`
+}
+
+// LowStockFilter narrows the low-stock report. Days is the window sales
+// velocity is measured over; a nil CategoryID covers every category.
+type LowStockFilter struct {
+	Days       int
+	CategoryID *int
+}
+
+// StockAlert is raised when a sale, adjustment or write-off takes a UPC
+// below its product's MinStock. Stock is the UPC's stock right after MovementID.
+type StockAlert struct {
+	AlertID         int        `json:"alert_id"`
+	UPC             string     `json:"upc"`
+	ProductID       int        `json:"product_id"`
+	ProductName     string     `json:"product_name"`
+	CategoryName    string     `json:"category_name"`
+	MovementID      int64      `json:"movement_id"`
+	Kind            string     `json:"kind"`
+	Stock           int        `json:"stock"`
+	MinStock        int        `json:"min_stock"`
+	ReorderQuantity int        `json:"reorder_quantity"`
+	CurrentStock    int        `json:"current_stock"`
+	CreatedAt       time.Time  `json:"created_at"`
+	Status          string     `json:"status"`
+	ResolvedAt      *time.Time `json:"resolved_at"`
+	ResolvedBy      *string    `json:"resolved_by"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// StockBatch is units of a UPC that arrived together. ExpiryDate is a
+// YYYY-MM-DD day, nil when unknown; Quantity is what is left of
+// InitialQuantity.
+type StockBatch struct {
+	BatchID         int       `json:"batch_id"`
+	UPC             string    `json:"upc"`
+	ReceivedAt      time.Time `json:"received_at"`
+	ExpiryDate      *string   `json:"expiry_date"`
+	InitialQuantity int       `json:"initial_quantity"`
+	Quantity        int       `json:"quantity"`
+	Reference       *string   `json:"reference"`
+}
+
+// ExpiringBatch is a batch on hand that expires soon or has expired;
+// DaysLeft is negative for the latter. Value prices the units left at the
+// UPC's selling price.
+type ExpiringBatch struct {
+	StockBatch
+	ProductID          int    `json:"product_id"`
+	ProductName        string `json:"product_name"`
+	CategoryName       string `json:"category_name"`
+	SellingPrice       Money  `json:"selling_price"`
+	PromotionalProduct bool   `json:"promotional_product"`
+	DaysLeft           int    `json:"days_left"`
+	Value              Money  `json:"value"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Kinds of stock movement. An opening movement carries the stock a UPC had
+// before the ledger was introduced; a write-off takes out goods that were
+// not sold.
+const (
+	StockMovementOpening       = "opening"
+	StockMovementSale          = "sale"
+	StockMovementReturn        = "return"
+	StockMovementVoid          = "void"
+	StockMovementDelivery      = "delivery"
+	StockMovementAdjustment    = "adjustment"
+	StockMovementPromoTransfer = "promo_transfer"
+	StockMovementWriteOff      = "write_off"
+)
+
+// StockMovementCreate describes a change to a UPC's stock. Reference names
+// the document behind it, such as a receipt or return number.
+type StockMovementCreate struct {
+	UPC        string
+	Delta      int
+	Kind       string
+	EmployeeId *string
+	Reason     *string
+	Reference  *string
+}
+
+// StockMovementRetrieve is a ledger entry; Balance is the UPC's stock right
+// after it.
+type StockMovementRetrieve struct {
+	MovementID int64     `json:"movement_id"`
+	UPC        string    `json:"upc"`
+	MovedAt    time.Time `json:"moved_at"`
+	Kind       string    `json:"kind"`
+	Delta      int       `json:"delta"`
+	Balance    int       `json:"balance"`
+	EmployeeId *string   `json:"employee_id"`
+	Reason     *string   `json:"reason"`
+	Reference  *string   `json:"reference"`
+}
+
+// StockLedgerCheck compares a UPC's stock with the stock rebuilt from its
+// ledger: the sum of its movements and the balance of the latest one.
+type StockLedgerCheck struct {
+	UPC         string `json:"upc"`
+	Stock       int    `json:"products_number"`
+	LedgerStock int    `json:"ledger_stock"`
+	LastBalance *int   `json:"last_balance"`
+	Movements   int    `json:"movements"`
+	Matches     bool   `json:"matches"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+const (
+	StocktakeStatusOpen      = "open"
+	StocktakeStatusApproved  = "approved"
+	StocktakeStatusCancelled = "cancelled"
+)
+
+// StocktakeCreate opens a stocktake of the category, or of every store
+// product when CategoryID is nil.
+type StocktakeCreate struct {
+	CategoryID *int
+	Note       *string
+	EmployeeId string
+	OpenedAt   time.Time
+}
+
+// StocktakeCountCreate is Quantity units of UPC counted at Location, such as
+// a shelf or the back room.
+type StocktakeCountCreate struct {
+	UPC      string
+	Quantity int
+	Location *string
+}
+
+type StocktakeRetrieve struct {
+	StocktakeID  int        `json:"stocktake_id"`
+	CategoryID   *int       `json:"category_id"`
+	CategoryName *string    `json:"category_name"`
+	Note         *string    `json:"note"`
+	Status       string     `json:"status"`
+	OpenedBy     string     `json:"opened_by"`
+	OpenedAt     time.Time  `json:"opened_at"`
+	ClosedBy     *string    `json:"closed_by"`
+	ClosedAt     *time.Time `json:"closed_at"`
+}
+
+type StocktakeCount struct {
+	CountID     int       `json:"count_id"`
+	UPC         string    `json:"upc"`
+	Quantity    int       `json:"quantity"`
+	Location    *string   `json:"location"`
+	EmployeeId  string    `json:"employee_id"`
+	CountedAt   time.Time `json:"counted_at"`
+	SystemStock int       `json:"system_stock"`
+}
+
+// StocktakeLine compares the merged count of a UPC with its stock when it was
+// first counted. While the stocktake is open, UPCs not counted yet show the
+// current stock with a nil Counted and Variance; once approved the lines are
+// the counted UPCs as they were posted.
+type StocktakeLine struct {
+	UPC                string `json:"upc"`
+	ProductID          int    `json:"product_id"`
+	ProductName        string `json:"product_name"`
+	PromotionalProduct bool   `json:"promotional_product"`
+	Counted            *int   `json:"counted"`
+	SystemStock        int    `json:"system_stock"`
+	Variance           *int   `json:"variance"`
+}
+
+// StocktakeLineRecord is a counted UPC as it is posted on approval.
+type StocktakeLineRecord struct {
+	UPC         string
+	ProductID   int
+	Counted     int
+	SystemStock int
+	Variance    int
+}
+
+type StocktakeView struct {
+	StocktakeRetrieve
+	Lines  []StocktakeLine  `json:"lines"`
+	Counts []StocktakeCount `json:"counts"`
+}
@@ -0,0 +1,32 @@
+package models
+
+type SupplierCreate struct {
+	Name        *string
+	ContactName *string
+	PhoneNumber *string
+	Email       *string
+	City        *string
+	Street      *string
+	ZipCode     *string
+}
+
+type SupplierRetrieve struct {
+	SupplierID  int     `json:"supplier_id"`
+	Name        string  `json:"supplier_name"`
+	ContactName *string `json:"contact_name"`
+	PhoneNumber *string `json:"phone_number"`
+	Email       *string `json:"email"`
+	City        *string `json:"city"`
+	Street      *string `json:"street"`
+	ZipCode     *string `json:"zip_code"`
+}
+
+type SupplierUpdate struct {
+	Name        *string
+	ContactName *string
+	PhoneNumber *string
+	Email       *string
+	City        *string
+	Street      *string
+	ZipCode     *string
+}
@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// Reasons goods are written off.
+const (
+	WriteOffReasonExpired     = "expired"
+	WriteOffReasonDamaged     = "damaged"
+	WriteOffReasonTheft       = "theft"
+	WriteOffReasonInternalUse = "internal_use"
+)
+
+type WriteOffLineCreate struct {
+	UPC      string
+	Quantity int
+}
+
+type WriteOffCreate struct {
+	Reason       string
+	Note         *string
+	EmployeeId   string
+	Lines        []WriteOffLineCreate
+	WrittenOffAt time.Time
+}
+
+// WriteOffLineRecord is a line as it is booked, valued at the UPC's selling
+// price at that moment.
+type WriteOffLineRecord struct {
+	LineNo    int
+	UPC       string
+	ProductID int
+	Quantity  int
+	UnitPrice Money
+}
+
+type WriteOffRetrieve struct {
+	WriteOffID   int       `json:"write_off_id"`
+	Reason       string    `json:"reason"`
+	Note         *string   `json:"note"`
+	EmployeeId   string    `json:"employee_id"`
+	WrittenOffAt time.Time `json:"written_off_at"`
+	Quantity     int       `json:"quantity"`
+	Value        Money     `json:"value"`
+}
+
+type WriteOffLine struct {
+	WriteOffID   int    `json:"-"`
+	LineNo       int    `json:"line_no"`
+	UPC          string `json:"upc"`
+	ProductID    int    `json:"product_id"`
+	ProductName  string `json:"product_name"`
+	CategoryName string `json:"category_name"`
+	Quantity     int    `json:"quantity"`
+	UnitPrice    Money  `json:"unit_price"`
+	Value        Money  `json:"value"`
+}
+
+type WriteOffView struct {
+	WriteOffRetrieve
+	Lines []WriteOffLine `json:"lines"`
+}
+
+// WriteOffFilter narrows GET /write-offs. Nil fields do not filter; To is
+// exclusive.
+type WriteOffFilter struct {
+	Reason string
+	From   *time.Time
+	To     *time.Time
+}
+
+// WriteOffReportRow sums the write-offs of one category for one reason.
+type WriteOffReportRow struct {
+	CategoryID   int    `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	Reason       string `json:"reason"`
+	WriteOffs    int    `json:"write_offs"`
+	Quantity     int    `json:"quantity"`
+	Value        Money  `json:"value"`
+}
+
+// WriteOffReport sums the write-offs of a YYYY-MM month in the store's time
+// zone by category and reason.
+type WriteOffReport struct {
+	Month    string              `json:"month"`
+	Rows     []WriteOffReportRow `json:"rows"`
+	Quantity int                 `json:"quantity"`
+	Value    Money               `json:"value"`
+}
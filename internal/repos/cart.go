@@ -0,0 +1,290 @@
+package repos
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/utils"
+)
+
+func getNewCartID(q dbtx) (string, error) {
+	const maxRetries = 10
+
+	for i := 0; i < maxRetries; i++ {
+		cartID, err := utils.GenerateID(10)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate cart ID: %w", err)
+		}
+
+		var exists bool
+		err = q.QueryRow("SELECT EXISTS(SELECT 1 FROM cart WHERE cart_id = $1)", cartID).Scan(&exists)
+		if err != nil {
+			return "", fmt.Errorf("failed to check cart ID uniqueness: %w", err)
+		}
+
+		if !exists {
+			return cartID, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to generate unique cart ID after %d attempts", maxRetries)
+}
+
+type CartRepo struct {
+	db *sql.DB
+}
+
+func NewCartRepo(db *sql.DB) *CartRepo {
+	return &CartRepo{
+		db: db,
+	}
+}
+
+func (r *CartRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func (r *CartRepo) CreateCart(c models.CartCreate) (string, error) {
+	query := `
+		INSERT INTO cart (
+			cart_id,
+			employee_id,
+			card_number,
+			status,
+			created_at,
+			updated_at,
+			expires_at
+		) VALUES ($1, $2, $3, $4, $5, $5, $6)
+		RETURNING cart_id
+	`
+
+	cartID, err := getNewCartID(r.db)
+	if err != nil {
+		return "", err
+	}
+	err = r.db.QueryRow(
+		query,
+		cartID,
+		c.EmployeeId,
+		c.CardNumber,
+		models.CartStatusOpen,
+		c.CreatedAt,
+		c.ExpiresAt,
+	).Scan(&cartID)
+
+	return cartID, err
+}
+
+func (r *CartRepo) RetrieveCartByID(cartID string) (models.CartRetrieve, error) {
+	return retrieveCart(r.db, cartID, false)
+}
+
+// RetrieveCartForUpdateTx reads a cart and locks its row so that concurrent
+// scans on the same till are applied one after another.
+func (r *CartRepo) RetrieveCartForUpdateTx(tx *sql.Tx, cartID string) (models.CartRetrieve, error) {
+	return retrieveCart(tx, cartID, true)
+}
+
+func retrieveCart(q dbtx, cartID string, forUpdate bool) (models.CartRetrieve, error) {
+	query := `
+		SELECT
+			cart_id,
+			employee_id,
+			card_number,
+			status,
+			created_at,
+			updated_at,
+			expires_at,
+			receipt_number
+		FROM cart
+		WHERE cart_id = $1
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var cart models.CartRetrieve
+	err := q.QueryRow(query, cartID).Scan(
+		&cart.CartID,
+		&cart.EmployeeId,
+		&cart.CardNumber,
+		&cart.Status,
+		&cart.CreatedAt,
+		&cart.UpdatedAt,
+		&cart.ExpiresAt,
+		&cart.ReceiptNumber,
+	)
+	if err != nil {
+		return models.CartRetrieve{}, err
+	}
+
+	return cart, nil
+}
+
+func (r *CartRepo) RetrieveCartItems(cartID string) ([]models.CartItem, error) {
+	return retrieveCartItems(r.db, cartID)
+}
+
+func (r *CartRepo) RetrieveCartItemsTx(tx *sql.Tx, cartID string) ([]models.CartItem, error) {
+	return retrieveCartItems(tx, cartID)
+}
+
+func retrieveCartItems(q dbtx, cartID string) ([]models.CartItem, error) {
+	query := `
+		SELECT
+			ci.upc,
+			ci.product_number,
+			sp.upc,
+			sp.upc_prom,
+			sp.product_id,
+			sp.selling_price,
+			sp.products_number,
+			sp.promotional_product,
+			pp.upc,
+			pp.product_id,
+			pp.selling_price,
+			pp.products_number,
+			pp.promotional_product
+		FROM cart_item ci
+		JOIN store_product sp ON sp.upc = ci.upc
+		LEFT JOIN store_product pp ON pp.upc = sp.upc_prom
+		WHERE ci.cart_id = $1
+		ORDER BY ci.added_at, ci.upc
+	`
+
+	rows, err := q.Query(query, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.CartItem
+	for rows.Next() {
+		var item models.CartItem
+		var promotionUPC *string
+		var promotionProductID, promotionNumber *int
+		var promotionPrice *models.Money
+		var promotionPromotional *bool
+		err := rows.Scan(
+			&item.UPC,
+			&item.ProductNumber,
+			&item.StoreProduct.UPC,
+			&item.StoreProduct.UPCProm,
+			&item.StoreProduct.ProductID,
+			&item.StoreProduct.SellingPrice,
+			&item.StoreProduct.ProductsNumber,
+			&item.StoreProduct.PromotionalProduct,
+			&promotionUPC,
+			&promotionProductID,
+			&promotionPrice,
+			&promotionNumber,
+			&promotionPromotional,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if promotionUPC != nil {
+			item.Promotion = &models.StoreProductRetrieve{
+				UPC:                *promotionUPC,
+				ProductID:          *promotionProductID,
+				SellingPrice:       *promotionPrice,
+				ProductsNumber:     *promotionNumber,
+				PromotionalProduct: *promotionPromotional,
+			}
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// AddCartItemTx adds units of a UPC to the cart, increasing the quantity if
+// the UPC has already been scanned.
+func (r *CartRepo) AddCartItemTx(tx *sql.Tx, cartID, upc string, quantity int, addedAt time.Time) error {
+	query := `
+		INSERT INTO cart_item (cart_id, upc, product_number, added_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (cart_id, upc)
+		DO UPDATE SET product_number = cart_item.product_number + EXCLUDED.product_number
+	`
+	_, err := tx.Exec(query, cartID, upc, quantity, addedAt)
+	return err
+}
+
+// SetCartItemQuantityTx sets the quantity of a UPC in the cart, adding the
+// line if it is not there yet.
+func (r *CartRepo) SetCartItemQuantityTx(tx *sql.Tx, cartID, upc string, quantity int, addedAt time.Time) error {
+	query := `
+		INSERT INTO cart_item (cart_id, upc, product_number, added_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (cart_id, upc)
+		DO UPDATE SET product_number = EXCLUDED.product_number
+	`
+	_, err := tx.Exec(query, cartID, upc, quantity, addedAt)
+	return err
+}
+
+// DeleteCartItemTx removes the UPC from the cart, or returns sql.ErrNoRows
+// when it is not in the cart.
+func (r *CartRepo) DeleteCartItemTx(tx *sql.Tx, cartID, upc string) error {
+	query := `DELETE FROM cart_item WHERE cart_id = $1 AND upc = $2`
+	result, err := tx.Exec(query, cartID, upc)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *CartRepo) SetCartCardTx(tx *sql.Tx, cartID string, cardNumber *string) error {
+	query := `UPDATE cart SET card_number = $2 WHERE cart_id = $1`
+	_, err := tx.Exec(query, cartID, cardNumber)
+	return err
+}
+
+// TouchCartTx records activity on a cart and pushes its expiry forward.
+func (r *CartRepo) TouchCartTx(tx *sql.Tx, cartID string, updatedAt, expiresAt time.Time) error {
+	query := `UPDATE cart SET updated_at = $2, expires_at = $3 WHERE cart_id = $1`
+	_, err := tx.Exec(query, cartID, updatedAt, expiresAt)
+	return err
+}
+
+// CloseCartTx moves an open cart into a final status, optionally linking the
+// receipt it was turned into.
+func (r *CartRepo) CloseCartTx(tx *sql.Tx, cartID string, status string, receiptNumber *string, updatedAt time.Time) error {
+	query := `
+		UPDATE cart
+		SET
+			status = $2,
+			receipt_number = $3,
+			updated_at = $4
+		WHERE cart_id = $1 AND status = 'open'
+	`
+	_, err := tx.Exec(query, cartID, status, receiptNumber, updatedAt)
+	return err
+}
+
+// ExpireCarts marks every open cart whose expiry has passed as expired.
+func (r *CartRepo) ExpireCarts(now time.Time) (int64, error) {
+	query := `
+		UPDATE cart
+		SET status = 'expired', updated_at = $1
+		WHERE status = 'open' AND expires_at <= $1
+	`
+	result, err := r.db.Exec(query, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -18,17 +18,17 @@ func NewCategoryRepo(db *sql.DB) *CategoryRepo {
 
 func (r *CategoryRepo) CreateCategory(c models.CategoryCreate) (int, error) {
 	var id int
-	query := `INSERT INTO category (category_name) VALUES($1) RETURNING category_id`
-	err := r.db.QueryRow(query, c.Name).Scan(&id)
+	query := `INSERT INTO category (category_name, vat_rate) VALUES($1, $2) RETURNING category_id`
+	err := r.db.QueryRow(query, c.Name, c.VATRate).Scan(&id)
 	return id, err
 }
 
 func (r *CategoryRepo) RetrieveCategoryByID(id int) (models.CategoryRetrieve, error) {
-	query := `SELECT category_id, category_name FROM category WHERE category_id = $1`
+	query := `SELECT category_id, category_name, vat_rate FROM category WHERE category_id = $1`
 	row := r.db.QueryRow(query, id)
 
 	var category models.CategoryRetrieve
-	err := row.Scan(&category.ID, &category.Name)
+	err := row.Scan(&category.ID, &category.Name, &category.VATRate)
 	if err != nil {
 		return models.CategoryRetrieve{}, err
 	}
@@ -37,7 +37,7 @@ func (r *CategoryRepo) RetrieveCategoryByID(id int) (models.CategoryRetrieve, er
 }
 
 func (r *CategoryRepo) RetrieveCategories() ([]models.CategoryRetrieve, error) {
-	query := `SELECT category_id, category_name FROM category`
+	query := `SELECT category_id, category_name, vat_rate FROM category`
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -47,7 +47,7 @@ func (r *CategoryRepo) RetrieveCategories() ([]models.CategoryRetrieve, error) {
 	var categories []models.CategoryRetrieve
 	for rows.Next() {
 		var category models.CategoryRetrieve
-		err := rows.Scan(&category.ID, &category.Name)
+		err := rows.Scan(&category.ID, &category.Name, &category.VATRate)
 		if err != nil {
 			return nil, err
 		}
@@ -68,3 +68,11 @@ func (r *CategoryRepo) UpdateCategory(id int, c models.CategoryUpdate) error {
 	_, err := r.db.Exec(query, c.Name, id)
 	return err
 }
+
+// SetCategoryVATRate sets the rate of the category; nil clears it so the
+// store default applies.
+func (r *CategoryRepo) SetCategoryVATRate(id int, rate *float64) error {
+	query := `UPDATE category SET vat_rate = $1 WHERE category_id = $2`
+	_, err := r.db.Exec(query, rate, id)
+	return err
+}
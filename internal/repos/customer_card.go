@@ -78,6 +78,14 @@ func (r *CustomerCardRepo) CreateCustomerCard(c models.CustomerCardCreate) (stri
 }
 
 func (r *CustomerCardRepo) RetrieveCustomerCardByCardNumber(cardNumber string) (models.CustomerCardRetrieve, error) {
+	return retrieveCustomerCardByCardNumber(r.db, cardNumber)
+}
+
+func (r *CustomerCardRepo) RetrieveCustomerCardByCardNumberTx(tx *sql.Tx, cardNumber string) (models.CustomerCardRetrieve, error) {
+	return retrieveCustomerCardByCardNumber(tx, cardNumber)
+}
+
+func retrieveCustomerCardByCardNumber(q dbtx, cardNumber string) (models.CustomerCardRetrieve, error) {
 	query := `
 		SELECT
 			card_number,
@@ -93,7 +101,7 @@ func (r *CustomerCardRepo) RetrieveCustomerCardByCardNumber(cardNumber string) (
 		WHERE card_number = $1
 	`
 	var customerCard models.CustomerCardRetrieve
-	err := r.db.QueryRow(query, cardNumber).Scan(
+	err := q.QueryRow(query, cardNumber).Scan(
 		&customerCard.CardNumber,
 		&customerCard.Surname,
 		&customerCard.Name,
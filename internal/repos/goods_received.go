@@ -0,0 +1,246 @@
+package repos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type GoodsReceivedRepo struct {
+	db *sql.DB
+}
+
+func NewGoodsReceivedRepo(db *sql.DB) *GoodsReceivedRepo {
+	return &GoodsReceivedRepo{
+		db: db,
+	}
+}
+
+func (r *GoodsReceivedRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// RetrievePostedGoodsReceivedByInvoiceTx returns the posted document that
+// booked the supplier's invoice, or sql.ErrNoRows.
+func (r *GoodsReceivedRepo) RetrievePostedGoodsReceivedByInvoiceTx(tx *sql.Tx, supplierID int, invoiceNumber string) (int, error) {
+	query := `
+		SELECT document_id
+		FROM goods_received
+		WHERE supplier_id = $1 AND invoice_number = $2 AND status = 'posted'
+	`
+	var documentID int
+	err := tx.QueryRow(query, supplierID, invoiceNumber).Scan(&documentID)
+	return documentID, err
+}
+
+func (r *GoodsReceivedRepo) CreateGoodsReceivedTx(tx *sql.Tx, c models.GoodsReceivedCreate) (int, error) {
+	query := `
+		INSERT INTO goods_received (
+			supplier_id,
+			invoice_number,
+			invoice_date,
+			employee_id,
+			posted_at
+		) VALUES ($1, $2, $3, $4, $5)
+		RETURNING document_id
+	`
+
+	var documentID int
+	err := tx.QueryRow(
+		query,
+		c.SupplierID,
+		c.InvoiceNumber,
+		c.InvoiceDate,
+		c.EmployeeId,
+		c.PostedAt,
+	).Scan(&documentID)
+
+	return documentID, err
+}
+
+func (r *GoodsReceivedRepo) CreateGoodsReceivedLineTx(tx *sql.Tx, documentID int, l models.GoodsReceivedLineRecord) error {
+	query := `
+		INSERT INTO goods_received_line (
+			document_id,
+			line_no,
+			upc,
+			product_id,
+			quantity,
+			cost_price,
+			new_price,
+			previous_price,
+			expiry_date
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+	_, err := tx.Exec(
+		query,
+		documentID,
+		l.LineNo,
+		l.UPC,
+		l.ProductID,
+		l.Quantity,
+		l.CostPrice,
+		l.NewPrice,
+		l.PreviousPrice,
+		l.ExpiryDate,
+	)
+	return err
+}
+
+func (r *GoodsReceivedRepo) ReverseGoodsReceivedTx(tx *sql.Tx, documentID int, rv models.GoodsReceivedReverse, reversedAt time.Time) error {
+	query := `
+		UPDATE goods_received
+		SET
+			status = 'reversed',
+			reversed_at = $2,
+			reversed_by = $3,
+			reversal_reason = $4
+		WHERE document_id = $1 AND status = 'posted'
+	`
+	_, err := tx.Exec(query, documentID, reversedAt, rv.EmployeeId, rv.Reason)
+	return err
+}
+
+const goodsReceivedColumns = `
+			g.document_id,
+			g.supplier_id,
+			s.supplier_name,
+			g.invoice_number,
+			TO_CHAR(g.invoice_date, 'YYYY-MM-DD'),
+			g.employee_id,
+			g.status,
+			g.posted_at,
+			g.reversed_at,
+			g.reversed_by,
+			g.reversal_reason,
+			COALESCE((
+				SELECT SUM(l.quantity * l.cost_price)
+				FROM goods_received_line l
+				WHERE l.document_id = g.document_id
+			), 0)
+		FROM goods_received g
+		JOIN supplier s ON s.supplier_id = g.supplier_id
+`
+
+func scanGoodsReceived(row interface{ Scan(...any) error }) (models.GoodsReceivedRetrieve, error) {
+	var document models.GoodsReceivedRetrieve
+	err := row.Scan(
+		&document.DocumentID,
+		&document.SupplierID,
+		&document.SupplierName,
+		&document.InvoiceNumber,
+		&document.InvoiceDate,
+		&document.EmployeeId,
+		&document.Status,
+		&document.PostedAt,
+		&document.ReversedAt,
+		&document.ReversedBy,
+		&document.ReversalReason,
+		&document.CostTotal,
+	)
+	return document, err
+}
+
+func (r *GoodsReceivedRepo) RetrieveGoodsReceivedByID(documentID int) (models.GoodsReceivedRetrieve, error) {
+	query := `SELECT` + goodsReceivedColumns + `WHERE g.document_id = $1`
+	return scanGoodsReceived(r.db.QueryRow(query, documentID))
+}
+
+// RetrieveGoodsReceivedForUpdateTx reads a document and locks its row, so
+// that it is reversed at most once.
+func (r *GoodsReceivedRepo) RetrieveGoodsReceivedForUpdateTx(tx *sql.Tx, documentID int) (models.GoodsReceivedRetrieve, error) {
+	query := `SELECT` + goodsReceivedColumns + `WHERE g.document_id = $1 FOR UPDATE OF g`
+	return scanGoodsReceived(tx.QueryRow(query, documentID))
+}
+
+// RetrieveGoodsReceivedList lists documents matching f, latest invoice
+// first.
+func (r *GoodsReceivedRepo) RetrieveGoodsReceivedList(f models.GoodsReceivedFilter) ([]models.GoodsReceivedRetrieve, error) {
+	query := `SELECT` + goodsReceivedColumns + `
+		WHERE ($1::integer IS NULL OR g.supplier_id = $1)
+			AND ($2 = '' OR g.status = $2)
+			AND ($3 = '' OR g.invoice_date >= $3::date)
+			AND ($4 = '' OR g.invoice_date <= $4::date)
+		ORDER BY g.invoice_date DESC, g.document_id DESC
+	`
+
+	rows, err := r.db.Query(query, f.SupplierID, f.Status, f.From, f.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var documents []models.GoodsReceivedRetrieve
+	for rows.Next() {
+		document, err := scanGoodsReceived(rows)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+
+	return documents, rows.Err()
+}
+
+// RetrieveGoodsReceivedLines returns the lines of the given documents,
+// ordered by document and line number.
+func (r *GoodsReceivedRepo) RetrieveGoodsReceivedLines(documentIDs []int) ([]models.GoodsReceivedLine, error) {
+	return retrieveGoodsReceivedLines(r.db, documentIDs)
+}
+
+func (r *GoodsReceivedRepo) RetrieveGoodsReceivedLinesTx(tx *sql.Tx, documentID int) ([]models.GoodsReceivedLine, error) {
+	return retrieveGoodsReceivedLines(tx, []int{documentID})
+}
+
+func retrieveGoodsReceivedLines(q dbtx, documentIDs []int) ([]models.GoodsReceivedLine, error) {
+	query := `
+		SELECT
+			l.document_id,
+			l.line_no,
+			l.upc,
+			l.product_id,
+			p.product_name,
+			l.quantity,
+			l.cost_price,
+			l.quantity * l.cost_price,
+			l.new_price,
+			l.previous_price,
+			TO_CHAR(l.expiry_date, 'YYYY-MM-DD')
+		FROM goods_received_line l
+		JOIN product p ON p.product_id = l.product_id
+		WHERE l.document_id = ANY($1)
+		ORDER BY l.document_id, l.line_no
+	`
+
+	rows, err := q.Query(query, pq.Array(documentIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.GoodsReceivedLine
+	for rows.Next() {
+		var line models.GoodsReceivedLine
+		err := rows.Scan(
+			&line.DocumentID,
+			&line.LineNo,
+			&line.UPC,
+			&line.ProductID,
+			&line.ProductName,
+			&line.Quantity,
+			&line.CostPrice,
+			&line.CostTotal,
+			&line.NewPrice,
+			&line.PreviousPrice,
+			&line.ExpiryDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
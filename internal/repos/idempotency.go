@@ -0,0 +1,123 @@
+package repos
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type IdempotencyRepo struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepo(db *sql.DB) *IdempotencyRepo {
+	return &IdempotencyRepo{
+		db: db,
+	}
+}
+
+// ClaimIdempotencyKey stores the key as in progress. It reports false when the
+// key is already held by a record that has not expired yet.
+func (r *IdempotencyRepo) ClaimIdempotencyKey(c models.IdempotencyKeyCreate) (bool, error) {
+	query := `
+		INSERT INTO idempotency_key (
+			idempotency_key,
+			employee_id,
+			method,
+			path,
+			request_hash,
+			created_at,
+			expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (employee_id, idempotency_key) DO UPDATE SET
+			method = EXCLUDED.method,
+			path = EXCLUDED.path,
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_key.expires_at <= EXCLUDED.created_at
+		RETURNING idempotency_key
+	`
+	var key string
+	err := r.db.QueryRow(
+		query,
+		c.Key,
+		c.EmployeeId,
+		c.Method,
+		c.Path,
+		c.RequestHash,
+		c.CreatedAt,
+		c.ExpiresAt,
+	).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *IdempotencyRepo) RetrieveIdempotencyKey(employeeID, key string) (models.IdempotencyKeyRetrieve, error) {
+	query := `
+		SELECT
+			idempotency_key,
+			employee_id,
+			method,
+			path,
+			request_hash,
+			status_code,
+			response_body,
+			created_at,
+			expires_at
+		FROM idempotency_key
+		WHERE employee_id = $1 AND idempotency_key = $2
+	`
+	var record models.IdempotencyKeyRetrieve
+	err := r.db.QueryRow(query, employeeID, key).Scan(
+		&record.Key,
+		&record.EmployeeId,
+		&record.Method,
+		&record.Path,
+		&record.RequestHash,
+		&record.StatusCode,
+		&record.ResponseBody,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return models.IdempotencyKeyRetrieve{}, err
+	}
+
+	return record, nil
+}
+
+func (r *IdempotencyRepo) SaveIdempotencyResponse(employeeID, key string, statusCode int, body []byte) error {
+	query := `
+		UPDATE idempotency_key
+		SET status_code = $3, response_body = $4
+		WHERE employee_id = $1 AND idempotency_key = $2
+	`
+	_, err := r.db.Exec(query, employeeID, key, statusCode, body)
+	return err
+}
+
+func (r *IdempotencyRepo) DeleteIdempotencyKey(employeeID, key string) error {
+	query := `DELETE FROM idempotency_key WHERE employee_id = $1 AND idempotency_key = $2`
+	_, err := r.db.Exec(query, employeeID, key)
+	return err
+}
+
+func (r *IdempotencyRepo) DeleteExpiredIdempotencyKeys(now time.Time) (int64, error) {
+	query := `DELETE FROM idempotency_key WHERE expires_at <= $1`
+	result, err := r.db.Exec(query, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -23,9 +23,9 @@ func (r *IndividualsRepo) QueryVlad1(categoryID int, months int) ([]models.Vlad1
 	    c.category_name,
 	    p.product_id,
 	    p.product_name,
-	    COUNT(s.upc) AS total_sales,
-	    SUM(s.product_number) AS total_units_sold,
-	    SUM(s.product_number * s.selling_price) AS total_revenue
+	    COUNT(m.upc) FILTER (WHERE m.product_number > 0) AS total_sales,
+	    SUM(m.product_number) AS total_units_sold,
+	    SUM(m.revenue) AS total_revenue
 	FROM
 	    category c
 	JOIN
@@ -33,28 +33,26 @@ func (r *IndividualsRepo) QueryVlad1(categoryID int, months int) ([]models.Vlad1
 	JOIN
 	    store_product sp ON p.product_id = sp.product_id
 	JOIN
-	    sale s ON sp.upc = s.upc
-	JOIN
-	    receipt r ON s.receipt_number = r.receipt_number
+	    sale_movement m ON sp.upc = m.upc
 	WHERE
-	    r.print_date BETWEEN CURRENT_DATE - ($2 * INTERVAL '1 month') AND CURRENT_DATE
+	    m.movement_date >= CURRENT_DATE - ($2 * INTERVAL '1 month') AND m.movement_date < CURRENT_DATE + 1
 	    AND c.category_id = $1
+	    AND NOT m.voided
 	GROUP BY
 	    c.category_id, c.category_name, p.product_id, p.product_name
 	HAVING
-	    SUM(s.product_number) >= ALL (
-	        SELECT SUM(s2.product_number)
+	    SUM(m.product_number) >= ALL (
+	        SELECT SUM(m2.product_number)
 	        FROM
 	            product p2
 	        JOIN
 	            store_product sp2 ON p2.product_id = sp2.product_id
 	        JOIN
-	            sale s2 ON sp2.upc = s2.upc
-	        JOIN
-	            receipt r2 ON s2.receipt_number = r2.receipt_number
+	            sale_movement m2 ON sp2.upc = m2.upc
 	        WHERE
 	            p2.category_id = c.category_id
-	            AND r2.print_date BETWEEN CURRENT_DATE - ($2 * INTERVAL '1 month') AND CURRENT_DATE
+	            AND m2.movement_date >= CURRENT_DATE - ($2 * INTERVAL '1 month') AND m2.movement_date < CURRENT_DATE + 1
+	            AND NOT m2.voided
 	        GROUP BY
 	            p2.product_id
 	    )
@@ -104,6 +102,7 @@ func (r *IndividualsRepo) QueryVlad2() ([]models.Vlad2Response, error) {
 	            receipt AS r
 	        WHERE
 	            r.employee_id = e.employee_id
+	            AND r.voided_at IS NULL
 	            AND EXISTS (
 	                SELECT 1
 	                FROM
@@ -144,14 +143,14 @@ func (r *IndividualsRepo) QueryArthur1(startDate, endDate string) ([]models.Arth
 	query := `
 	SELECT
 	    c.category_name,
-	    SUM(s.product_number) as units_sold,
-	    SUM(s.selling_price * s.product_number) as revenue
-	FROM sale s
-	    JOIN receipt r ON s.receipt_number = r.receipt_number
-	    JOIN store_product sp ON s.upc = sp.upc
+	    SUM(m.product_number) as units_sold,
+	    SUM(m.revenue) as revenue
+	FROM sale_movement m
+	    JOIN store_product sp ON m.upc = sp.upc
 	    JOIN product p ON sp.product_id = p.product_id
 	    JOIN category c ON p.category_id = c.category_id
-	WHERE r.print_date BETWEEN $1::date AND $2::date
+	WHERE m.movement_date >= $1::date AND m.movement_date < $2::date + 1
+	    AND NOT m.voided
 	GROUP BY c.category_name
 	ORDER BY revenue DESC, c.category_name ASC
 	LIMIT 5;
@@ -193,7 +192,9 @@ func (r *IndividualsRepo) QueryArthur2() ([]models.Arthur2Response, error) {
 	WHERE NOT EXISTS (
 	          SELECT 1
 	          FROM   sale s
+	          JOIN   receipt r ON r.receipt_number = s.receipt_number
 	          WHERE  s.upc = sp.upc
+	            AND  r.voided_at IS NULL
 	      )
 
 	  AND NOT EXISTS (
@@ -243,12 +244,15 @@ func (r *IndividualsRepo) QueryOleksii1(discountThreshold int) ([]models.Oleksii
 		COUNT(DISTINCT cc.card_number) as high_discount_customers,
 		COUNT(DISTINCT r.receipt_number) as total_receipts_high_discount,
 		SUM(r.sum_total) as total_revenue_high_discount,
-		AVG(r.sum_total) as avg_receipt_amount,
-		AVG(cc.percent) as avg_customer_discount
+		ROUND(AVG(r.sum_total), 2) as avg_receipt_amount,
+		AVG(cc.percent) as avg_customer_discount,
+		AVG(r.discount_percent) as avg_receipt_discount,
+		SUM(r.discount_sum) as total_discount_high_discount
 	FROM employee e
 	JOIN receipt r ON e.employee_id = r.employee_id
 	JOIN customer_card cc ON r.card_number = cc.card_number
 	WHERE cc.percent > $1
+		AND r.voided_at IS NULL
 		AND e.empl_role = 'Cashier'
 	GROUP BY e.employee_id, e.empl_surname, e.empl_name
 	HAVING COUNT(DISTINCT cc.card_number) > 0
@@ -275,6 +279,8 @@ func (r *IndividualsRepo) QueryOleksii1(discountThreshold int) ([]models.Oleksii
 			&result.TotalRevenueHighDisc,
 			&result.AvgReceiptAmount,
 			&result.AvgCustomerDiscount,
+			&result.AvgReceiptDiscount,
+			&result.TotalDiscountHighDisc,
 		)
 		if err != nil {
 			return nil, err
@@ -303,6 +309,7 @@ func (r *IndividualsRepo) QueryOleksii2() ([]models.Oleksii2Response, error) {
 	        JOIN store_product sp ON sp.upc           = s.upc
 	        JOIN product       p  ON p.product_id     = sp.product_id
 	        WHERE r.card_number = cc.card_number
+	          AND r.voided_at IS NULL
 	          AND r.print_date  >= CURRENT_DATE - INTERVAL '1 month'
 	          AND p.category_id = c.category_id
 	    )
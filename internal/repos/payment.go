@@ -0,0 +1,135 @@
+package repos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type PaymentRepo struct {
+	db *sql.DB
+}
+
+func NewPaymentRepo(db *sql.DB) *PaymentRepo {
+	return &PaymentRepo{
+		db: db,
+	}
+}
+
+func (r *PaymentRepo) CreatePaymentTx(tx *sql.Tx, p models.PaymentRetrieve) (int, error) {
+	query := `
+		INSERT INTO receipt_payment (
+			receipt_number,
+			tender,
+			amount,
+			tendered,
+			reference
+		) VALUES ($1, $2, $3, $4, $5)
+		RETURNING payment_id
+	`
+	var paymentID int
+	err := tx.QueryRow(
+		query,
+		p.ReceiptNumber,
+		p.Tender,
+		p.Amount,
+		p.Tendered,
+		p.Reference,
+	).Scan(&paymentID)
+
+	return paymentID, err
+}
+
+func (r *PaymentRepo) RetrievePaymentsByReceipt(receiptNumber string) ([]models.PaymentRetrieve, error) {
+	query := `
+		SELECT
+			payment_id,
+			receipt_number,
+			tender,
+			amount,
+			tendered,
+			reference
+		FROM receipt_payment
+		WHERE receipt_number = $1
+		ORDER BY payment_id
+	`
+	rows, err := r.db.Query(query, receiptNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []models.PaymentRetrieve
+	for rows.Next() {
+		var payment models.PaymentRetrieve
+		err := rows.Scan(
+			&payment.PaymentID,
+			&payment.ReceiptNumber,
+			&payment.Tender,
+			&payment.Amount,
+			&payment.Tendered,
+			&payment.Reference,
+		)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// RetrieveTenderTotals sums payments of non-voided receipts per cashier, day
+// and tender for print dates in [from, to). An empty employeeID covers all
+// cashiers.
+func (r *PaymentRepo) RetrieveTenderTotals(from, to time.Time, employeeID string) ([]models.TenderTotal, error) {
+	query := `
+		SELECT
+			e.employee_id,
+			e.empl_surname,
+			e.empl_name,
+			TO_CHAR(DATE(r.print_date), 'YYYY-MM-DD') as day,
+			p.tender,
+			COUNT(DISTINCT r.receipt_number) as receipts,
+			SUM(p.amount) as amount,
+			SUM(p.tendered) as tendered,
+			SUM(p.tendered - p.amount) as change
+		FROM receipt_payment p
+		JOIN receipt r ON p.receipt_number = r.receipt_number
+		JOIN employee e ON r.employee_id = e.employee_id
+		WHERE r.print_date >= $1
+			AND r.print_date < $2
+			AND ($3 = '' OR r.employee_id = $3)
+			AND r.voided_at IS NULL
+		GROUP BY e.employee_id, e.empl_surname, e.empl_name, DATE(r.print_date), p.tender
+		ORDER BY day, e.empl_surname, e.employee_id, p.tender
+	`
+	rows, err := r.db.Query(query, from, to, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.TenderTotal
+	for rows.Next() {
+		var total models.TenderTotal
+		err := rows.Scan(
+			&total.EmployeeId,
+			&total.EmployeeSurname,
+			&total.EmployeeName,
+			&total.Day,
+			&total.Tender,
+			&total.Receipts,
+			&total.Amount,
+			&total.Tendered,
+			&total.Change,
+		)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
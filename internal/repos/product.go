@@ -21,10 +21,10 @@ func NewProductRepo(db *sql.DB) *ProductRepo {
 func (r *ProductRepo) CreateProduct(p models.ProductCreate) (int, error) {
 	var id int
 	err := r.db.QueryRow(
-		`INSERT INTO product (product_name, characteristics, category_id)
-		 VALUES ($1, $2, $3)
+		`INSERT INTO product (product_name, characteristics, category_id, vat_rate)
+		 VALUES ($1, $2, $3, $4)
 		 RETURNING product_id`,
-		p.Name, p.Characteristics, p.CategoryID,
+		p.Name, p.Characteristics, p.CategoryID, p.VATRate,
 	).Scan(&id)
 	return id, err
 }
@@ -32,17 +32,17 @@ func (r *ProductRepo) CreateProduct(p models.ProductCreate) (int, error) {
 func (r *ProductRepo) RetrieveProductByID(id int) (models.ProductRetrieve, error) {
 	var pr models.ProductRetrieve
 	err := r.db.QueryRow(
-		`SELECT product_id, product_name, characteristics, category_id
+		`SELECT product_id, product_name, characteristics, category_id, vat_rate
 		 FROM product
 		 WHERE product_id = $1`,
 		id,
-	).Scan(&pr.ID, &pr.Name, &pr.Characteristics, &pr.CategoryID)
+	).Scan(&pr.ID, &pr.Name, &pr.Characteristics, &pr.CategoryID, &pr.VATRate)
 	return pr, err
 }
 
 func (r *ProductRepo) RetrieveProducts() ([]models.ProductRetrieve, error) {
 	rows, err := r.db.Query(
-		`SELECT product_id, product_name, characteristics, category_id
+		`SELECT product_id, product_name, characteristics, category_id, vat_rate
 		 FROM product`,
 	)
 	if err != nil {
@@ -53,7 +53,7 @@ func (r *ProductRepo) RetrieveProducts() ([]models.ProductRetrieve, error) {
 	var list []models.ProductRetrieve
 	for rows.Next() {
 		var pr models.ProductRetrieve
-		if err := rows.Scan(&pr.ID, &pr.Name, &pr.Characteristics, &pr.CategoryID); err != nil {
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.Characteristics, &pr.CategoryID, &pr.VATRate); err != nil {
 			return nil, err
 		}
 		list = append(list, pr)
@@ -63,7 +63,7 @@ func (r *ProductRepo) RetrieveProducts() ([]models.ProductRetrieve, error) {
 
 func (r *ProductRepo) RetrieveProductsByCategory(categoryID int) ([]models.ProductRetrieve, error) {
 	rows, err := r.db.Query(
-		`SELECT product_id, product_name, characteristics, category_id
+		`SELECT product_id, product_name, characteristics, category_id, vat_rate
 		 FROM product
 		 WHERE category_id = $1`,
 		categoryID,
@@ -76,7 +76,7 @@ func (r *ProductRepo) RetrieveProductsByCategory(categoryID int) ([]models.Produ
 	var list []models.ProductRetrieve
 	for rows.Next() {
 		var pr models.ProductRetrieve
-		if err := rows.Scan(&pr.ID, &pr.Name, &pr.Characteristics, &pr.CategoryID); err != nil {
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.Characteristics, &pr.CategoryID, &pr.VATRate); err != nil {
 			return nil, err
 		}
 		list = append(list, pr)
@@ -86,7 +86,7 @@ func (r *ProductRepo) RetrieveProductsByCategory(categoryID int) ([]models.Produ
 
 func (r *ProductRepo) RetrieveProductsByName(name string) ([]models.ProductRetrieve, error) {
 	rows, err := r.db.Query(
-		`SELECT product_id, product_name, characteristics, category_id
+		`SELECT product_id, product_name, characteristics, category_id, vat_rate
 		 FROM product
 		 WHERE product_name LIKE $1`,
 		"%"+name+"%",
@@ -99,7 +99,7 @@ func (r *ProductRepo) RetrieveProductsByName(name string) ([]models.ProductRetri
 	var list []models.ProductRetrieve
 	for rows.Next() {
 		var pr models.ProductRetrieve
-		if err := rows.Scan(&pr.ID, &pr.Name, &pr.Characteristics, &pr.CategoryID); err != nil {
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.Characteristics, &pr.CategoryID, &pr.VATRate); err != nil {
 			return nil, err
 		}
 		list = append(list, pr)
@@ -141,3 +141,10 @@ func (r *ProductRepo) DeleteProduct(id int) error {
 	_, err := r.db.Exec(`DELETE FROM product WHERE product_id = $1`, id)
 	return err
 }
+
+// SetProductVATRate sets the product's VAT override; nil clears it so the
+// category rate applies.
+func (r *ProductRepo) SetProductVATRate(id int, rate *float64) error {
+	_, err := r.db.Exec(`UPDATE product SET vat_rate = $1 WHERE product_id = $2`, rate, id)
+	return err
+}
@@ -0,0 +1,284 @@
+package repos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type PurchaseOrderRepo struct {
+	db *sql.DB
+}
+
+func NewPurchaseOrderRepo(db *sql.DB) *PurchaseOrderRepo {
+	return &PurchaseOrderRepo{
+		db: db,
+	}
+}
+
+func (r *PurchaseOrderRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func (r *PurchaseOrderRepo) CreatePurchaseOrderTx(tx *sql.Tx, c models.PurchaseOrderCreate) (int, error) {
+	query := `
+		INSERT INTO purchase_order (
+			supplier_id,
+			employee_id,
+			status,
+			note,
+			created_at,
+			updated_at
+		) VALUES ($1, $2, $3, $4, $5, $5)
+		RETURNING order_id
+	`
+
+	var orderID int
+	err := tx.QueryRow(
+		query,
+		c.SupplierID,
+		c.EmployeeId,
+		models.PurchaseOrderStatusDraft,
+		c.Note,
+		c.CreatedAt,
+	).Scan(&orderID)
+
+	return orderID, err
+}
+
+func (r *PurchaseOrderRepo) CreatePurchaseOrderLineTx(tx *sql.Tx, orderID int, l models.PurchaseOrderLineCreate) error {
+	query := `
+		INSERT INTO purchase_order_line (
+			order_id,
+			product_id,
+			quantity,
+			expected_cost
+		) VALUES ($1, $2, $3, $4)
+	`
+	_, err := tx.Exec(query, orderID, l.ProductID, l.Quantity, l.ExpectedCost)
+	return err
+}
+
+func (r *PurchaseOrderRepo) DeletePurchaseOrderLinesTx(tx *sql.Tx, orderID int) error {
+	query := `DELETE FROM purchase_order_line WHERE order_id = $1`
+	_, err := tx.Exec(query, orderID)
+	return err
+}
+
+func (r *PurchaseOrderRepo) UpdatePurchaseOrderTx(tx *sql.Tx, orderID, supplierID int, note *string, updatedAt time.Time) error {
+	query := `
+		UPDATE purchase_order
+		SET supplier_id = $2, note = $3, updated_at = $4
+		WHERE order_id = $1
+	`
+	_, err := tx.Exec(query, orderID, supplierID, note, updatedAt)
+	return err
+}
+
+// SetPurchaseOrderStatusTx moves the order to status; moving it to sent also
+// stamps sent_at.
+func (r *PurchaseOrderRepo) SetPurchaseOrderStatusTx(tx *sql.Tx, orderID int, status string, updatedAt time.Time) error {
+	query := `
+		UPDATE purchase_order
+		SET
+			status = $2,
+			updated_at = $3,
+			sent_at = CASE WHEN $2 = 'sent' THEN $3 ELSE sent_at END
+		WHERE order_id = $1
+	`
+	_, err := tx.Exec(query, orderID, status, updatedAt)
+	return err
+}
+
+const purchaseOrderColumns = `
+			po.order_id,
+			po.supplier_id,
+			s.supplier_name,
+			po.employee_id,
+			po.status,
+			po.note,
+			po.created_at,
+			po.updated_at,
+			po.sent_at,
+			COALESCE((
+				SELECT SUM(l.quantity * l.expected_cost)
+				FROM purchase_order_line l
+				WHERE l.order_id = po.order_id
+			), 0)
+		FROM purchase_order po
+		JOIN supplier s ON s.supplier_id = po.supplier_id
+`
+
+func scanPurchaseOrder(row interface{ Scan(...any) error }) (models.PurchaseOrderRetrieve, error) {
+	var order models.PurchaseOrderRetrieve
+	err := row.Scan(
+		&order.OrderID,
+		&order.SupplierID,
+		&order.SupplierName,
+		&order.EmployeeId,
+		&order.Status,
+		&order.Note,
+		&order.CreatedAt,
+		&order.UpdatedAt,
+		&order.SentAt,
+		&order.ExpectedTotal,
+	)
+	return order, err
+}
+
+func (r *PurchaseOrderRepo) RetrievePurchaseOrderByID(orderID int) (models.PurchaseOrderRetrieve, error) {
+	query := `SELECT` + purchaseOrderColumns + `WHERE po.order_id = $1`
+	return scanPurchaseOrder(r.db.QueryRow(query, orderID))
+}
+
+// RetrievePurchaseOrderForUpdateTx reads an order and locks its row, so that
+// changes of its status and receivings against it are serialized.
+func (r *PurchaseOrderRepo) RetrievePurchaseOrderForUpdateTx(tx *sql.Tx, orderID int) (models.PurchaseOrderRetrieve, error) {
+	query := `SELECT` + purchaseOrderColumns + `WHERE po.order_id = $1 FOR UPDATE OF po`
+	return scanPurchaseOrder(tx.QueryRow(query, orderID))
+}
+
+// RetrievePurchaseOrders lists orders matching f, latest first.
+func (r *PurchaseOrderRepo) RetrievePurchaseOrders(f models.PurchaseOrderFilter) ([]models.PurchaseOrderRetrieve, error) {
+	query := `SELECT` + purchaseOrderColumns + `
+		WHERE ($1::integer IS NULL OR po.supplier_id = $1)
+			AND ($2 = '' OR po.status = $2)
+		ORDER BY po.created_at DESC, po.order_id DESC
+	`
+
+	rows, err := r.db.Query(query, f.SupplierID, f.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []models.PurchaseOrderRetrieve
+	for rows.Next() {
+		order, err := scanPurchaseOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func (r *PurchaseOrderRepo) RetrievePurchaseOrderLines(orderID int) ([]models.PurchaseOrderLine, error) {
+	return retrievePurchaseOrderLines(r.db, orderID)
+}
+
+func (r *PurchaseOrderRepo) RetrievePurchaseOrderLinesTx(tx *sql.Tx, orderID int) ([]models.PurchaseOrderLine, error) {
+	return retrievePurchaseOrderLines(tx, orderID)
+}
+
+func retrievePurchaseOrderLines(q dbtx, orderID int) ([]models.PurchaseOrderLine, error) {
+	query := `
+		SELECT
+			l.product_id,
+			p.product_name,
+			l.quantity,
+			l.received_quantity,
+			l.expected_cost,
+			l.quantity * l.expected_cost
+		FROM purchase_order_line l
+		JOIN product p ON p.product_id = l.product_id
+		WHERE l.order_id = $1
+		ORDER BY l.product_id
+	`
+
+	rows, err := q.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.PurchaseOrderLine
+	for rows.Next() {
+		var line models.PurchaseOrderLine
+		err := rows.Scan(
+			&line.ProductID,
+			&line.ProductName,
+			&line.Quantity,
+			&line.ReceivedQuantity,
+			&line.ExpectedCost,
+			&line.ExpectedTotal,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+func (r *PurchaseOrderRepo) RetrievePurchaseOrderDeliveries(orderID int) ([]models.PurchaseOrderDelivery, error) {
+	query := `
+		SELECT
+			delivery_id,
+			product_id,
+			upc,
+			quantity,
+			received_at,
+			employee_id
+		FROM purchase_order_delivery
+		WHERE order_id = $1
+		ORDER BY delivery_id
+	`
+
+	rows, err := r.db.Query(query, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []models.PurchaseOrderDelivery
+	for rows.Next() {
+		var delivery models.PurchaseOrderDelivery
+		err := rows.Scan(
+			&delivery.DeliveryID,
+			&delivery.ProductID,
+			&delivery.UPC,
+			&delivery.Quantity,
+			&delivery.ReceivedAt,
+			&delivery.EmployeeId,
+		)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// CreatePurchaseOrderDeliveryTx records units received against an order line
+// and adds them to the line's received quantity.
+func (r *PurchaseOrderRepo) CreatePurchaseOrderDeliveryTx(tx *sql.Tx, orderID int, productID int, l models.PurchaseOrderReceiveLine, employeeID string, receivedAt time.Time) error {
+	query := `
+		WITH line AS (
+			UPDATE purchase_order_line
+			SET received_quantity = received_quantity + $4
+			WHERE order_id = $1 AND product_id = $2
+			RETURNING order_id, product_id
+		)
+		INSERT INTO purchase_order_delivery (order_id, product_id, upc, quantity, received_at, employee_id)
+		SELECT order_id, product_id, $3, $4, $5, $6
+		FROM line
+	`
+	result, err := tx.Exec(query, orderID, productID, l.UPC, l.Quantity, receivedAt, employeeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -8,7 +8,7 @@ import (
 	"github.com/velosypedno/zlagoda/internal/utils"
 )
 
-func getNewReceiptNumber(r *ReceiptRepo) (string, error) {
+func getNewReceiptNumber(q dbtx) (string, error) {
 	const maxRetries = 10
 
 	for i := 0; i < maxRetries; i++ {
@@ -18,7 +18,7 @@ func getNewReceiptNumber(r *ReceiptRepo) (string, error) {
 		}
 
 		var exists bool
-		err = r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM receipt WHERE receipt_number = $1)", receiptNumber).Scan(&exists)
+		err = q.QueryRow("SELECT EXISTS(SELECT 1 FROM receipt WHERE receipt_number = $1)", receiptNumber).Scan(&exists)
 		if err != nil {
 			return "", fmt.Errorf("failed to check receipt number uniqueness: %w", err)
 		}
@@ -41,7 +41,19 @@ func NewReceiptRepo(db *sql.DB) *ReceiptRepo {
 	}
 }
 
+func (r *ReceiptRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
 func (r *ReceiptRepo) CreateReceipt(c models.ReceiptCreate) (string, error) {
+	return createReceipt(r.db, c)
+}
+
+func (r *ReceiptRepo) CreateReceiptTx(tx *sql.Tx, c models.ReceiptCreate) (string, error) {
+	return createReceipt(tx, c)
+}
+
+func createReceipt(q dbtx, c models.ReceiptCreate) (string, error) {
 	query := `
 		INSERT INTO receipt (
 			receipt_number,
@@ -49,16 +61,25 @@ func (r *ReceiptRepo) CreateReceipt(c models.ReceiptCreate) (string, error) {
 			card_number,
 			print_date,
 			sum_total,
-			vat
-		) VALUES ($1, $2, $3, $4, $5, $6)
+			vat,
+			discount_percent,
+			discount_sum,
+			shift_id
+		) VALUES ($1, $2, $3, $4, $5, $6, COALESCE($7, 0), COALESCE($8, 0), $9)
 		RETURNING receipt_number
 	`
 
-	receiptNumber, err := getNewReceiptNumber(r)
-	if err != nil {
-		return "", err
+	var receiptNumber string
+	if c.ReceiptNumber != nil {
+		receiptNumber = *c.ReceiptNumber
+	} else {
+		var err error
+		receiptNumber, err = getNewReceiptNumber(q)
+		if err != nil {
+			return "", err
+		}
 	}
-	err = r.db.QueryRow(
+	err := q.QueryRow(
 		query,
 		receiptNumber,
 		c.EmployeeId,
@@ -66,11 +87,31 @@ func (r *ReceiptRepo) CreateReceipt(c models.ReceiptCreate) (string, error) {
 		c.PrintDate,
 		c.TotalSum,
 		c.VAT,
+		c.DiscountPercent,
+		c.DiscountSum,
+		c.ShiftID,
 	).Scan(&receiptNumber)
 
 	return receiptNumber, err
 }
 
+// NextReceiptSequenceTx takes the next number of the register. The sequence
+// row stays locked until tx ends, so concurrent checkouts on the register
+// queue up and a rollback returns the number unused.
+func (r *ReceiptRepo) NextReceiptSequenceTx(tx *sql.Tx, registerID string) (int, error) {
+	query := `
+		INSERT INTO receipt_number_sequence (register_id, last_number)
+		VALUES ($1, 1)
+		ON CONFLICT (register_id)
+		DO UPDATE SET last_number = receipt_number_sequence.last_number + 1
+		RETURNING last_number
+	`
+
+	var number int
+	err := tx.QueryRow(query, registerID).Scan(&number)
+	return number, err
+}
+
 func (r *ReceiptRepo) RetrieveReceiptByReceiptNumber(receiptNumber string) (models.ReceiptRetrieve, error) {
 	query := `
 		SELECT
@@ -79,7 +120,13 @@ func (r *ReceiptRepo) RetrieveReceiptByReceiptNumber(receiptNumber string) (mode
 			card_number,
 			print_date,
 			sum_total,
-			vat
+			vat,
+			discount_percent,
+			discount_sum,
+			voided_at,
+			voided_by,
+			void_reason,
+			shift_id
 		FROM receipt
 		WHERE receipt_number = $1
 	`
@@ -91,6 +138,12 @@ func (r *ReceiptRepo) RetrieveReceiptByReceiptNumber(receiptNumber string) (mode
 		&receipt.PrintDate,
 		&receipt.TotalSum,
 		&receipt.VAT,
+		&receipt.DiscountPercent,
+		&receipt.DiscountSum,
+		&receipt.VoidedAt,
+		&receipt.VoidedBy,
+		&receipt.VoidReason,
+		&receipt.ShiftID,
 	)
 	if err != nil {
 		return models.ReceiptRetrieve{}, err
@@ -99,7 +152,9 @@ func (r *ReceiptRepo) RetrieveReceiptByReceiptNumber(receiptNumber string) (mode
 	return receipt, nil
 }
 
-func (r *ReceiptRepo) RetrieveReceipts() ([]models.ReceiptRetrieve, error) {
+// RetrieveReceiptForUpdateTx reads a receipt and locks its row until tx ends,
+// serializing documents such as returns that are posted against it.
+func (r *ReceiptRepo) RetrieveReceiptForUpdateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptRetrieve, error) {
 	query := `
 		SELECT
 			receipt_number,
@@ -107,10 +162,112 @@ func (r *ReceiptRepo) RetrieveReceipts() ([]models.ReceiptRetrieve, error) {
 			card_number,
 			print_date,
 			sum_total,
-			vat
+			vat,
+			discount_percent,
+			discount_sum,
+			voided_at,
+			voided_by,
+			void_reason,
+			shift_id
 		FROM receipt
+		WHERE receipt_number = $1
+		FOR UPDATE
 	`
-	rows, err := r.db.Query(query)
+	var receipt models.ReceiptRetrieve
+	err := tx.QueryRow(query, receiptNumber).Scan(
+		&receipt.ReceiptNumber,
+		&receipt.EmployeeId,
+		&receipt.CardNumber,
+		&receipt.PrintDate,
+		&receipt.TotalSum,
+		&receipt.VAT,
+		&receipt.DiscountPercent,
+		&receipt.DiscountSum,
+		&receipt.VoidedAt,
+		&receipt.VoidedBy,
+		&receipt.VoidReason,
+		&receipt.ShiftID,
+	)
+	if err != nil {
+		return models.ReceiptRetrieve{}, err
+	}
+
+	return receipt, nil
+}
+
+// receiptSortColumns maps the sortable fields to the expression the listing
+// is ordered by and the type its cursor value is cast to. Nullable columns
+// are coalesced so that keyset comparisons never see NULL; the expressions
+// match the indexes from 000009_add_receipt_list_indexes and
+// 000014_use_timestamptz.
+var receiptSortColumns = map[string]struct {
+	expr string
+	cast string
+}{
+	"print_date":  {"print_date", "timestamptz"},
+	"sum_total":   {"sum_total", "numeric"},
+	"employee_id": {"employee_id", "varchar"},
+	"card_number": {"COALESCE(card_number, '')", "varchar"},
+	"voided_at":   {"COALESCE(voided_at, '-infinity'::timestamptz)", "timestamptz"},
+}
+
+// RetrieveReceipts returns up to p.Limit receipts matching f, ordered by
+// p.Sort and the receipt number, starting after p.After.
+func (r *ReceiptRepo) RetrieveReceipts(f models.ReceiptFilter, p models.ReceiptPage) ([]models.ReceiptRetrieve, error) {
+	column, ok := receiptSortColumns[p.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field %q", p.Sort)
+	}
+	direction, comparison := "ASC", ">"
+	if p.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	var afterValue, afterNumber *string
+	if p.After != nil {
+		afterValue, afterNumber = &p.After.Value, &p.After.ReceiptNumber
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			receipt_number,
+			employee_id,
+			card_number,
+			print_date,
+			sum_total,
+			vat,
+			discount_percent,
+			discount_sum,
+			voided_at,
+			voided_by,
+			void_reason,
+			shift_id
+		FROM receipt
+		WHERE ($1::varchar IS NULL OR employee_id = $1)
+			AND ($2::varchar IS NULL OR card_number = $2)
+			AND ($3::timestamptz IS NULL OR print_date >= $3)
+			AND ($4::timestamptz IS NULL OR print_date < $4)
+			AND ($5::numeric IS NULL OR sum_total >= $5)
+			AND ($6::numeric IS NULL OR sum_total <= $6)
+			AND ($7::boolean IS NULL OR (voided_at IS NOT NULL) = $7)
+			AND ($8::varchar IS NULL OR (%[1]s, receipt_number) %[3]s ($8::varchar::%[2]s, $9::varchar))
+		ORDER BY %[1]s %[4]s, receipt_number %[4]s
+		LIMIT $10
+	`, column.expr, column.cast, comparison, direction)
+
+	rows, err := r.db.Query(
+		query,
+		f.EmployeeId,
+		f.CardNumber,
+		f.PrintDateFrom,
+		f.PrintDateTo,
+		f.MinTotal,
+		f.MaxTotal,
+		f.Voided,
+		afterValue,
+		afterNumber,
+		p.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -126,6 +283,12 @@ func (r *ReceiptRepo) RetrieveReceipts() ([]models.ReceiptRetrieve, error) {
 			&receipt.PrintDate,
 			&receipt.TotalSum,
 			&receipt.VAT,
+			&receipt.DiscountPercent,
+			&receipt.DiscountSum,
+			&receipt.VoidedAt,
+			&receipt.VoidedBy,
+			&receipt.VoidReason,
+			&receipt.ShiftID,
 		)
 		if err != nil {
 			return nil, err
@@ -136,31 +299,164 @@ func (r *ReceiptRepo) RetrieveReceipts() ([]models.ReceiptRetrieve, error) {
 	return receipts, nil
 }
 
+// RetrieveReceiptVAT returns the receipt's VAT breakdown by rate, summed
+// from the rate and VAT stored on each sale line.
+func (r *ReceiptRepo) RetrieveReceiptVAT(receiptNumber string) ([]models.ReceiptVATLine, error) {
+	query := `
+		SELECT
+			vat_rate,
+			taxable_sum,
+			vat_sum
+		FROM receipt_vat
+		WHERE receipt_number = $1
+		ORDER BY vat_rate DESC
+	`
+
+	rows, err := r.db.Query(query, receiptNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var breakdown []models.ReceiptVATLine
+	for rows.Next() {
+		var line models.ReceiptVATLine
+		if err := rows.Scan(&line.Rate, &line.Base, &line.Amount); err != nil {
+			return nil, err
+		}
+		breakdown = append(breakdown, line)
+	}
+
+	return breakdown, rows.Err()
+}
+
+// VoidReceiptTx marks a receipt as voided. The row is kept so the document
+// stays auditable; it fails if the receipt is missing or already voided.
+func (r *ReceiptRepo) VoidReceiptTx(tx *sql.Tx, receiptNumber string, v models.ReceiptVoid) error {
+	query := `
+		UPDATE receipt
+		SET
+			voided_at = $2,
+			voided_by = $3,
+			void_reason = $4
+		WHERE receipt_number = $1 AND voided_at IS NULL
+	`
+	result, err := tx.Exec(query, receiptNumber, v.VoidedAt, v.VoidedBy, v.Reason)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("receipt not found or already voided")
+	}
+
+	return nil
+}
+
 func (r *ReceiptRepo) DeleteReceipt(receiptNumber string) error {
 	query := `DELETE FROM receipt WHERE receipt_number = $1`
 	_, err := r.db.Exec(query, receiptNumber)
 	return err
 }
 
-func (r *ReceiptRepo) UpdateReceipt(receiptNumber string, c models.ReceiptUpdate) error {
+func (r *ReceiptRepo) UpdateReceiptTx(tx *sql.Tx, receiptNumber string, c models.ReceiptUpdate) error {
 	query := `
 		UPDATE receipt
 		SET
 			employee_id = $2,
 			card_number = $3,
 			print_date = $4,
-			sum_total = $5,
-			vat = $6
+			discount_percent = $5
 		WHERE receipt_number = $1
 	`
-	_, err := r.db.Exec(
+	_, err := tx.Exec(
 		query,
 		receiptNumber,
 		c.EmployeeId,
 		c.CardNumber,
 		c.PrintDate,
-		c.TotalSum,
-		c.VAT,
+		c.DiscountPercent,
 	)
 	return err
 }
+
+// RetrieveReceiptEditStateTx reads the payments, returns and shift of a
+// receipt locked by tx.
+func (r *ReceiptRepo) RetrieveReceiptEditStateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptEditState, error) {
+	query := `
+		SELECT
+			(SELECT COUNT(*) FROM receipt_payment p WHERE p.receipt_number = r.receipt_number),
+			(SELECT COALESCE(SUM(p.amount), 0) FROM receipt_payment p WHERE p.receipt_number = r.receipt_number),
+			EXISTS (SELECT 1 FROM receipt_return rr WHERE rr.receipt_number = r.receipt_number),
+			COALESCE(s.closed_at IS NOT NULL, FALSE)
+		FROM receipt r
+		LEFT JOIN shift s ON s.shift_id = r.shift_id
+		WHERE r.receipt_number = $1
+	`
+	var state models.ReceiptEditState
+	err := tx.QueryRow(query, receiptNumber).Scan(
+		&state.Payments,
+		&state.Paid,
+		&state.Returned,
+		&state.ShiftClosed,
+	)
+	return state, err
+}
+
+// UpdateReceiptTotalsTx stores the totals derived from the receipt's lines.
+func (r *ReceiptRepo) UpdateReceiptTotalsTx(tx *sql.Tx, receiptNumber string, t models.ReceiptTotals) error {
+	query := `
+		UPDATE receipt
+		SET
+			sum_total = $2,
+			vat = $3,
+			discount_sum = $4
+		WHERE receipt_number = $1
+	`
+	_, err := tx.Exec(query, receiptNumber, t.TotalSum, t.VAT, t.DiscountSum)
+	return err
+}
+
+// RetrieveReceiptTotals returns the stored totals and discount of every
+// receipt, voided ones included, for checking them against their lines.
+func (r *ReceiptRepo) RetrieveReceiptTotals() ([]models.ReceiptRetrieve, error) {
+	query := `
+		SELECT
+			receipt_number,
+			sum_total,
+			vat,
+			discount_percent,
+			discount_sum
+		FROM receipt
+		ORDER BY receipt_number
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var receipts []models.ReceiptRetrieve
+	for rows.Next() {
+		var receipt models.ReceiptRetrieve
+		err := rows.Scan(
+			&receipt.ReceiptNumber,
+			&receipt.TotalSum,
+			&receipt.VAT,
+			&receipt.DiscountPercent,
+			&receipt.DiscountSum,
+		)
+		if err != nil {
+			return nil, err
+		}
+		receipts = append(receipts, receipt)
+	}
+
+	return receipts, rows.Err()
+}
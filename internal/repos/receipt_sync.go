@@ -0,0 +1,156 @@
+package repos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type ReceiptSyncRepo struct {
+	db *sql.DB
+}
+
+func NewReceiptSyncRepo(db *sql.DB) *ReceiptSyncRepo {
+	return &ReceiptSyncRepo{
+		db: db,
+	}
+}
+
+func (r *ReceiptSyncRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// RetrieveSyncedReceiptNumber returns the receipt an offline receipt was
+// written as, or sql.ErrNoRows when it has not been synced yet.
+func (r *ReceiptSyncRepo) RetrieveSyncedReceiptNumber(clientID string) (string, error) {
+	return retrieveSyncedReceiptNumber(r.db, clientID)
+}
+
+func (r *ReceiptSyncRepo) RetrieveSyncedReceiptNumberTx(tx *sql.Tx, clientID string) (string, error) {
+	return retrieveSyncedReceiptNumber(tx, clientID)
+}
+
+func retrieveSyncedReceiptNumber(q dbtx, clientID string) (string, error) {
+	query := `SELECT receipt_number FROM receipt_sync WHERE client_id = $1`
+	var receiptNumber string
+	err := q.QueryRow(query, clientID).Scan(&receiptNumber)
+	return receiptNumber, err
+}
+
+func (r *ReceiptSyncRepo) CreateReceiptSyncTx(tx *sql.Tx, clientID, receiptNumber string, syncedAt time.Time) error {
+	query := `
+		INSERT INTO receipt_sync (
+			client_id,
+			receipt_number,
+			synced_at
+		) VALUES ($1, $2, $3)
+	`
+	_, err := tx.Exec(query, clientID, receiptNumber, syncedAt)
+	return err
+}
+
+func (r *ReceiptSyncRepo) CreateReceiptSyncConflictTx(tx *sql.Tx, clientID string, s models.StockShortage) error {
+	query := `
+		INSERT INTO receipt_sync_conflict (
+			client_id,
+			upc,
+			requested,
+			available
+		) VALUES ($1, $2, $3, $4)
+	`
+	_, err := tx.Exec(query, clientID, s.UPC, s.Requested, s.Available)
+	return err
+}
+
+// RetrieveReceiptSyncConflicts lists the flagged UPCs of synced receipts,
+// latest sync first.
+func (r *ReceiptSyncRepo) RetrieveReceiptSyncConflicts() ([]models.ReceiptSyncConflict, error) {
+	query := `
+		SELECT
+			s.client_id,
+			s.receipt_number,
+			s.synced_at,
+			c.upc,
+			c.requested,
+			c.available
+		FROM receipt_sync_conflict c
+		JOIN receipt_sync s ON s.client_id = c.client_id
+		ORDER BY s.synced_at DESC, s.client_id, c.upc
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var conflicts []models.ReceiptSyncConflict
+	for rows.Next() {
+		var conflict models.ReceiptSyncConflict
+		err := rows.Scan(
+			&conflict.ClientID,
+			&conflict.ReceiptNumber,
+			&conflict.SyncedAt,
+			&conflict.UPC,
+			&conflict.Requested,
+			&conflict.Available,
+		)
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, rows.Err()
+}
+
+func (r *ReceiptSyncRepo) CreateReceiptSyncTenderMismatchTx(tx *sql.Tx, clientID string, m models.TenderMismatch) error {
+	query := `
+		INSERT INTO receipt_sync_tender_mismatch (
+			client_id,
+			due,
+			paid
+		) VALUES ($1, $2, $3)
+	`
+	_, err := tx.Exec(query, clientID, m.Due, m.Paid)
+	return err
+}
+
+// RetrieveReceiptSyncTenderMismatches lists the synced receipts whose
+// payments did not match their total, latest sync first.
+func (r *ReceiptSyncRepo) RetrieveReceiptSyncTenderMismatches() ([]models.ReceiptSyncTenderMismatch, error) {
+	query := `
+		SELECT
+			s.client_id,
+			s.receipt_number,
+			s.synced_at,
+			m.due,
+			m.paid
+		FROM receipt_sync_tender_mismatch m
+		JOIN receipt_sync s ON s.client_id = m.client_id
+		ORDER BY s.synced_at DESC, s.client_id
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []models.ReceiptSyncTenderMismatch
+	for rows.Next() {
+		var mismatch models.ReceiptSyncTenderMismatch
+		err := rows.Scan(
+			&mismatch.ClientID,
+			&mismatch.ReceiptNumber,
+			&mismatch.SyncedAt,
+			&mismatch.Due,
+			&mismatch.Paid,
+		)
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, mismatch)
+	}
+
+	return mismatches, rows.Err()
+}
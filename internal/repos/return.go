@@ -0,0 +1,287 @@
+package repos
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/utils"
+)
+
+func getNewReturnNumber(q dbtx) (string, error) {
+	const maxRetries = 10
+
+	for i := 0; i < maxRetries; i++ {
+		returnNumber, err := utils.GenerateID(10)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate return number: %w", err)
+		}
+
+		var exists bool
+		err = q.QueryRow("SELECT EXISTS(SELECT 1 FROM receipt_return WHERE return_number = $1)", returnNumber).Scan(&exists)
+		if err != nil {
+			return "", fmt.Errorf("failed to check return number uniqueness: %w", err)
+		}
+
+		if !exists {
+			return returnNumber, nil
+		}
+	}
+
+	return "", fmt.Errorf("failed to generate unique return number after %d attempts", maxRetries)
+}
+
+type ReturnRepo struct {
+	db *sql.DB
+}
+
+func NewReturnRepo(db *sql.DB) *ReturnRepo {
+	return &ReturnRepo{
+		db: db,
+	}
+}
+
+func (r *ReturnRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+// RetrieveReturnableSalesTx lists the sale lines of a receipt together with
+// the units already returned against each of them.
+func (r *ReturnRepo) RetrieveReturnableSalesTx(tx *sql.Tx, receiptNumber string) ([]models.ReturnableSale, error) {
+	query := `
+		SELECT
+			s.upc,
+			s.product_number,
+			s.selling_price,
+			s.vat_rate,
+			COALESCE(SUM(ri.product_number), 0) AS returned
+		FROM sale s
+		LEFT JOIN return_item ri
+			ON ri.receipt_number = s.receipt_number AND ri.upc = s.upc
+		WHERE s.receipt_number = $1
+		GROUP BY s.upc, s.product_number, s.selling_price, s.vat_rate
+		ORDER BY s.upc
+	`
+
+	rows, err := tx.Query(query, receiptNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sales []models.ReturnableSale
+	for rows.Next() {
+		var sale models.ReturnableSale
+		err := rows.Scan(
+			&sale.UPC,
+			&sale.ProductNumber,
+			&sale.SellingPrice,
+			&sale.VATRate,
+			&sale.Returned,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sales = append(sales, sale)
+	}
+
+	return sales, rows.Err()
+}
+
+func (r *ReturnRepo) CreateReturnTx(tx *sql.Tx, c models.ReturnCreate) (string, error) {
+	query := `
+		INSERT INTO receipt_return (
+			return_number,
+			receipt_number,
+			employee_id,
+			return_date,
+			reason,
+			sum_total,
+			vat,
+			shift_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING return_number
+	`
+
+	returnNumber, err := getNewReturnNumber(tx)
+	if err != nil {
+		return "", err
+	}
+	err = tx.QueryRow(
+		query,
+		returnNumber,
+		c.ReceiptNumber,
+		c.EmployeeId,
+		c.ReturnDate,
+		c.Reason,
+		c.TotalSum,
+		c.VAT,
+		c.ShiftID,
+	).Scan(&returnNumber)
+	if err != nil {
+		return "", err
+	}
+
+	itemQuery := `
+		INSERT INTO return_item (
+			return_number,
+			upc,
+			receipt_number,
+			product_number,
+			selling_price
+		) VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, item := range c.Items {
+		_, err = tx.Exec(
+			itemQuery,
+			returnNumber,
+			item.UPC,
+			c.ReceiptNumber,
+			item.ProductNumber,
+			item.SellingPrice,
+		)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return returnNumber, nil
+}
+
+func (r *ReturnRepo) RetrieveReturnByReturnNumber(returnNumber string) (models.ReturnRetrieve, error) {
+	query := `
+		SELECT
+			return_number,
+			receipt_number,
+			employee_id,
+			return_date,
+			reason,
+			sum_total,
+			vat,
+			shift_id
+		FROM receipt_return
+		WHERE return_number = $1
+	`
+
+	var ret models.ReturnRetrieve
+	err := r.db.QueryRow(query, returnNumber).Scan(
+		&ret.ReturnNumber,
+		&ret.ReceiptNumber,
+		&ret.EmployeeId,
+		&ret.ReturnDate,
+		&ret.Reason,
+		&ret.TotalSum,
+		&ret.VAT,
+		&ret.ShiftID,
+	)
+	if err != nil {
+		return models.ReturnRetrieve{}, err
+	}
+
+	ret.Items, err = r.RetrieveReturnItems(returnNumber)
+	if err != nil {
+		return models.ReturnRetrieve{}, err
+	}
+
+	return ret, nil
+}
+
+func (r *ReturnRepo) RetrieveReturnItems(returnNumber string) ([]models.ReturnItemRetrieve, error) {
+	query := `
+		SELECT
+			upc,
+			product_number,
+			selling_price,
+			(product_number * selling_price) AS total_price
+		FROM return_item
+		WHERE return_number = $1
+		ORDER BY upc
+	`
+
+	rows, err := r.db.Query(query, returnNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.ReturnItemRetrieve
+	for rows.Next() {
+		var item models.ReturnItemRetrieve
+		err := rows.Scan(
+			&item.UPC,
+			&item.ProductNumber,
+			&item.SellingPrice,
+			&item.TotalPrice,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (r *ReturnRepo) RetrieveReturns() ([]models.ReturnRetrieve, error) {
+	query := `
+		SELECT
+			return_number,
+			receipt_number,
+			employee_id,
+			return_date,
+			reason,
+			sum_total,
+			vat,
+			shift_id
+		FROM receipt_return
+		ORDER BY return_date DESC
+	`
+	return r.queryReturns(query)
+}
+
+func (r *ReturnRepo) RetrieveReturnsByReceipt(receiptNumber string) ([]models.ReturnRetrieve, error) {
+	query := `
+		SELECT
+			return_number,
+			receipt_number,
+			employee_id,
+			return_date,
+			reason,
+			sum_total,
+			vat,
+			shift_id
+		FROM receipt_return
+		WHERE receipt_number = $1
+		ORDER BY return_date
+	`
+	return r.queryReturns(query, receiptNumber)
+}
+
+func (r *ReturnRepo) queryReturns(query string, args ...interface{}) ([]models.ReturnRetrieve, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []models.ReturnRetrieve
+	for rows.Next() {
+		var ret models.ReturnRetrieve
+		err := rows.Scan(
+			&ret.ReturnNumber,
+			&ret.ReceiptNumber,
+			&ret.EmployeeId,
+			&ret.ReturnDate,
+			&ret.Reason,
+			&ret.TotalSum,
+			&ret.VAT,
+			&ret.ShiftID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+
+	return returns, nil
+}
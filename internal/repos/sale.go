@@ -18,44 +18,64 @@ func NewSaleRepo(db *sql.DB) *SaleRepo {
 	}
 }
 
-func (r *SaleRepo) CreateSale(s models.SaleCreate) error {
+func (r *SaleRepo) CreateSaleTx(tx *sql.Tx, s models.SaleCreate) error {
+	return createSale(tx, s)
+}
+
+func createSale(q dbtx, s models.SaleCreate) error {
 	query := `
 		INSERT INTO sale (
 			upc,
 			receipt_number,
 			product_number,
-			selling_price
-		) VALUES ($1, $2, $3, $4)
+			selling_price,
+			vat_rate,
+			vat_sum
+		) VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.Exec(
+	_, err := q.Exec(
 		query,
 		s.UPC,
 		s.ReceiptNumber,
 		s.ProductNumber,
 		s.SellingPrice,
+		s.VATRate,
+		s.VATSum,
 	)
 
 	return err
 }
 
 func (r *SaleRepo) RetrieveSaleByKey(upc, receiptNumber string) (models.SaleRetrieve, error) {
+	return retrieveSaleByKey(r.db, upc, receiptNumber)
+}
+
+func (r *SaleRepo) RetrieveSaleByKeyTx(tx *sql.Tx, upc, receiptNumber string) (models.SaleRetrieve, error) {
+	return retrieveSaleByKey(tx, upc, receiptNumber)
+}
+
+func retrieveSaleByKey(q dbtx, upc, receiptNumber string) (models.SaleRetrieve, error) {
 	query := `
 		SELECT
 			upc,
 			receipt_number,
 			product_number,
-			selling_price
+			selling_price,
+			vat_rate,
+			vat_sum
 		FROM sale
 		WHERE upc = $1 AND receipt_number = $2
 	`
 
 	var sale models.SaleRetrieve
-	err := r.db.QueryRow(query, upc, receiptNumber).Scan(
+	err := q.QueryRow(query, upc, receiptNumber).Scan(
 		&sale.UPC,
 		&sale.ReceiptNumber,
 		&sale.ProductNumber,
 		&sale.SellingPrice,
+		&sale.VATRate,
+		&sale.VATSum,
 	)
 
 	if err != nil {
@@ -66,18 +86,28 @@ func (r *SaleRepo) RetrieveSaleByKey(upc, receiptNumber string) (models.SaleRetr
 }
 
 func (r *SaleRepo) RetrieveSalesByReceipt(receiptNumber string) ([]models.SaleRetrieve, error) {
+	return retrieveSalesByReceipt(r.db, receiptNumber)
+}
+
+func (r *SaleRepo) RetrieveSalesByReceiptTx(tx *sql.Tx, receiptNumber string) ([]models.SaleRetrieve, error) {
+	return retrieveSalesByReceipt(tx, receiptNumber)
+}
+
+func retrieveSalesByReceipt(q dbtx, receiptNumber string) ([]models.SaleRetrieve, error) {
 	query := `
 		SELECT
 			upc,
 			receipt_number,
 			product_number,
-			selling_price
+			selling_price,
+			vat_rate,
+			vat_sum
 		FROM sale
 		WHERE receipt_number = $1
 		ORDER BY upc
 	`
 
-	rows, err := r.db.Query(query, receiptNumber)
+	rows, err := q.Query(query, receiptNumber)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +121,8 @@ func (r *SaleRepo) RetrieveSalesByReceipt(receiptNumber string) ([]models.SaleRe
 			&sale.ReceiptNumber,
 			&sale.ProductNumber,
 			&sale.SellingPrice,
+			&sale.VATRate,
+			&sale.VATSum,
 		)
 		if err != nil {
 			return nil, err
@@ -107,7 +139,9 @@ func (r *SaleRepo) RetrieveSalesByUPC(upc string) ([]models.SaleRetrieve, error)
 			upc,
 			receipt_number,
 			product_number,
-			selling_price
+			selling_price,
+			vat_rate,
+			vat_sum
 		FROM sale
 		WHERE upc = $1
 		ORDER BY receipt_number
@@ -127,6 +161,8 @@ func (r *SaleRepo) RetrieveSalesByUPC(upc string) ([]models.SaleRetrieve, error)
 			&sale.ReceiptNumber,
 			&sale.ProductNumber,
 			&sale.SellingPrice,
+			&sale.VATRate,
+			&sale.VATSum,
 		)
 		if err != nil {
 			return nil, err
@@ -143,7 +179,9 @@ func (r *SaleRepo) RetrieveAllSales() ([]models.SaleRetrieve, error) {
 			upc,
 			receipt_number,
 			product_number,
-			selling_price
+			selling_price,
+			vat_rate,
+			vat_sum
 		FROM sale
 		ORDER BY receipt_number, upc
 	`
@@ -162,6 +200,8 @@ func (r *SaleRepo) RetrieveAllSales() ([]models.SaleRetrieve, error) {
 			&sale.ReceiptNumber,
 			&sale.ProductNumber,
 			&sale.SellingPrice,
+			&sale.VATRate,
+			&sale.VATSum,
 		)
 		if err != nil {
 			return nil, err
@@ -182,7 +222,9 @@ func (r *SaleRepo) RetrieveSalesWithDetails() ([]models.SaleWithDetails, error)
 			p.product_name,
 			c.category_name,
 			p.characteristics,
-			(s.product_number * s.selling_price) as total_price
+			(s.product_number * s.selling_price) as total_price,
+			s.vat_rate,
+			s.vat_sum
 		FROM sale s
 		JOIN store_product sp ON s.upc = sp.upc
 		JOIN product p ON sp.product_id = p.product_id
@@ -208,6 +250,8 @@ func (r *SaleRepo) RetrieveSalesWithDetails() ([]models.SaleWithDetails, error)
 			&sale.CategoryName,
 			&sale.Characteristics,
 			&sale.TotalPrice,
+			&sale.VATRate,
+			&sale.VATSum,
 		)
 		if err != nil {
 			return nil, err
@@ -228,7 +272,9 @@ func (r *SaleRepo) RetrieveSalesWithDetailsByReceipt(receiptNumber string) ([]mo
 			p.product_name,
 			c.category_name,
 			p.characteristics,
-			(s.product_number * s.selling_price) as total_price
+			(s.product_number * s.selling_price) as total_price,
+			s.vat_rate,
+			s.vat_sum
 		FROM sale s
 		JOIN store_product sp ON s.upc = sp.upc
 		JOIN product p ON sp.product_id = p.product_id
@@ -255,6 +301,8 @@ func (r *SaleRepo) RetrieveSalesWithDetailsByReceipt(receiptNumber string) ([]mo
 			&sale.CategoryName,
 			&sale.Characteristics,
 			&sale.TotalPrice,
+			&sale.VATRate,
+			&sale.VATSum,
 		)
 		if err != nil {
 			return nil, err
@@ -265,7 +313,7 @@ func (r *SaleRepo) RetrieveSalesWithDetailsByReceipt(receiptNumber string) ([]mo
 	return sales, nil
 }
 
-func (r *SaleRepo) UpdateSale(upc, receiptNumber string, s models.SaleUpdate) error {
+func (r *SaleRepo) UpdateSaleTx(tx *sql.Tx, upc, receiptNumber string, s models.SaleUpdate) error {
 	setParts := []string{}
 	args := []interface{}{}
 	argIndex := 1
@@ -282,6 +330,12 @@ func (r *SaleRepo) UpdateSale(upc, receiptNumber string, s models.SaleUpdate) er
 		argIndex++
 	}
 
+	if s.VATSum != nil {
+		setParts = append(setParts, fmt.Sprintf("vat_sum = $%d", argIndex))
+		args = append(args, *s.VATSum)
+		argIndex++
+	}
+
 	if len(setParts) == 0 {
 		return nil // Nothing to update
 	}
@@ -294,92 +348,91 @@ func (r *SaleRepo) UpdateSale(upc, receiptNumber string, s models.SaleUpdate) er
 
 	args = append(args, upc, receiptNumber)
 
-	_, err := r.db.Exec(query, args...)
+	_, err := tx.Exec(query, args...)
 	return err
 }
 
-func (r *SaleRepo) DeleteSale(upc, receiptNumber string) error {
-	query := `DELETE FROM sale WHERE upc = $1 AND receipt_number = $2`
-	_, err := r.db.Exec(query, upc, receiptNumber)
+// UpdateSaleVATTx rewrites the VAT of a line, e.g. after the receipt's
+// discount changed.
+func (r *SaleRepo) UpdateSaleVATTx(tx *sql.Tx, upc, receiptNumber string, vatSum models.Money) error {
+	query := `UPDATE sale SET vat_sum = $3 WHERE upc = $1 AND receipt_number = $2`
+	_, err := tx.Exec(query, upc, receiptNumber, vatSum)
 	return err
 }
 
-func (r *SaleRepo) DeleteSalesByReceipt(receiptNumber string) error {
-	query := `DELETE FROM sale WHERE receipt_number = $1`
-	_, err := r.db.Exec(query, receiptNumber)
+func (r *SaleRepo) DeleteSaleTx(tx *sql.Tx, upc, receiptNumber string) error {
+	query := `DELETE FROM sale WHERE upc = $1 AND receipt_number = $2`
+	_, err := tx.Exec(query, upc, receiptNumber)
 	return err
 }
 
-func (r *SaleRepo) GetReceiptTotal(receiptNumber string) (float64, error) {
-	query := `
-		SELECT COALESCE(SUM(product_number * selling_price), 0)
-		FROM sale
-		WHERE receipt_number = $1
-	`
-
-	var total float64
-	err := r.db.QueryRow(query, receiptNumber).Scan(&total)
-	return total, err
+func (r *SaleRepo) DeleteSalesByReceiptTx(tx *sql.Tx, receiptNumber string) error {
+	query := `DELETE FROM sale WHERE receipt_number = $1`
+	_, err := tx.Exec(query, receiptNumber)
+	return err
 }
 
-func (r *SaleRepo) GetSalesStatsByProduct(productID int, startDate, endDate string) (int, float64, error) {
+// GetSalesStatsByProduct reports net units and revenue for a product; units
+// returned within the period are subtracted and voided receipts are ignored.
+func (r *SaleRepo) GetSalesStatsByProduct(productID int, startDate, endDate string) (int, models.Money, error) {
 	query := `
 		SELECT
-			COALESCE(SUM(s.product_number), 0) as total_quantity,
-			COALESCE(SUM(s.product_number * s.selling_price), 0) as total_revenue
-		FROM sale s
-		JOIN store_product sp ON s.upc = sp.upc
-		JOIN receipt r ON s.receipt_number = r.receipt_number
+			COALESCE(SUM(m.product_number), 0) as total_quantity,
+			COALESCE(SUM(m.revenue), 0) as total_revenue
+		FROM sale_movement m
+		JOIN store_product sp ON m.upc = sp.upc
 		WHERE sp.product_id = $1
-		AND r.print_date >= $2::date
-		AND r.print_date <= $3::date
+		AND m.movement_date >= $2::date
+		AND m.movement_date < $3::date + 1
+		AND NOT m.voided
 	`
 
 	var totalQuantity int
-	var totalRevenue float64
+	var totalRevenue models.Money
 	err := r.db.QueryRow(query, productID, startDate, endDate).Scan(&totalQuantity, &totalRevenue)
 	return totalQuantity, totalRevenue, err
 }
 
-func (r *SaleRepo) GetTopSellingProducts(limit int) ([]struct {
-	ProductID    int     `json:"product_id"`
-	ProductName  string  `json:"product_name"`
-	TotalSold    int     `json:"total_sold"`
-	TotalRevenue float64 `json:"total_revenue"`
+func (r *SaleRepo) GetTopSellingProducts(limit int, includeVoided bool) ([]struct {
+	ProductID    int          `json:"product_id"`
+	ProductName  string       `json:"product_name"`
+	TotalSold    int          `json:"total_sold"`
+	TotalRevenue models.Money `json:"total_revenue"`
 }, error) {
 	query := `
 		SELECT
 			p.product_id,
 			p.product_name,
-			SUM(s.product_number) as total_sold,
-			SUM(s.product_number * s.selling_price) as total_revenue
-		FROM sale s
-		JOIN store_product sp ON s.upc = sp.upc
+			SUM(m.product_number) as total_sold,
+			SUM(m.revenue) as total_revenue
+		FROM sale_movement m
+		JOIN store_product sp ON m.upc = sp.upc
 		JOIN product p ON sp.product_id = p.product_id
+		WHERE $2 OR NOT m.voided
 		GROUP BY p.product_id, p.product_name
 		ORDER BY total_sold DESC
 		LIMIT $1
 	`
 
-	rows, err := r.db.Query(query, limit)
+	rows, err := r.db.Query(query, limit, includeVoided)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var results []struct {
-		ProductID    int     `json:"product_id"`
-		ProductName  string  `json:"product_name"`
-		TotalSold    int     `json:"total_sold"`
-		TotalRevenue float64 `json:"total_revenue"`
+		ProductID    int          `json:"product_id"`
+		ProductName  string       `json:"product_name"`
+		TotalSold    int          `json:"total_sold"`
+		TotalRevenue models.Money `json:"total_revenue"`
 	}
 
 	for rows.Next() {
 		var item struct {
-			ProductID    int     `json:"product_id"`
-			ProductName  string  `json:"product_name"`
-			TotalSold    int     `json:"total_sold"`
-			TotalRevenue float64 `json:"total_revenue"`
+			ProductID    int          `json:"product_id"`
+			ProductName  string       `json:"product_name"`
+			TotalSold    int          `json:"total_sold"`
+			TotalRevenue models.Money `json:"total_revenue"`
 		}
 		err := rows.Scan(
 			&item.ProductID,
@@ -0,0 +1,270 @@
+package repos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type ShiftRepo struct {
+	db *sql.DB
+}
+
+func NewShiftRepo(db *sql.DB) *ShiftRepo {
+	return &ShiftRepo{
+		db: db,
+	}
+}
+
+func (r *ShiftRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func (r *ShiftRepo) CreateShift(c models.ShiftCreate) (int, error) {
+	query := `
+		INSERT INTO shift (
+			register_id,
+			employee_id,
+			opened_at,
+			opening_float
+		) VALUES ($1, $2, $3, $4)
+		RETURNING shift_id
+	`
+
+	var shiftID int
+	err := r.db.QueryRow(
+		query,
+		c.RegisterId,
+		c.EmployeeId,
+		c.OpenedAt,
+		c.OpeningFloat,
+	).Scan(&shiftID)
+
+	return shiftID, err
+}
+
+const shiftColumns = `
+			shift_id,
+			register_id,
+			employee_id,
+			opened_at,
+			opening_float,
+			closed_at,
+			closed_by
+`
+
+func scanShift(row interface{ Scan(...any) error }) (models.ShiftRetrieve, error) {
+	var shift models.ShiftRetrieve
+	err := row.Scan(
+		&shift.ShiftID,
+		&shift.RegisterId,
+		&shift.EmployeeId,
+		&shift.OpenedAt,
+		&shift.OpeningFloat,
+		&shift.ClosedAt,
+		&shift.ClosedBy,
+	)
+	return shift, err
+}
+
+func (r *ShiftRepo) RetrieveShiftByID(shiftID int) (models.ShiftRetrieve, error) {
+	query := `SELECT` + shiftColumns + `FROM shift WHERE shift_id = $1`
+	return scanShift(r.db.QueryRow(query, shiftID))
+}
+
+// RetrieveShiftForUpdateTx reads a shift and locks its row, so that closing
+// it waits for receipts that are being written into it.
+func (r *ShiftRepo) RetrieveShiftForUpdateTx(tx *sql.Tx, shiftID int) (models.ShiftRetrieve, error) {
+	query := `SELECT` + shiftColumns + `FROM shift WHERE shift_id = $1 FOR UPDATE`
+	return scanShift(tx.QueryRow(query, shiftID))
+}
+
+func (r *ShiftRepo) RetrieveOpenShiftByEmployee(employeeID string) (models.ShiftRetrieve, error) {
+	query := `SELECT` + shiftColumns + `FROM shift WHERE employee_id = $1 AND closed_at IS NULL`
+	return scanShift(r.db.QueryRow(query, employeeID))
+}
+
+// RetrieveOpenShiftByEmployeeTx reads the cashier's open shift and holds a
+// share lock on it until tx ends, so the shift cannot be closed while a
+// receipt or return is being written into it.
+func (r *ShiftRepo) RetrieveOpenShiftByEmployeeTx(tx *sql.Tx, employeeID string) (models.ShiftRetrieve, error) {
+	query := `SELECT` + shiftColumns + `FROM shift WHERE employee_id = $1 AND closed_at IS NULL FOR SHARE`
+	return scanShift(tx.QueryRow(query, employeeID))
+}
+
+// RetrieveShiftAtTx reads the cashier's shift that was open at the given
+// time, closed or not, and holds a share lock on it until tx ends.
+func (r *ShiftRepo) RetrieveShiftAtTx(tx *sql.Tx, employeeID string, at time.Time) (models.ShiftRetrieve, error) {
+	query := `SELECT` + shiftColumns + `FROM shift
+		WHERE employee_id = $1 AND opened_at <= $2 AND (closed_at IS NULL OR closed_at >= $2)
+		ORDER BY opened_at DESC
+		LIMIT 1
+		FOR SHARE
+	`
+	return scanShift(tx.QueryRow(query, employeeID, at))
+}
+
+func (r *ShiftRepo) RetrieveOpenShiftByRegister(registerID string) (models.ShiftRetrieve, error) {
+	query := `SELECT` + shiftColumns + `FROM shift WHERE register_id = $1 AND closed_at IS NULL`
+	return scanShift(r.db.QueryRow(query, registerID))
+}
+
+// RetrieveShifts lists shifts matching f, latest first.
+func (r *ShiftRepo) RetrieveShifts(f models.ShiftFilter) ([]models.ShiftRetrieve, error) {
+	query := `SELECT` + shiftColumns + `FROM shift
+		WHERE ($1 = '' OR register_id = $1)
+			AND ($2 = '' OR employee_id = $2)
+			AND ($3::boolean IS NULL OR (closed_at IS NULL) = $3)
+		ORDER BY opened_at DESC, shift_id DESC
+	`
+
+	rows, err := r.db.Query(query, f.RegisterId, f.EmployeeId, f.Open)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shifts []models.ShiftRetrieve
+	for rows.Next() {
+		shift, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+
+	return shifts, rows.Err()
+}
+
+func (r *ShiftRepo) CloseShiftTx(tx *sql.Tx, shiftID int, closedBy string, closedAt time.Time) error {
+	query := `
+		UPDATE shift
+		SET closed_at = $2, closed_by = $3
+		WHERE shift_id = $1 AND closed_at IS NULL
+	`
+	_, err := tx.Exec(query, shiftID, closedAt, closedBy)
+	return err
+}
+
+func (r *ShiftRepo) RetrieveShiftReport(shiftID int) (models.ShiftReport, error) {
+	return retrieveShiftReport(r.db, shiftID)
+}
+
+func (r *ShiftRepo) RetrieveShiftReportTx(tx *sql.Tx, shiftID int) (models.ShiftReport, error) {
+	return retrieveShiftReport(tx, shiftID)
+}
+
+// retrieveShiftReport sums the receipts, VAT, tenders and returns written
+// into the shift. Voided receipts are only counted, but they are part of the
+// range of receipt numbers the shift issued.
+func retrieveShiftReport(q dbtx, shiftID int) (models.ShiftReport, error) {
+	var report models.ShiftReport
+
+	receiptsQuery := `
+		SELECT
+			COUNT(*) FILTER (WHERE voided_at IS NULL),
+			COUNT(*) FILTER (WHERE voided_at IS NOT NULL),
+			COALESCE(SUM(sum_total) FILTER (WHERE voided_at IS NULL), 0),
+			COALESCE(SUM(discount_sum) FILTER (WHERE voided_at IS NULL), 0),
+			COALESCE(SUM(vat) FILTER (WHERE voided_at IS NULL), 0),
+			MIN(receipt_number),
+			MAX(receipt_number)
+		FROM receipt
+		WHERE shift_id = $1
+	`
+	err := q.QueryRow(receiptsQuery, shiftID).Scan(
+		&report.Receipts,
+		&report.VoidedReceipts,
+		&report.SalesTotal,
+		&report.DiscountTotal,
+		&report.VATTotal,
+		&report.FirstReceipt,
+		&report.LastReceipt,
+	)
+	if err != nil {
+		return models.ShiftReport{}, err
+	}
+
+	vatQuery := `
+		SELECT
+			v.vat_rate,
+			SUM(v.taxable_sum),
+			SUM(v.vat_sum)
+		FROM receipt_vat v
+		JOIN receipt r ON r.receipt_number = v.receipt_number
+		WHERE r.shift_id = $1 AND r.voided_at IS NULL
+		GROUP BY v.vat_rate
+		ORDER BY v.vat_rate DESC
+	`
+	rows, err := q.Query(vatQuery, shiftID)
+	if err != nil {
+		return models.ShiftReport{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var line models.ReceiptVATLine
+		if err := rows.Scan(&line.Rate, &line.Base, &line.Amount); err != nil {
+			return models.ShiftReport{}, err
+		}
+		report.VAT = append(report.VAT, line)
+	}
+	if err := rows.Err(); err != nil {
+		return models.ShiftReport{}, err
+	}
+
+	tendersQuery := `
+		SELECT
+			p.tender,
+			COUNT(DISTINCT r.receipt_number),
+			SUM(p.amount),
+			SUM(p.tendered),
+			SUM(p.tendered - p.amount)
+		FROM receipt_payment p
+		JOIN receipt r ON p.receipt_number = r.receipt_number
+		WHERE r.shift_id = $1 AND r.voided_at IS NULL
+		GROUP BY p.tender
+		ORDER BY p.tender
+	`
+	tenderRows, err := q.Query(tendersQuery, shiftID)
+	if err != nil {
+		return models.ShiftReport{}, err
+	}
+	defer tenderRows.Close()
+	for tenderRows.Next() {
+		var total models.ShiftTenderTotal
+		err := tenderRows.Scan(
+			&total.Tender,
+			&total.Receipts,
+			&total.Amount,
+			&total.Tendered,
+			&total.Change,
+		)
+		if err != nil {
+			return models.ShiftReport{}, err
+		}
+		report.Tenders = append(report.Tenders, total)
+	}
+	if err := tenderRows.Err(); err != nil {
+		return models.ShiftReport{}, err
+	}
+
+	returnsQuery := `
+		SELECT
+			COUNT(*),
+			COALESCE(SUM(sum_total), 0),
+			COALESCE(SUM(vat), 0)
+		FROM receipt_return
+		WHERE shift_id = $1
+	`
+	err = q.QueryRow(returnsQuery, shiftID).Scan(
+		&report.Returns,
+		&report.ReturnsTotal,
+		&report.ReturnsVAT,
+	)
+	if err != nil {
+		return models.ShiftReport{}, err
+	}
+
+	return report, nil
+}
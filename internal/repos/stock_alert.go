@@ -0,0 +1,234 @@
+package repos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type StockAlertRepo struct {
+	db *sql.DB
+}
+
+func NewStockAlertRepo(db *sql.DB) *StockAlertRepo {
+	return &StockAlertRepo{
+		db: db,
+	}
+}
+
+// applyStockAlerts raises or resolves the low-stock alert of m.UPC after a
+// stock change has been written and recorded in the ledger. A sale,
+// adjustment or write-off that takes the UPC from its product's min_stock or
+// more to below it raises an alert, unless one is already open. An increase
+// that brings the UPC back to min_stock resolves the open alert.
+func applyStockAlerts(q dbtx, m models.StockMovementCreate) error {
+	switch {
+	case m.Delta < 0 && (m.Kind == models.StockMovementSale ||
+		m.Kind == models.StockMovementAdjustment ||
+		m.Kind == models.StockMovementWriteOff):
+		query := `
+			INSERT INTO stock_alert (upc, movement_id, stock, min_stock, reorder_quantity, created_at)
+			SELECT sm.upc, sm.movement_id, sm.balance, p.min_stock, p.reorder_quantity, sm.moved_at
+			FROM stock_movement sm
+			JOIN store_product sp ON sp.upc = sm.upc
+			JOIN product p ON p.product_id = sp.product_id
+			WHERE sm.movement_id = (SELECT MAX(movement_id) FROM stock_movement WHERE upc = $1)
+				AND sm.balance < p.min_stock
+				AND sm.balance - sm.delta >= p.min_stock
+			ON CONFLICT (upc) WHERE status = 'open' DO NOTHING
+		`
+		_, err := q.Exec(query, m.UPC)
+		return err
+	case m.Delta > 0:
+		query := `
+			UPDATE stock_alert a
+			SET status = 'resolved', resolved_at = NOW()
+			FROM store_product sp
+			JOIN product p ON p.product_id = sp.product_id
+			WHERE a.upc = $1
+				AND a.status = 'open'
+				AND sp.upc = a.upc
+				AND (p.min_stock IS NULL OR sp.products_number >= p.min_stock)
+		`
+		_, err := q.Exec(query, m.UPC)
+		return err
+	}
+	return nil
+}
+
+// RetrieveReorderLevels returns the product's levels, or sql.ErrNoRows when
+// the product does not exist or has none.
+func (r *StockAlertRepo) RetrieveReorderLevels(productID int) (models.ReorderLevels, error) {
+	query := `
+		SELECT product_id, min_stock, reorder_quantity
+		FROM product
+		WHERE product_id = $1 AND min_stock IS NOT NULL
+	`
+	var levels models.ReorderLevels
+	err := r.db.QueryRow(query, productID).Scan(&levels.ProductID, &levels.MinStock, &levels.ReorderQuantity)
+	return levels, err
+}
+
+// SetReorderLevels sets the levels of the product; nil clears them.
+func (r *StockAlertRepo) SetReorderLevels(productID int, levels *models.ReorderLevels) error {
+	var minStock, reorderQuantity *int
+	if levels != nil {
+		minStock = &levels.MinStock
+		reorderQuantity = &levels.ReorderQuantity
+	}
+	query := `UPDATE product SET min_stock = $2, reorder_quantity = $3 WHERE product_id = $1`
+	_, err := r.db.Exec(query, productID, minStock, reorderQuantity)
+	return err
+}
+
+// RetrieveLowStock lists the UPCs below their product's min_stock with the
+// units sold net of returns and voids in the last f.Days days, by category
+// and product.
+func (r *StockAlertRepo) RetrieveLowStock(f models.LowStockFilter) ([]models.LowStockProduct, error) {
+	query := `
+		SELECT
+			sp.upc,
+			p.product_id,
+			p.product_name,
+			c.category_id,
+			c.category_name,
+			sp.promotional_product,
+			sp.products_number,
+			p.min_stock,
+			p.reorder_quantity,
+			COALESCE(-SUM(sm.delta), 0)
+		FROM store_product sp
+		JOIN product p ON p.product_id = sp.product_id
+		JOIN category c ON c.category_id = p.category_id
+		LEFT JOIN stock_movement sm ON sm.upc = sp.upc
+			AND sm.kind IN ('sale', 'return', 'void')
+			AND sm.moved_at >= NOW() - make_interval(days => $1)
+		WHERE p.min_stock IS NOT NULL
+			AND sp.products_number < p.min_stock
+			AND ($2::integer IS NULL OR c.category_id = $2)
+		GROUP BY sp.upc, p.product_id, c.category_id
+		ORDER BY c.category_name, p.product_name, sp.upc
+	`
+
+	rows, err := r.db.Query(query, f.Days, f.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []models.LowStockProduct
+	for rows.Next() {
+		var product models.LowStockProduct
+		err := rows.Scan(
+			&product.UPC,
+			&product.ProductID,
+			&product.ProductName,
+			&product.CategoryID,
+			&product.CategoryName,
+			&product.PromotionalProduct,
+			&product.ProductsNumber,
+			&product.MinStock,
+			&product.ReorderQuantity,
+			&product.UnitsSold,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+
+	return products, rows.Err()
+}
+
+const stockAlertColumns = `
+			a.alert_id,
+			a.upc,
+			p.product_id,
+			p.product_name,
+			c.category_name,
+			a.movement_id,
+			sm.kind,
+			a.stock,
+			a.min_stock,
+			a.reorder_quantity,
+			sp.products_number,
+			a.created_at,
+			a.status,
+			a.resolved_at,
+			a.resolved_by
+		FROM stock_alert a
+		JOIN stock_movement sm ON sm.movement_id = a.movement_id
+		JOIN store_product sp ON sp.upc = a.upc
+		JOIN product p ON p.product_id = sp.product_id
+		JOIN category c ON c.category_id = p.category_id
+`
+
+func scanStockAlert(row interface{ Scan(...any) error }) (models.StockAlert, error) {
+	var alert models.StockAlert
+	err := row.Scan(
+		&alert.AlertID,
+		&alert.UPC,
+		&alert.ProductID,
+		&alert.ProductName,
+		&alert.CategoryName,
+		&alert.MovementID,
+		&alert.Kind,
+		&alert.Stock,
+		&alert.MinStock,
+		&alert.ReorderQuantity,
+		&alert.CurrentStock,
+		&alert.CreatedAt,
+		&alert.Status,
+		&alert.ResolvedAt,
+		&alert.ResolvedBy,
+	)
+	return alert, err
+}
+
+func (r *StockAlertRepo) RetrieveStockAlertByID(alertID int) (models.StockAlert, error) {
+	query := `SELECT` + stockAlertColumns + `WHERE a.alert_id = $1`
+	return scanStockAlert(r.db.QueryRow(query, alertID))
+}
+
+// RetrieveStockAlerts lists alerts with the given status, or all of them
+// for an empty status, oldest first.
+func (r *StockAlertRepo) RetrieveStockAlerts(status string) ([]models.StockAlert, error) {
+	query := `SELECT` + stockAlertColumns + `
+		WHERE ($1 = '' OR a.status = $1)
+		ORDER BY a.created_at, a.alert_id
+	`
+
+	rows, err := r.db.Query(query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var alerts []models.StockAlert
+	for rows.Next() {
+		alert, err := scanStockAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// ResolveStockAlert closes an open alert; it reports false when the alert
+// was not open.
+func (r *StockAlertRepo) ResolveStockAlert(alertID int, employeeID string, resolvedAt time.Time) (bool, error) {
+	query := `
+		UPDATE stock_alert
+		SET status = 'resolved', resolved_at = $2, resolved_by = $3
+		WHERE alert_id = $1 AND status = 'open'
+	`
+	result, err := r.db.Exec(query, alertID, resolvedAt, employeeID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
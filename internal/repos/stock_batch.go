@@ -0,0 +1,180 @@
+package repos
+
+import (
+	"database/sql"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type StockBatchRepo struct {
+	db *sql.DB
+}
+
+func NewStockBatchRepo(db *sql.DB) *StockBatchRepo {
+	return &StockBatchRepo{
+		db: db,
+	}
+}
+
+// applyStockBatches keeps the batches of m.UPC in step with a stock change
+// that has just been written; the store_product row is locked by then, so
+// the batches of a UPC are changed by one transaction at a time.
+//
+// An increase opens a batch expiring on expiryDate. A promotional UPC
+// taking over the units of its regular UPC instead gets copies of that
+// UPC's batches. A decrease takes units first-expiry-first-out, batches of
+// unknown expiry last; a delivery taken back out empties the batches it
+// opened first. Units beyond what the batches hold, as sold by offline
+// tills, are taken from no batch.
+func applyStockBatches(q dbtx, m models.StockMovementCreate, expiryDate *string) error {
+	switch {
+	case m.Delta > 0 && m.Kind == models.StockMovementPromoTransfer && m.Reference != nil:
+		query := `
+			INSERT INTO stock_batch (upc, received_at, expiry_date, initial_quantity, quantity, reference)
+			SELECT $1, received_at, expiry_date, quantity, quantity, reference
+			FROM stock_batch
+			WHERE upc = $2 AND quantity > 0
+			ORDER BY batch_id
+		`
+		_, err := q.Exec(query, m.UPC, *m.Reference)
+		return err
+	case m.Delta > 0:
+		query := `
+			INSERT INTO stock_batch (upc, expiry_date, initial_quantity, quantity, reference)
+			VALUES ($1, $2, $3, $3, $4)
+		`
+		_, err := q.Exec(query, m.UPC, expiryDate, m.Delta, m.Reference)
+		return err
+	case m.Delta < 0:
+		var reference *string
+		if m.Kind == models.StockMovementDelivery {
+			reference = m.Reference
+		}
+		query := `
+			WITH batches AS (
+				SELECT
+					batch_id,
+					quantity,
+					SUM(quantity) OVER (
+						ORDER BY
+							($3::text IS NOT NULL AND reference = $3) DESC,
+							expiry_date NULLS LAST,
+							received_at,
+							batch_id
+					) - quantity AS taken_before
+				FROM (
+					SELECT batch_id, quantity, expiry_date, received_at, reference
+					FROM stock_batch
+					WHERE upc = $1 AND quantity > 0
+					FOR UPDATE
+				) b
+			)
+			UPDATE stock_batch s
+			SET quantity = s.quantity - LEAST(b.quantity, $2 - b.taken_before)
+			FROM batches b
+			WHERE s.batch_id = b.batch_id AND b.taken_before < $2
+		`
+		_, err := q.Exec(query, m.UPC, -m.Delta, reference)
+		return err
+	}
+	return nil
+}
+
+const stockBatchColumns = `
+			b.batch_id,
+			b.upc,
+			b.received_at,
+			TO_CHAR(b.expiry_date, 'YYYY-MM-DD'),
+			b.initial_quantity,
+			b.quantity,
+			b.reference
+`
+
+// RetrieveStockBatchesByUPC lists the batches of a UPC still on hand in the
+// order sales take them.
+func (r *StockBatchRepo) RetrieveStockBatchesByUPC(upc string) ([]models.StockBatch, error) {
+	query := `SELECT` + stockBatchColumns + `
+		FROM stock_batch b
+		WHERE b.upc = $1 AND b.quantity > 0
+		ORDER BY b.expiry_date NULLS LAST, b.received_at, b.batch_id
+	`
+
+	rows, err := r.db.Query(query, upc)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []models.StockBatch
+	for rows.Next() {
+		var batch models.StockBatch
+		err := rows.Scan(
+			&batch.BatchID,
+			&batch.UPC,
+			&batch.ReceivedAt,
+			&batch.ExpiryDate,
+			&batch.InitialQuantity,
+			&batch.Quantity,
+			&batch.Reference,
+		)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
+
+// RetrieveExpiringBatches lists the batches on hand that expire within days
+// of today in the store's time zone, expired ones included, soonest first.
+func (r *StockBatchRepo) RetrieveExpiringBatches(days int) ([]models.ExpiringBatch, error) {
+	query := `SELECT` + stockBatchColumns + `,
+			sp.product_id,
+			p.product_name,
+			c.category_name,
+			sp.selling_price,
+			sp.promotional_product,
+			b.expiry_date - CURRENT_DATE,
+			b.quantity * sp.selling_price
+		FROM stock_batch b
+		JOIN store_product sp ON sp.upc = b.upc
+		JOIN product p ON p.product_id = sp.product_id
+		JOIN category c ON c.category_id = p.category_id
+		WHERE b.quantity > 0 AND b.expiry_date <= CURRENT_DATE + $1::integer
+		ORDER BY b.expiry_date, b.upc, b.batch_id
+	`
+
+	rows, err := r.db.Query(query, days)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var batches []models.ExpiringBatch
+	for rows.Next() {
+		var batch models.ExpiringBatch
+		err := rows.Scan(
+			&batch.BatchID,
+			&batch.UPC,
+			&batch.ReceivedAt,
+			&batch.ExpiryDate,
+			&batch.InitialQuantity,
+			&batch.Quantity,
+			&batch.Reference,
+			&batch.ProductID,
+			&batch.ProductName,
+			&batch.CategoryName,
+			&batch.SellingPrice,
+			&batch.PromotionalProduct,
+			&batch.DaysLeft,
+			&batch.Value,
+		)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, batch)
+	}
+
+	return batches, rows.Err()
+}
@@ -0,0 +1,109 @@
+package repos
+
+import (
+	"database/sql"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type StockMovementRepo struct {
+	db *sql.DB
+}
+
+func NewStockMovementRepo(db *sql.DB) *StockMovementRepo {
+	return &StockMovementRepo{
+		db: db,
+	}
+}
+
+// RetrieveStockMovementsByUPC returns the ledger of a UPC, oldest first.
+func (r *StockMovementRepo) RetrieveStockMovementsByUPC(upc string) ([]models.StockMovementRetrieve, error) {
+	query := `
+		SELECT
+			movement_id,
+			upc,
+			moved_at,
+			kind,
+			delta,
+			balance,
+			employee_id,
+			reason,
+			reference
+		FROM stock_movement
+		WHERE upc = $1
+		ORDER BY movement_id
+	`
+	rows, err := r.db.Query(query, upc)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []models.StockMovementRetrieve
+	for rows.Next() {
+		var movement models.StockMovementRetrieve
+		err := rows.Scan(
+			&movement.MovementID,
+			&movement.UPC,
+			&movement.MovedAt,
+			&movement.Kind,
+			&movement.Delta,
+			&movement.Balance,
+			&movement.EmployeeId,
+			&movement.Reason,
+			&movement.Reference,
+		)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, movement)
+	}
+
+	return movements, rows.Err()
+}
+
+// RetrieveStockLedgerChecks rebuilds the stock of every store product from
+// its movements. Matches is left for the caller to decide.
+func (r *StockMovementRepo) RetrieveStockLedgerChecks() ([]models.StockLedgerCheck, error) {
+	query := `
+		SELECT
+			sp.upc,
+			sp.products_number,
+			COALESCE(SUM(m.delta), 0),
+			(
+				SELECT l.balance
+				FROM stock_movement l
+				WHERE l.upc = sp.upc
+				ORDER BY l.movement_id DESC
+				LIMIT 1
+			),
+			COUNT(m.movement_id)
+		FROM store_product sp
+		LEFT JOIN stock_movement m ON m.upc = sp.upc
+		GROUP BY sp.upc, sp.products_number
+		ORDER BY sp.upc
+	`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checks []models.StockLedgerCheck
+	for rows.Next() {
+		var check models.StockLedgerCheck
+		err := rows.Scan(
+			&check.UPC,
+			&check.Stock,
+			&check.LedgerStock,
+			&check.LastBalance,
+			&check.Movements,
+		)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, rows.Err()
+}
@@ -0,0 +1,324 @@
+package repos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type StocktakeRepo struct {
+	db *sql.DB
+}
+
+func NewStocktakeRepo(db *sql.DB) *StocktakeRepo {
+	return &StocktakeRepo{
+		db: db,
+	}
+}
+
+func (r *StocktakeRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func (r *StocktakeRepo) CreateStocktake(c models.StocktakeCreate) (int, error) {
+	query := `
+		INSERT INTO stocktake (category_id, note, opened_by, opened_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING stocktake_id
+	`
+	var stocktakeID int
+	err := r.db.QueryRow(query, c.CategoryID, c.Note, c.EmployeeId, c.OpenedAt).Scan(&stocktakeID)
+	return stocktakeID, err
+}
+
+const stocktakeColumns = `
+			s.stocktake_id,
+			s.category_id,
+			c.category_name,
+			s.note,
+			s.status,
+			s.opened_by,
+			s.opened_at,
+			s.closed_by,
+			s.closed_at
+		FROM stocktake s
+		LEFT JOIN category c ON c.category_id = s.category_id
+`
+
+func scanStocktake(row interface{ Scan(...any) error }) (models.StocktakeRetrieve, error) {
+	var stocktake models.StocktakeRetrieve
+	err := row.Scan(
+		&stocktake.StocktakeID,
+		&stocktake.CategoryID,
+		&stocktake.CategoryName,
+		&stocktake.Note,
+		&stocktake.Status,
+		&stocktake.OpenedBy,
+		&stocktake.OpenedAt,
+		&stocktake.ClosedBy,
+		&stocktake.ClosedAt,
+	)
+	return stocktake, err
+}
+
+func (r *StocktakeRepo) RetrieveStocktakeByID(stocktakeID int) (models.StocktakeRetrieve, error) {
+	query := `SELECT` + stocktakeColumns + `WHERE s.stocktake_id = $1`
+	return scanStocktake(r.db.QueryRow(query, stocktakeID))
+}
+
+// RetrieveStocktakeForUpdateTx reads a stocktake and locks its row, so that
+// no count is added or removed while it is being approved.
+func (r *StocktakeRepo) RetrieveStocktakeForUpdateTx(tx *sql.Tx, stocktakeID int) (models.StocktakeRetrieve, error) {
+	query := `SELECT` + stocktakeColumns + `WHERE s.stocktake_id = $1 FOR UPDATE OF s`
+	return scanStocktake(tx.QueryRow(query, stocktakeID))
+}
+
+// RetrieveStocktakes lists stocktakes with the given status, or all of them
+// for an empty status, latest first.
+func (r *StocktakeRepo) RetrieveStocktakes(status string) ([]models.StocktakeRetrieve, error) {
+	query := `SELECT` + stocktakeColumns + `
+		WHERE ($1 = '' OR s.status = $1)
+		ORDER BY s.opened_at DESC, s.stocktake_id DESC
+	`
+
+	rows, err := r.db.Query(query, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stocktakes []models.StocktakeRetrieve
+	for rows.Next() {
+		stocktake, err := scanStocktake(rows)
+		if err != nil {
+			return nil, err
+		}
+		stocktakes = append(stocktakes, stocktake)
+	}
+
+	return stocktakes, rows.Err()
+}
+
+// CreateStocktakeCountTx records a count of a store product within the
+// stocktake's category, along with its stock at that moment. It returns
+// sql.ErrNoRows when the UPC does not exist or is outside the category.
+func (r *StocktakeRepo) CreateStocktakeCountTx(tx *sql.Tx, s models.StocktakeRetrieve, employeeID string, c models.StocktakeCountCreate, countedAt time.Time) (int, error) {
+	query := `
+		INSERT INTO stocktake_count (stocktake_id, upc, quantity, location, employee_id, counted_at, system_stock)
+		SELECT $1, sp.upc, $3, $4, $5, $6, sp.products_number
+		FROM store_product sp
+		JOIN product p ON p.product_id = sp.product_id
+		WHERE sp.upc = $2 AND ($7::integer IS NULL OR p.category_id = $7)
+		RETURNING count_id
+	`
+	var countID int
+	err := tx.QueryRow(query, s.StocktakeID, c.UPC, c.Quantity, c.Location, employeeID, countedAt, s.CategoryID).Scan(&countID)
+	return countID, err
+}
+
+// DeleteStocktakeCountTx removes a count of the stocktake; it reports false
+// when the stocktake has no such count.
+func (r *StocktakeRepo) DeleteStocktakeCountTx(tx *sql.Tx, stocktakeID int, countID int) (bool, error) {
+	query := `DELETE FROM stocktake_count WHERE stocktake_id = $1 AND count_id = $2`
+	result, err := tx.Exec(query, stocktakeID, countID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// RetrieveStocktakeCounts lists every count of the stocktake in the order
+// they were made.
+func (r *StocktakeRepo) RetrieveStocktakeCounts(stocktakeID int) ([]models.StocktakeCount, error) {
+	query := `
+		SELECT count_id, upc, quantity, location, employee_id, counted_at, system_stock
+		FROM stocktake_count
+		WHERE stocktake_id = $1
+		ORDER BY counted_at, count_id
+	`
+
+	rows, err := r.db.Query(query, stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []models.StocktakeCount
+	for rows.Next() {
+		var count models.StocktakeCount
+		err := rows.Scan(
+			&count.CountID,
+			&count.UPC,
+			&count.Quantity,
+			&count.Location,
+			&count.EmployeeId,
+			&count.CountedAt,
+			&count.SystemStock,
+		)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, rows.Err()
+}
+
+// stocktakeCountTotals merges the counts of each UPC of stocktake $1. The
+// UPC's system stock is the one recorded with its first count, so every
+// movement after that carries over the stocktake.
+const stocktakeCountTotals = `
+			SELECT DISTINCT ON (upc)
+				upc,
+				SUM(quantity) OVER (PARTITION BY upc) AS counted,
+				system_stock
+			FROM stocktake_count
+			WHERE stocktake_id = $1
+			ORDER BY upc, counted_at, count_id
+`
+
+// RetrieveStocktakeCountTotalsTx merges the counts of the stocktake into one
+// record per UPC, in UPC order, with only UPC, Counted and SystemStock set.
+func (r *StocktakeRepo) RetrieveStocktakeCountTotalsTx(tx *sql.Tx, stocktakeID int) ([]models.StocktakeLineRecord, error) {
+	query := stocktakeCountTotals
+
+	rows, err := tx.Query(query, stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []models.StocktakeLineRecord
+	for rows.Next() {
+		var total models.StocktakeLineRecord
+		if err := rows.Scan(&total.UPC, &total.Counted, &total.SystemStock); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+func (r *StocktakeRepo) CreateStocktakeLineTx(tx *sql.Tx, stocktakeID int, l models.StocktakeLineRecord) error {
+	query := `
+		INSERT INTO stocktake_line (
+			stocktake_id,
+			upc,
+			product_id,
+			counted,
+			system_stock,
+			variance
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := tx.Exec(query, stocktakeID, l.UPC, l.ProductID, l.Counted, l.SystemStock, l.Variance)
+	return err
+}
+
+// CloseStocktakeTx approves or cancels an open stocktake.
+func (r *StocktakeRepo) CloseStocktakeTx(tx *sql.Tx, stocktakeID int, status string, employeeID string, closedAt time.Time) error {
+	query := `
+		UPDATE stocktake
+		SET status = $2, closed_by = $3, closed_at = $4
+		WHERE stocktake_id = $1 AND status = 'open'
+	`
+	_, err := tx.Exec(query, stocktakeID, status, employeeID, closedAt)
+	return err
+}
+
+// RetrieveOpenStocktakeLines compares the merged counts of an open stocktake
+// with the stock of every store product in its scope: the stock recorded
+// with the first count of a counted UPC, the current stock of the others.
+func (r *StocktakeRepo) RetrieveOpenStocktakeLines(s models.StocktakeRetrieve) ([]models.StocktakeLine, error) {
+	query := `
+		SELECT
+			sp.upc,
+			p.product_id,
+			p.product_name,
+			sp.promotional_product,
+			counts.counted,
+			COALESCE(counts.system_stock, sp.products_number)
+		FROM store_product sp
+		JOIN product p ON p.product_id = sp.product_id
+		LEFT JOIN (` + stocktakeCountTotals + `) counts ON counts.upc = sp.upc
+		WHERE $2::integer IS NULL OR p.category_id = $2
+		ORDER BY p.product_name, sp.upc
+	`
+
+	rows, err := r.db.Query(query, s.StocktakeID, s.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.StocktakeLine
+	for rows.Next() {
+		var line models.StocktakeLine
+		err := rows.Scan(
+			&line.UPC,
+			&line.ProductID,
+			&line.ProductName,
+			&line.PromotionalProduct,
+			&line.Counted,
+			&line.SystemStock,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if line.Counted != nil {
+			variance := *line.Counted - line.SystemStock
+			line.Variance = &variance
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// RetrieveStocktakeLines returns the lines posted when the stocktake was
+// approved.
+func (r *StocktakeRepo) RetrieveStocktakeLines(stocktakeID int) ([]models.StocktakeLine, error) {
+	query := `
+		SELECT
+			l.upc,
+			l.product_id,
+			p.product_name,
+			COALESCE(sp.promotional_product, FALSE),
+			l.counted,
+			l.system_stock,
+			l.variance
+		FROM stocktake_line l
+		JOIN product p ON p.product_id = l.product_id
+		LEFT JOIN store_product sp ON sp.upc = l.upc
+		WHERE l.stocktake_id = $1
+		ORDER BY p.product_name, l.upc
+	`
+
+	rows, err := r.db.Query(query, stocktakeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.StocktakeLine
+	for rows.Next() {
+		var line models.StocktakeLine
+		err := rows.Scan(
+			&line.UPC,
+			&line.ProductID,
+			&line.ProductName,
+			&line.PromotionalProduct,
+			&line.Counted,
+			&line.SystemStock,
+			&line.Variance,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
@@ -2,9 +2,11 @@ package repos
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 
+	"github.com/lib/pq"
 	"github.com/velosypedno/zlagoda/internal/models"
 	"github.com/velosypedno/zlagoda/internal/utils"
 )
@@ -42,16 +44,24 @@ func NewStoreProductRepo(db *sql.DB) *StoreProductRepo {
 	}
 }
 
-func (r *StoreProductRepo) CreateStoreProduct(sp models.StoreProductCreate) (string, error) {
+// CreateStoreProduct inserts the store product and records its initial
+// stock as movement m and its first batch; m's UPC and Delta are filled in.
+func (r *StoreProductRepo) CreateStoreProduct(sp models.StoreProductCreate, m models.StockMovementCreate) (string, error) {
 	query := `
-		INSERT INTO store_product (
-			upc,
-			upc_prom,
-			product_id,
-			selling_price,
-			products_number,
-			promotional_product
-		) VALUES ($1, $2, $3, $4, $5, $6)
+		WITH created AS (
+			INSERT INTO store_product (
+				upc,
+				upc_prom,
+				product_id,
+				selling_price,
+				products_number,
+				promotional_product
+			) VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING upc, products_number
+		)
+		INSERT INTO stock_movement (upc, kind, delta, balance, employee_id, reason, reference)
+		SELECT upc, $7, products_number, products_number, $8, $9, $10
+		FROM created
 		RETURNING upc
 	`
 
@@ -59,15 +69,27 @@ func (r *StoreProductRepo) CreateStoreProduct(sp models.StoreProductCreate) (str
 	if err != nil {
 		return "", err
 	}
-	err = r.db.QueryRow(
-		query,
-		upc,
-		sp.UPCProm,
-		sp.ProductID,
-		sp.SellingPrice,
-		sp.ProductsNumber,
-		sp.PromotionalProduct,
-	).Scan(&upc)
+	err = withTx(r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRow(
+			query,
+			upc,
+			sp.UPCProm,
+			sp.ProductID,
+			sp.SellingPrice,
+			sp.ProductsNumber,
+			sp.PromotionalProduct,
+			m.Kind,
+			m.EmployeeId,
+			m.Reason,
+			m.Reference,
+		).Scan(&upc)
+		if err != nil {
+			return err
+		}
+		m.UPC = upc
+		m.Delta = sp.ProductsNumber
+		return afterStockChange(tx, m, nil)
+	})
 
 	return upc, err
 }
@@ -268,7 +290,9 @@ func (r *StoreProductRepo) RetrievePromotionalProducts() ([]models.StoreProductR
 	return storeProducts, nil
 }
 
-func (r *StoreProductRepo) UpdateStoreProduct(upc string, sp models.StoreProductUpdate) error {
+// UpdateStoreProduct changes the given fields. A change to products_number is
+// recorded as movement m, whose UPC and Delta are filled in from the row.
+func (r *StoreProductRepo) UpdateStoreProduct(upc string, sp models.StoreProductUpdate, m models.StockMovementCreate) error {
 	setParts := []string{}
 	args := []interface{}{}
 	argIndex := 1
@@ -307,16 +331,55 @@ func (r *StoreProductRepo) UpdateStoreProduct(upc string, sp models.StoreProduct
 		return nil
 	}
 
-	query := fmt.Sprintf(`
-		UPDATE store_product
-		SET %s
-		WHERE upc = $%d
-	`, strings.Join(setParts, ", "), argIndex)
+	if sp.ProductsNumber == nil {
+		query := fmt.Sprintf(`
+			UPDATE store_product
+			SET %s
+			WHERE upc = $%d
+		`, strings.Join(setParts, ", "), argIndex)
 
-	args = append(args, upc)
+		args = append(args, upc)
 
-	_, err := r.db.Exec(query, args...)
-	return err
+		_, err := r.db.Exec(query, args...)
+		return err
+	}
+
+	// The row is locked before it is read, so the recorded delta is taken
+	// from the stock the update replaces
+	query := fmt.Sprintf(`
+		WITH current AS (
+			SELECT upc, products_number
+			FROM store_product
+			WHERE upc = $%[2]d
+			FOR UPDATE
+		), updated AS (
+			UPDATE store_product
+			SET %[1]s
+			WHERE upc = $%[2]d
+			RETURNING upc, products_number
+		)
+		INSERT INTO stock_movement (upc, kind, delta, balance, employee_id, reason, reference)
+		SELECT u.upc, $%[3]d, u.products_number - c.products_number, u.products_number, $%[4]d, $%[5]d, $%[6]d
+		FROM updated u
+		JOIN current c ON c.upc = u.upc
+		WHERE u.products_number <> c.products_number
+		RETURNING delta
+	`, strings.Join(setParts, ", "), argIndex, argIndex+1, argIndex+2, argIndex+3, argIndex+4)
+
+	args = append(args, upc, m.Kind, m.EmployeeId, m.Reason, m.Reference)
+
+	return withTx(r.db, func(tx *sql.Tx) error {
+		err := tx.QueryRow(query, args...).Scan(&m.Delta)
+		if errors.Is(err, sql.ErrNoRows) {
+			// The stock did not change
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		m.UPC = upc
+		return afterStockChange(tx, m, nil)
+	})
 }
 
 func (r *StoreProductRepo) DeleteStoreProduct(upc string) error {
@@ -325,13 +388,41 @@ func (r *StoreProductRepo) DeleteStoreProduct(upc string) error {
 	return err
 }
 
-func (r *StoreProductRepo) UpdateProductQuantity(upc string, quantityChange int) error {
-	query := `
-		UPDATE store_product
-		SET products_number = products_number + $2
-		WHERE upc = $1 AND products_number + $2 >= 0
-	`
-	result, err := r.db.Exec(query, upc, quantityChange)
+func (r *StoreProductRepo) UpdateProductQuantity(m models.StockMovementCreate) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		return updateProductQuantity(tx, m, true)
+	})
+}
+
+func (r *StoreProductRepo) UpdateProductQuantityTx(tx *sql.Tx, m models.StockMovementCreate) error {
+	return updateProductQuantity(tx, m, true)
+}
+
+// UpdateProductQuantityUncheckedTx changes the stock without the check that
+// keeps it from going negative, for sales that have already happened.
+func (r *StoreProductRepo) UpdateProductQuantityUncheckedTx(tx *sql.Tx, m models.StockMovementCreate) error {
+	return updateProductQuantity(tx, m, false)
+}
+
+// updateProductQuantity adds m.Delta to the stock and records m with the
+// resulting balance in the same statement, then updates the UPC's batches.
+func updateProductQuantity(q dbtx, m models.StockMovementCreate, checked bool) error {
+	condition := "upc = $1"
+	if checked {
+		condition += " AND products_number + $2 >= 0"
+	}
+	query := fmt.Sprintf(`
+		WITH updated AS (
+			UPDATE store_product
+			SET products_number = products_number + $2
+			WHERE %s
+			RETURNING upc, products_number
+		)
+		INSERT INTO stock_movement (upc, kind, delta, balance, employee_id, reason, reference)
+		SELECT upc, $3, $2, products_number, $4, $5, $6
+		FROM updated
+	`, condition)
+	result, err := q.Exec(query, m.UPC, m.Delta, m.Kind, m.EmployeeId, m.Reason, m.Reference)
 	if err != nil {
 		return err
 	}
@@ -342,10 +433,22 @@ func (r *StoreProductRepo) UpdateProductQuantity(upc string, quantityChange int)
 	}
 
 	if rowsAffected == 0 {
+		if !checked {
+			return sql.ErrNoRows
+		}
 		return fmt.Errorf("insufficient stock or product not found")
 	}
 
-	return nil
+	return afterStockChange(q, m, nil)
+}
+
+// afterStockChange brings the batches and the low-stock alert of m.UPC in
+// step with a stock change that has just been written.
+func afterStockChange(q dbtx, m models.StockMovementCreate, expiryDate *string) error {
+	if err := applyStockBatches(q, m, expiryDate); err != nil {
+		return err
+	}
+	return applyStockAlerts(q, m)
 }
 
 func (r *StoreProductRepo) CheckStockAvailability(upc string, requiredQuantity int) (bool, error) {
@@ -360,6 +463,103 @@ func (r *StoreProductRepo) CheckStockAvailability(upc string, requiredQuantity i
 	return currentStock >= requiredQuantity, nil
 }
 
+// LockStoreProductsTx selects the given store products with FOR UPDATE so that
+// concurrent checkouts touching the same UPCs are serialized until tx ends.
+// Rows are locked in UPC order to avoid deadlocks between transactions.
+// UPCs that do not exist are simply absent from the returned map.
+func (r *StoreProductRepo) LockStoreProductsTx(tx *sql.Tx, upcs []string) (map[string]models.StoreProductRetrieve, error) {
+	return lockStoreProducts(tx, `upc = ANY($1)`, upcs)
+}
+
+// LockStoreProductsWithPromotionsTx locks the given store products like
+// LockStoreProductsTx, together with the UPCs their upc_prom links to, in
+// one statement so the UPC order holds across all of them.
+func (r *StoreProductRepo) LockStoreProductsWithPromotionsTx(tx *sql.Tx, upcs []string) (map[string]models.StoreProductRetrieve, error) {
+	return lockStoreProducts(tx, `upc = ANY($1) OR upc IN (SELECT upc_prom FROM store_product WHERE upc = ANY($1))`, upcs)
+}
+
+func lockStoreProducts(tx *sql.Tx, condition string, upcs []string) (map[string]models.StoreProductRetrieve, error) {
+	query := `
+		SELECT
+			upc,
+			upc_prom,
+			product_id,
+			selling_price,
+			products_number,
+			promotional_product
+		FROM store_product
+		WHERE ` + condition + `
+		ORDER BY upc
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(query, pq.Array(upcs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	storeProducts := make(map[string]models.StoreProductRetrieve, len(upcs))
+	for rows.Next() {
+		var storeProduct models.StoreProductRetrieve
+		err := rows.Scan(
+			&storeProduct.UPC,
+			&storeProduct.UPCProm,
+			&storeProduct.ProductID,
+			&storeProduct.SellingPrice,
+			&storeProduct.ProductsNumber,
+			&storeProduct.PromotionalProduct,
+		)
+		if err != nil {
+			return nil, err
+		}
+		storeProducts[storeProduct.UPC] = storeProduct
+	}
+
+	return storeProducts, rows.Err()
+}
+
+func (r *StoreProductRepo) RetrieveVATRates(upcs []string) (map[string]models.Rate, error) {
+	return retrieveVATRates(r.db, upcs)
+}
+
+func (r *StoreProductRepo) RetrieveVATRatesTx(tx *sql.Tx, upcs []string) (map[string]models.Rate, error) {
+	return retrieveVATRates(tx, upcs)
+}
+
+// retrieveVATRates resolves the VAT rate of each UPC from its product, then
+// its category. UPCs where neither sets a rate are absent from the map.
+func retrieveVATRates(q dbtx, upcs []string) (map[string]models.Rate, error) {
+	query := `
+		SELECT
+			sp.upc,
+			COALESCE(p.vat_rate, c.vat_rate)
+		FROM store_product sp
+		JOIN product p ON sp.product_id = p.product_id
+		JOIN category c ON p.category_id = c.category_id
+		WHERE sp.upc = ANY($1)
+		AND COALESCE(p.vat_rate, c.vat_rate) IS NOT NULL
+	`
+
+	rows, err := q.Query(query, pq.Array(upcs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rates := make(map[string]models.Rate, len(upcs))
+	for rows.Next() {
+		var upc string
+		var rate models.Rate
+		if err := rows.Scan(&upc, &rate); err != nil {
+			return nil, err
+		}
+		rates[upc] = rate
+	}
+
+	return rates, rows.Err()
+}
+
 func (r *StoreProductRepo) RetrieveStoreProductsByCategory(categoryID int) ([]models.StoreProductWithDetails, error) {
 	query := `
 		SELECT
@@ -456,21 +656,41 @@ func (r *StoreProductRepo) RetrieveStoreProductsByName(name string) ([]models.St
 	return storeProducts, nil
 }
 
-func (r *StoreProductRepo) UpdateProductDelivery(upc string, quantityChange int, newPrice *float64) error {
+func (r *StoreProductRepo) UpdateProductDelivery(m models.StockMovementCreate, newPrice *models.Money, expiryDate *string) error {
+	return withTx(r.db, func(tx *sql.Tx) error {
+		return updateProductDelivery(tx, m, newPrice, expiryDate)
+	})
+}
+
+func (r *StoreProductRepo) UpdateProductDeliveryTx(tx *sql.Tx, m models.StockMovementCreate, newPrice *models.Money, expiryDate *string) error {
+	return updateProductDelivery(tx, m, newPrice, expiryDate)
+}
+
+// updateProductDelivery adds the delivered units m.Delta to the stock and
+// optionally sets a new selling price, recording m with the resulting
+// balance. The units form a batch expiring on expiryDate, a YYYY-MM-DD day
+// or nil when unknown.
+func updateProductDelivery(q dbtx, m models.StockMovementCreate, newPrice *models.Money, expiryDate *string) error {
 	setParts := []string{"products_number = products_number + $2"}
-	args := []interface{}{upc, quantityChange}
-	argIndex := 3
+	args := []interface{}{m.UPC, m.Delta, m.Kind, m.EmployeeId, m.Reason, m.Reference}
+	argIndex := 7
 	if newPrice != nil {
 		setParts = append(setParts, fmt.Sprintf("selling_price = $%d", argIndex))
 		args = append(args, *newPrice)
 		argIndex++
 	}
 	query := fmt.Sprintf(`
-		UPDATE store_product
-		SET %s
-		WHERE upc = $1 AND products_number + $2 >= 0
+		WITH updated AS (
+			UPDATE store_product
+			SET %s
+			WHERE upc = $1 AND products_number + $2 >= 0
+			RETURNING upc, products_number
+		)
+		INSERT INTO stock_movement (upc, kind, delta, balance, employee_id, reason, reference)
+		SELECT upc, $3, $2, products_number, $4, $5, $6
+		FROM updated
 	`, strings.Join(setParts, ", "))
-	result, err := r.db.Exec(query, args...)
+	result, err := q.Exec(query, args...)
 	if err != nil {
 		return err
 	}
@@ -481,5 +701,5 @@ func (r *StoreProductRepo) UpdateProductDelivery(upc string, quantityChange int,
 	if rowsAffected == 0 {
 		return fmt.Errorf("insufficient stock or product not found")
 	}
-	return nil
+	return afterStockChange(q, m, expiryDate)
 }
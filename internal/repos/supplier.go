@@ -0,0 +1,130 @@
+package repos
+
+import (
+	"database/sql"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type SupplierRepo struct {
+	db *sql.DB
+}
+
+func NewSupplierRepo(db *sql.DB) *SupplierRepo {
+	return &SupplierRepo{
+		db: db,
+	}
+}
+
+func (r *SupplierRepo) CreateSupplier(s models.SupplierCreate) (int, error) {
+	query := `
+		INSERT INTO supplier (
+			supplier_name,
+			contact_name,
+			phone_number,
+			email,
+			city,
+			street,
+			zip_code
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING supplier_id
+	`
+
+	var supplierID int
+	err := r.db.QueryRow(
+		query,
+		s.Name,
+		s.ContactName,
+		s.PhoneNumber,
+		s.Email,
+		s.City,
+		s.Street,
+		s.ZipCode,
+	).Scan(&supplierID)
+
+	return supplierID, err
+}
+
+const supplierColumns = `
+			supplier_id,
+			supplier_name,
+			contact_name,
+			phone_number,
+			email,
+			city,
+			street,
+			zip_code
+`
+
+func scanSupplier(row interface{ Scan(...any) error }) (models.SupplierRetrieve, error) {
+	var supplier models.SupplierRetrieve
+	err := row.Scan(
+		&supplier.SupplierID,
+		&supplier.Name,
+		&supplier.ContactName,
+		&supplier.PhoneNumber,
+		&supplier.Email,
+		&supplier.City,
+		&supplier.Street,
+		&supplier.ZipCode,
+	)
+	return supplier, err
+}
+
+func (r *SupplierRepo) RetrieveSupplierByID(supplierID int) (models.SupplierRetrieve, error) {
+	query := `SELECT` + supplierColumns + `FROM supplier WHERE supplier_id = $1`
+	return scanSupplier(r.db.QueryRow(query, supplierID))
+}
+
+func (r *SupplierRepo) RetrieveSuppliers() ([]models.SupplierRetrieve, error) {
+	query := `SELECT` + supplierColumns + `FROM supplier ORDER BY supplier_name, supplier_id`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var suppliers []models.SupplierRetrieve
+	for rows.Next() {
+		supplier, err := scanSupplier(rows)
+		if err != nil {
+			return nil, err
+		}
+		suppliers = append(suppliers, supplier)
+	}
+
+	return suppliers, rows.Err()
+}
+
+func (r *SupplierRepo) UpdateSupplier(supplierID int, s models.SupplierUpdate) error {
+	query := `
+		UPDATE supplier
+		SET
+			supplier_name = $2,
+			contact_name = $3,
+			phone_number = $4,
+			email = $5,
+			city = $6,
+			street = $7,
+			zip_code = $8
+		WHERE supplier_id = $1
+	`
+	_, err := r.db.Exec(
+		query,
+		supplierID,
+		s.Name,
+		s.ContactName,
+		s.PhoneNumber,
+		s.Email,
+		s.City,
+		s.Street,
+		s.ZipCode,
+	)
+	return err
+}
+
+func (r *SupplierRepo) DeleteSupplier(supplierID int) error {
+	query := `DELETE FROM supplier WHERE supplier_id = $1`
+	_, err := r.db.Exec(query, supplierID)
+	return err
+}
@@ -0,0 +1,26 @@
+package repos
+
+import "database/sql"
+
+// dbtx is satisfied by both *sql.DB and *sql.Tx, so the same query code can
+// run on its own or as part of a caller-managed transaction.
+type dbtx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// withTx runs f in a transaction of its own, for repo methods whose
+// statements must apply together.
+func withTx(db *sql.DB, f func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
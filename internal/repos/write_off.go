@@ -0,0 +1,207 @@
+package repos
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type WriteOffRepo struct {
+	db *sql.DB
+}
+
+func NewWriteOffRepo(db *sql.DB) *WriteOffRepo {
+	return &WriteOffRepo{
+		db: db,
+	}
+}
+
+func (r *WriteOffRepo) BeginTx() (*sql.Tx, error) {
+	return r.db.Begin()
+}
+
+func (r *WriteOffRepo) CreateWriteOffTx(tx *sql.Tx, c models.WriteOffCreate) (int, error) {
+	query := `
+		INSERT INTO write_off (reason, note, employee_id, written_off_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING write_off_id
+	`
+	var writeOffID int
+	err := tx.QueryRow(query, c.Reason, c.Note, c.EmployeeId, c.WrittenOffAt).Scan(&writeOffID)
+	return writeOffID, err
+}
+
+func (r *WriteOffRepo) CreateWriteOffLineTx(tx *sql.Tx, writeOffID int, l models.WriteOffLineRecord) error {
+	query := `
+		INSERT INTO write_off_line (
+			write_off_id,
+			line_no,
+			upc,
+			product_id,
+			quantity,
+			unit_price
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	`
+	_, err := tx.Exec(query, writeOffID, l.LineNo, l.UPC, l.ProductID, l.Quantity, l.UnitPrice)
+	return err
+}
+
+const writeOffColumns = `
+			w.write_off_id,
+			w.reason,
+			w.note,
+			w.employee_id,
+			w.written_off_at,
+			COALESCE(t.quantity, 0),
+			COALESCE(t.value, 0)
+		FROM write_off w
+		LEFT JOIN (
+			SELECT write_off_id, SUM(quantity) AS quantity, SUM(quantity * unit_price) AS value
+			FROM write_off_line
+			GROUP BY write_off_id
+		) t ON t.write_off_id = w.write_off_id
+`
+
+func scanWriteOff(row interface{ Scan(...any) error }) (models.WriteOffRetrieve, error) {
+	var writeOff models.WriteOffRetrieve
+	err := row.Scan(
+		&writeOff.WriteOffID,
+		&writeOff.Reason,
+		&writeOff.Note,
+		&writeOff.EmployeeId,
+		&writeOff.WrittenOffAt,
+		&writeOff.Quantity,
+		&writeOff.Value,
+	)
+	return writeOff, err
+}
+
+func (r *WriteOffRepo) RetrieveWriteOffByID(writeOffID int) (models.WriteOffRetrieve, error) {
+	query := `SELECT` + writeOffColumns + `WHERE w.write_off_id = $1`
+	return scanWriteOff(r.db.QueryRow(query, writeOffID))
+}
+
+// RetrieveWriteOffs lists write-offs matching f, latest first.
+func (r *WriteOffRepo) RetrieveWriteOffs(f models.WriteOffFilter) ([]models.WriteOffRetrieve, error) {
+	query := `SELECT` + writeOffColumns + `
+		WHERE ($1 = '' OR w.reason = $1)
+			AND ($2::timestamptz IS NULL OR w.written_off_at >= $2)
+			AND ($3::timestamptz IS NULL OR w.written_off_at < $3)
+		ORDER BY w.written_off_at DESC, w.write_off_id DESC
+	`
+
+	rows, err := r.db.Query(query, f.Reason, f.From, f.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var writeOffs []models.WriteOffRetrieve
+	for rows.Next() {
+		writeOff, err := scanWriteOff(rows)
+		if err != nil {
+			return nil, err
+		}
+		writeOffs = append(writeOffs, writeOff)
+	}
+
+	return writeOffs, rows.Err()
+}
+
+// RetrieveWriteOffLines returns the lines of the given write-offs, ordered
+// by write-off and line number.
+func (r *WriteOffRepo) RetrieveWriteOffLines(writeOffIDs []int) ([]models.WriteOffLine, error) {
+	query := `
+		SELECT
+			l.write_off_id,
+			l.line_no,
+			l.upc,
+			l.product_id,
+			p.product_name,
+			c.category_name,
+			l.quantity,
+			l.unit_price,
+			l.quantity * l.unit_price
+		FROM write_off_line l
+		JOIN product p ON p.product_id = l.product_id
+		JOIN category c ON c.category_id = p.category_id
+		WHERE l.write_off_id = ANY($1)
+		ORDER BY l.write_off_id, l.line_no
+	`
+
+	rows, err := r.db.Query(query, pq.Array(writeOffIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []models.WriteOffLine
+	for rows.Next() {
+		var line models.WriteOffLine
+		err := rows.Scan(
+			&line.WriteOffID,
+			&line.LineNo,
+			&line.UPC,
+			&line.ProductID,
+			&line.ProductName,
+			&line.CategoryName,
+			&line.Quantity,
+			&line.UnitPrice,
+			&line.Value,
+		)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+// RetrieveWriteOffReport sums the write-off lines from from up to to by the
+// product's category and the reason.
+func (r *WriteOffRepo) RetrieveWriteOffReport(from, to time.Time) ([]models.WriteOffReportRow, error) {
+	query := `
+		SELECT
+			c.category_id,
+			c.category_name,
+			w.reason,
+			COUNT(DISTINCT w.write_off_id),
+			SUM(l.quantity),
+			SUM(l.quantity * l.unit_price)
+		FROM write_off w
+		JOIN write_off_line l ON l.write_off_id = w.write_off_id
+		JOIN product p ON p.product_id = l.product_id
+		JOIN category c ON c.category_id = p.category_id
+		WHERE w.written_off_at >= $1 AND w.written_off_at < $2
+		GROUP BY c.category_id, w.reason
+		ORDER BY c.category_name, w.reason
+	`
+
+	rows, err := r.db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var report []models.WriteOffReportRow
+	for rows.Next() {
+		var row models.WriteOffReportRow
+		err := rows.Scan(
+			&row.CategoryID,
+			&row.CategoryName,
+			&row.Reason,
+			&row.WriteOffs,
+			&row.Quantity,
+			&row.Value,
+		)
+		if err != nil {
+			return nil, err
+		}
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
@@ -1,22 +1,38 @@
 package server
 
 import (
+	"reflect"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/velosypedno/zlagoda/internal/config"
 	"github.com/velosypedno/zlagoda/internal/ioc"
 	"github.com/velosypedno/zlagoda/internal/middleware"
+	"github.com/velosypedno/zlagoda/internal/models"
 )
 
+// moneyValue lets binding tags such as gte=0 compare models.Money amounts.
+func moneyValue(field reflect.Value) interface{} {
+	if money, ok := field.Interface().(models.Money); ok {
+		return money.Float64()
+	}
+	return nil
+}
+
 func SetupRoutes(c *ioc.HandlerContainer, cfg *config.Config) *gin.Engine {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterCustomTypeFunc(moneyValue, models.Money{})
+	}
+
 	router := gin.Default()
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "PATCH"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.IdempotencyKeyHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -33,6 +49,8 @@ func SetupRoutes(c *ioc.HandlerContainer, cfg *config.Config) *gin.Engine {
 		api.GET("/categories/:id", c.CategoryRetrieveGETHandler)
 		api.DELETE("/categories/:id", c.CategoryDeleteDELETEHandler)
 		api.PATCH("/categories/:id", c.CategoryUpdatePATCHHandler)
+		api.PUT("/categories/:id/vat-rate", c.CategoryVATRatePUTHandler)
+		api.DELETE("/categories/:id/vat-rate", c.CategoryVATRateDELETEHandler)
 
 		api.POST("/customer-cards", c.CustomerCardCreatePOSTHandler)
 		api.GET("/customer-cards", c.CustomerCardsListGETHandler)
@@ -47,12 +65,44 @@ func SetupRoutes(c *ioc.HandlerContainer, cfg *config.Config) *gin.Engine {
 		api.DELETE("/employees/:id", c.EmployeeDeleteDELETEHandler)
 		api.PATCH("/employees/:id", c.EmployeeUpdatePATCHHandler)
 
-		api.POST("/receipts", c.ReceiptCreatePOSTHandler)
-		api.POST("/receipts/complete", c.ReceiptCreateCompletePOSTHandler)
+		api.POST("/receipts", c.IdempotencyMiddleware, c.ReceiptCreatePOSTHandler)
+		api.POST("/receipts/complete", c.IdempotencyMiddleware, c.ReceiptCreateCompletePOSTHandler)
+		api.POST("/receipts/sync", c.ReceiptSyncPOSTHandler)
+		api.GET("/receipts/sync/conflicts", c.ReceiptSyncConflictsGETHandler)
+		api.GET("/receipts/sync/tender-mismatches", c.ReceiptSyncTenderMismatchesGETHandler)
 		api.GET("/receipts", c.ReceiptsListGETHandler)
 		api.GET("/receipts/:receipt_number", c.ReceiptRetrieveGETHandler)
+		api.GET("/receipts/:receipt_number/pdf", c.ReceiptRetrieveGETHandler)
 		api.DELETE("/receipts/:receipt_number", c.ReceiptDeleteDELETEHandler)
 		api.PATCH("/receipts/:receipt_number", c.ReceiptUpdatePATCHHandler)
+		api.POST("/receipts/:receipt_number/void", c.ReceiptVoidPOSTHandler)
+		api.GET("/receipts/:receipt_number/print", c.ReceiptPrintGETHandler)
+		api.GET("/receipts/:receipt_number/payments", c.PaymentsByReceiptGETHandler)
+
+		api.GET("/payments/tender-totals", c.TenderTotalsGETHandler)
+
+		api.POST("/shifts", c.ShiftOpenPOSTHandler)
+		api.GET("/shifts", c.ShiftsListGETHandler)
+		api.GET("/shifts/current", c.ShiftCurrentGETHandler)
+		api.GET("/shifts/:id", c.ShiftRetrieveGETHandler)
+		api.GET("/shifts/:id/x-report", c.ShiftXReportGETHandler)
+		api.POST("/shifts/:id/close", c.ShiftClosePOSTHandler)
+		api.GET("/shifts/:id/z-report", c.ShiftZReportGETHandler)
+
+		api.POST("/returns", c.ReturnCreatePOSTHandler)
+		api.GET("/returns", c.ReturnsListGETHandler)
+		api.GET("/returns/by-receipt/:receipt_number", c.ReturnsByReceiptGETHandler)
+		api.GET("/returns/:return_number", c.ReturnRetrieveGETHandler)
+
+		api.POST("/carts", c.CartCreatePOSTHandler)
+		api.GET("/carts/:cart_id", c.CartRetrieveGETHandler)
+		api.DELETE("/carts/:cart_id", c.CartCancelDELETEHandler)
+		api.POST("/carts/:cart_id/items", c.CartItemAddPOSTHandler)
+		api.PUT("/carts/:cart_id/items/:upc", c.CartItemUpdatePUTHandler)
+		api.DELETE("/carts/:cart_id/items/:upc", c.CartItemDeleteDELETEHandler)
+		api.PUT("/carts/:cart_id/card", c.CartCardPUTHandler)
+		api.DELETE("/carts/:cart_id/card", c.CartCardDeleteDELETEHandler)
+		api.POST("/carts/:cart_id/finalize", c.CartFinalizePOSTHandler)
 
 		api.POST("/products", c.ProductCreatePOSTHandler)
 		api.GET("/products", c.ProductsListGETHandler)
@@ -61,6 +111,11 @@ func SetupRoutes(c *ioc.HandlerContainer, cfg *config.Config) *gin.Engine {
 		api.GET("/products/:id", c.ProductRetrieveGETHandler)
 		api.DELETE("/products/:id", c.ProductDeleteDELETEHandler)
 		api.PATCH("/products/:id", c.ProductUpdatePATCHHandler)
+		api.PUT("/products/:id/vat-rate", c.ProductVATRatePUTHandler)
+		api.DELETE("/products/:id/vat-rate", c.ProductVATRateDELETEHandler)
+		api.GET("/products/:id/reorder-levels", c.ReorderLevelsGETHandler)
+		api.PUT("/products/:id/reorder-levels", c.ReorderLevelsPUTHandler)
+		api.DELETE("/products/:id/reorder-levels", c.ReorderLevelsDELETEHandler)
 
 		api.POST("/store-products", c.StoreProductCreatePOSTHandler)
 		api.GET("/store-products", c.StoreProductsListGETHandler)
@@ -68,6 +123,7 @@ func SetupRoutes(c *ioc.HandlerContainer, cfg *config.Config) *gin.Engine {
 		api.GET("/store-products/search", c.StoreProductsByNameGETHandler)
 		api.GET("/store-products/by-category/:category_id", c.StoreProductsByCategoryGETHandler)
 		api.GET("/store-products/promotional", c.PromotionalProductsGETHandler)
+		api.GET("/store-products/low-stock", c.LowStockGETHandler)
 		api.GET("/store-products/by-product/:product_id", c.StoreProductsByProductIDGETHandler)
 		api.GET("/store-products/:upc", c.StoreProductRetrieveGETHandler)
 		api.DELETE("/store-products/:upc", c.StoreProductDeleteDELETEHandler)
@@ -75,8 +131,46 @@ func SetupRoutes(c *ioc.HandlerContainer, cfg *config.Config) *gin.Engine {
 		api.PATCH("/store-products/:upc/quantity", c.StoreProductQuantityUpdatePATCHHandler)
 		api.GET("/store-products/:upc/stock-check", c.StoreProductStockCheckGETHandler)
 		api.PATCH("/store-products/:upc/delivery", c.StoreProductDeliveryPATCHHandler)
+		api.GET("/store-products/:upc/movements", c.StockMovementsByUPCGETHandler)
+		api.GET("/store-products/:upc/batches", c.StockBatchesByUPCGETHandler)
+		api.GET("/stock-movements/verify", c.StockVerifyGETHandler)
+		api.GET("/stock-batches/expiring", c.ExpiringBatchesGETHandler)
+		api.GET("/stock-alerts", c.StockAlertsGETHandler)
+		api.POST("/stock-alerts/:id/resolve", c.StockAlertResolvePOSTHandler)
+
+		api.POST("/suppliers", c.SupplierCreatePOSTHandler)
+		api.GET("/suppliers", c.SuppliersListGETHandler)
+		api.GET("/suppliers/:id", c.SupplierRetrieveGETHandler)
+		api.PATCH("/suppliers/:id", c.SupplierUpdatePATCHHandler)
+		api.DELETE("/suppliers/:id", c.SupplierDeleteDELETEHandler)
+
+		api.POST("/purchase-orders", c.PurchaseOrderCreatePOSTHandler)
+		api.GET("/purchase-orders", c.PurchaseOrdersListGETHandler)
+		api.GET("/purchase-orders/:id", c.PurchaseOrderRetrieveGETHandler)
+		api.PATCH("/purchase-orders/:id", c.PurchaseOrderUpdatePATCHHandler)
+		api.POST("/purchase-orders/:id/send", c.PurchaseOrderSendPOSTHandler)
+		api.POST("/purchase-orders/:id/cancel", c.PurchaseOrderCancelPOSTHandler)
+		api.POST("/purchase-orders/:id/receive", c.PurchaseOrderReceivePOSTHandler)
+
+		api.POST("/goods-received", c.GoodsReceivedCreatePOSTHandler)
+		api.GET("/goods-received", c.GoodsReceivedListGETHandler)
+		api.GET("/goods-received/:id", c.GoodsReceivedRetrieveGETHandler)
+		api.POST("/goods-received/:id/reverse", c.GoodsReceivedReversePOSTHandler)
 
-		api.POST("/sales", c.SaleCreatePOSTHandler)
+		api.POST("/stocktakes", c.StocktakeCreatePOSTHandler)
+		api.GET("/stocktakes", c.StocktakesListGETHandler)
+		api.GET("/stocktakes/:id", c.StocktakeRetrieveGETHandler)
+		api.POST("/stocktakes/:id/counts", c.StocktakeCountsPOSTHandler)
+		api.DELETE("/stocktakes/:id/counts/:count_id", c.StocktakeCountDELETEHandler)
+		api.POST("/stocktakes/:id/approve", c.StocktakeApprovePOSTHandler)
+		api.POST("/stocktakes/:id/cancel", c.StocktakeCancelPOSTHandler)
+
+		api.POST("/write-offs", c.WriteOffCreatePOSTHandler)
+		api.GET("/write-offs", c.WriteOffsListGETHandler)
+		api.GET("/write-offs/report", c.WriteOffReportGETHandler)
+		api.GET("/write-offs/:id", c.WriteOffRetrieveGETHandler)
+
+		api.POST("/sales", c.IdempotencyMiddleware, c.SaleCreatePOSTHandler)
 		api.GET("/sales", c.SalesListGETHandler)
 		api.GET("/sales/details", c.SalesWithDetailsListGETHandler)
 		api.GET("/sales/top-products", c.TopSellingProductsGETHandler)
@@ -91,12 +185,21 @@ func SetupRoutes(c *ioc.HandlerContainer, cfg *config.Config) *gin.Engine {
 
 		api.GET("/receipts/:receipt_number/total", c.ReceiptTotalGETHandler)
 
+		api.GET("/admin/receipt-totals", c.ReceiptTotalsMismatchesGETHandler)
+		api.POST("/admin/receipt-totals/repair", c.ReceiptTotalsRepairPOSTHandler)
+
 		api.GET("/vlad1", c.Vlad1GETHandler)
+		api.GET("/vlad1/pdf", c.Vlad1GETHandler)
 		api.GET("/vlad2", c.Vlad2GETHandler)
+		api.GET("/vlad2/pdf", c.Vlad2GETHandler)
 		api.GET("/arthur1", c.Arthur1GETHandler)
+		api.GET("/arthur1/pdf", c.Arthur1GETHandler)
 		api.GET("/arthur2", c.Arthur2GETHandler)
+		api.GET("/arthur2/pdf", c.Arthur2GETHandler)
 		api.GET("/oleksii1", c.Oleksii1GETHandler)
+		api.GET("/oleksii1/pdf", c.Oleksii1GETHandler)
 		api.GET("/oleksii2", c.Oleksii2GETHandler)
+		api.GET("/oleksii2/pdf", c.Oleksii2GETHandler)
 	}
 	return router
 }
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrCartNotOpen      = errors.New("cart is not open")
+	ErrCartExpired      = errors.New("cart has expired")
+	ErrCartEmpty        = errors.New("cart is empty")
+	ErrCartUnknownUPC   = errors.New("store product not found")
+	ErrCartUnknownCard  = errors.New("customer card not found")
+	ErrCartItemNotFound = errors.New("UPC is not in the cart")
+)
+
+type CartRepo interface {
+	BeginTx() (*sql.Tx, error)
+	CreateCart(c models.CartCreate) (string, error)
+	RetrieveCartByID(cartID string) (models.CartRetrieve, error)
+	RetrieveCartForUpdateTx(tx *sql.Tx, cartID string) (models.CartRetrieve, error)
+	RetrieveCartItems(cartID string) ([]models.CartItem, error)
+	RetrieveCartItemsTx(tx *sql.Tx, cartID string) ([]models.CartItem, error)
+	AddCartItemTx(tx *sql.Tx, cartID, upc string, quantity int, addedAt time.Time) error
+	SetCartItemQuantityTx(tx *sql.Tx, cartID, upc string, quantity int, addedAt time.Time) error
+	DeleteCartItemTx(tx *sql.Tx, cartID, upc string) error
+	SetCartCardTx(tx *sql.Tx, cartID string, cardNumber *string) error
+	TouchCartTx(tx *sql.Tx, cartID string, updatedAt, expiresAt time.Time) error
+	CloseCartTx(tx *sql.Tx, cartID string, status string, receiptNumber *string, updatedAt time.Time) error
+	ExpireCarts(now time.Time) (int64, error)
+}
+
+type CartCustomerCardRepo interface {
+	RetrieveCustomerCardByCardNumber(cardNumber string) (models.CustomerCardRetrieve, error)
+}
+
+type CartStoreProductRepo interface {
+	RetrieveStoreProductByUPC(upc string) (models.StoreProductRetrieve, error)
+	RetrieveVATRates(upcs []string) (map[string]models.Rate, error)
+}
+
+type CartCheckout interface {
+	CreateReceiptCompleteTx(tx *sql.Tx, c models.ReceiptCreateComplete, defaultVATRate models.Rate) (models.ReceiptCompleteResult, error)
+}
+
+// CartService keeps an open receipt at the till while items are scanned. A
+// cart is only turned into a receipt, and only touches stock, on Finalize.
+type CartService struct {
+	cartRepo         CartRepo
+	customerCardRepo CartCustomerCardRepo
+	storeProductRepo CartStoreProductRepo
+	checkout         CartCheckout
+	cfg              *config.Config
+}
+
+func NewCartService(cartRepo CartRepo, customerCardRepo CartCustomerCardRepo, storeProductRepo CartStoreProductRepo, checkout CartCheckout, cfg *config.Config) *CartService {
+	return &CartService{
+		cartRepo:         cartRepo,
+		customerCardRepo: customerCardRepo,
+		storeProductRepo: storeProductRepo,
+		checkout:         checkout,
+		cfg:              cfg,
+	}
+}
+
+func (s *CartService) CreateCart(employeeID string, cardNumber *string) (models.CartView, error) {
+	now := time.Now()
+
+	// Abandoned carts are swept whenever a new one is opened
+	if _, err := s.cartRepo.ExpireCarts(now); err != nil {
+		return models.CartView{}, fmt.Errorf("failed to expire carts: %w", err)
+	}
+
+	if cardNumber != nil {
+		if err := s.checkCard(*cardNumber); err != nil {
+			return models.CartView{}, err
+		}
+	}
+
+	expiresAt := now.Add(s.cfg.CART_TTL)
+	cartID, err := s.cartRepo.CreateCart(models.CartCreate{
+		EmployeeId: &employeeID,
+		CardNumber: cardNumber,
+		CreatedAt:  &now,
+		ExpiresAt:  &expiresAt,
+	})
+	if err != nil {
+		return models.CartView{}, fmt.Errorf("failed to create cart: %w", err)
+	}
+
+	return s.GetCart(cartID)
+}
+
+// GetCart returns the cart with every line priced at current store prices.
+func (s *CartService) GetCart(cartID string) (models.CartView, error) {
+	cart, err := s.cartRepo.RetrieveCartByID(cartID)
+	if err != nil {
+		return models.CartView{}, err
+	}
+	if cart.Status == models.CartStatusOpen && !time.Now().Before(cart.ExpiresAt) {
+		cart.Status = models.CartStatusExpired
+	}
+
+	items, err := s.cartRepo.RetrieveCartItems(cartID)
+	if err != nil {
+		return models.CartView{}, fmt.Errorf("failed to retrieve cart items: %w", err)
+	}
+
+	sold := make([]models.StoreProductRetrieve, 0, len(items))
+	upcs := make([]string, 0, len(items))
+	for _, item := range items {
+		storeProduct := saleStoreProduct(item.StoreProduct, item.Promotion, item.ProductNumber)
+		sold = append(sold, storeProduct)
+		upcs = append(upcs, storeProduct.UPC)
+	}
+	rates, err := s.storeProductRepo.RetrieveVATRates(upcs)
+	if err != nil {
+		return models.CartView{}, fmt.Errorf("failed to resolve VAT rates: %w", err)
+	}
+
+	lines := make([]models.ReceiptLine, 0, len(items))
+	for i, item := range items {
+		vatRate := vatRateFor(rates, sold[i].UPC, s.cfg.VAT_RATE)
+		lines = append(lines, priceLine(sold[i], item.ProductNumber, vatRate))
+	}
+
+	var discountPercent int = 0
+	if cart.CardNumber != nil {
+		card, err := s.customerCardRepo.RetrieveCustomerCardByCardNumber(*cart.CardNumber)
+		if err != nil {
+			return models.CartView{}, fmt.Errorf("failed to retrieve customer card %s: %w", *cart.CardNumber, err)
+		}
+		if card.Percent != nil {
+			discountPercent = *card.Percent
+		}
+	}
+	totals := calculateTotals(lines, discountPercent)
+
+	return models.CartView{
+		CartRetrieve:    cart,
+		Items:           lines,
+		Subtotal:        totals.Subtotal,
+		DiscountPercent: totals.DiscountPercent,
+		DiscountSum:     totals.DiscountSum,
+		TotalSum:        totals.TotalSum,
+		VAT:             totals.VAT,
+		VATBreakdown:    totals.VATBreakdown,
+	}, nil
+}
+
+func (s *CartService) AddItem(cartID, upc string, quantity int) (models.CartView, error) {
+	if err := s.checkUPC(upc); err != nil {
+		return models.CartView{}, err
+	}
+	return s.modify(cartID, func(tx *sql.Tx, now time.Time) error {
+		return s.cartRepo.AddCartItemTx(tx, cartID, upc, quantity, now)
+	})
+}
+
+func (s *CartService) SetItemQuantity(cartID, upc string, quantity int) (models.CartView, error) {
+	if err := s.checkUPC(upc); err != nil {
+		return models.CartView{}, err
+	}
+	return s.modify(cartID, func(tx *sql.Tx, now time.Time) error {
+		return s.cartRepo.SetCartItemQuantityTx(tx, cartID, upc, quantity, now)
+	})
+}
+
+func (s *CartService) RemoveItem(cartID, upc string) (models.CartView, error) {
+	return s.modify(cartID, func(tx *sql.Tx, now time.Time) error {
+		err := s.cartRepo.DeleteCartItemTx(tx, cartID, upc)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: UPC %s is not in the cart", ErrCartItemNotFound, upc)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove UPC %s: %w", upc, err)
+		}
+		return nil
+	})
+}
+
+func (s *CartService) AttachCard(cartID, cardNumber string) (models.CartView, error) {
+	if err := s.checkCard(cardNumber); err != nil {
+		return models.CartView{}, err
+	}
+	return s.modify(cartID, func(tx *sql.Tx, now time.Time) error {
+		return s.cartRepo.SetCartCardTx(tx, cartID, &cardNumber)
+	})
+}
+
+func (s *CartService) DetachCard(cartID string) (models.CartView, error) {
+	return s.modify(cartID, func(tx *sql.Tx, now time.Time) error {
+		return s.cartRepo.SetCartCardTx(tx, cartID, nil)
+	})
+}
+
+func (s *CartService) CancelCart(cartID string) error {
+	tx, err := s.openCartTx(cartID)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.cartRepo.CloseCartTx(tx, cartID, models.CartStatusCancelled, nil, time.Now()); err != nil {
+		return fmt.Errorf("failed to cancel cart: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// FinalizeCart turns the cart into a receipt through the regular checkout,
+// in the same transaction that closes the cart.
+func (s *CartService) FinalizeCart(cartID string, payments []models.PaymentCreate) (models.ReceiptCompleteResult, error) {
+	tx, err := s.openCartTx(cartID)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, err
+	}
+	defer tx.Rollback()
+
+	cart, err := s.cartRepo.RetrieveCartForUpdateTx(tx, cartID)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, err
+	}
+
+	items, err := s.cartRepo.RetrieveCartItemsTx(tx, cartID)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, fmt.Errorf("failed to retrieve cart items: %w", err)
+	}
+	if len(items) == 0 {
+		return models.ReceiptCompleteResult{}, ErrCartEmpty
+	}
+
+	receiptItems := make([]models.ReceiptItem, 0, len(items))
+	for _, item := range items {
+		upc, quantity := item.UPC, item.ProductNumber
+		receiptItems = append(receiptItems, models.ReceiptItem{
+			UPC:           &upc,
+			ProductNumber: &quantity,
+		})
+	}
+
+	now := time.Now()
+	result, err := s.checkout.CreateReceiptCompleteTx(tx, models.ReceiptCreateComplete{
+		EmployeeId: &cart.EmployeeId,
+		CardNumber: cart.CardNumber,
+		PrintDate:  &now,
+		Items:      receiptItems,
+		Payments:   payments,
+	}, s.cfg.VAT_RATE)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, err
+	}
+
+	err = s.cartRepo.CloseCartTx(tx, cartID, models.CartStatusFinalized, &result.ReceiptNumber, now)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, fmt.Errorf("failed to close cart: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ReceiptCompleteResult{}, fmt.Errorf("failed to commit cart: %w", err)
+	}
+
+	return result, nil
+}
+
+// modify runs change against a locked open cart, extends its expiry and
+// returns the repriced cart.
+func (s *CartService) modify(cartID string, change func(tx *sql.Tx, now time.Time) error) (models.CartView, error) {
+	tx, err := s.openCartTx(cartID)
+	if err != nil {
+		return models.CartView{}, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := change(tx, now); err != nil {
+		return models.CartView{}, err
+	}
+
+	if err := s.cartRepo.TouchCartTx(tx, cartID, now, now.Add(s.cfg.CART_TTL)); err != nil {
+		return models.CartView{}, fmt.Errorf("failed to update cart: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.CartView{}, fmt.Errorf("failed to commit cart: %w", err)
+	}
+
+	return s.GetCart(cartID)
+}
+
+// openCartTx begins a transaction holding the lock on an open cart. A cart
+// found past its expiry is marked expired and rejected.
+func (s *CartService) openCartTx(cartID string) (*sql.Tx, error) {
+	tx, err := s.cartRepo.BeginTx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	cart, err := s.cartRepo.RetrieveCartForUpdateTx(tx, cartID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	if cart.Status != models.CartStatusOpen {
+		tx.Rollback()
+		return nil, ErrCartNotOpen
+	}
+
+	now := time.Now()
+	if !now.Before(cart.ExpiresAt) {
+		defer tx.Rollback()
+		if err := s.cartRepo.CloseCartTx(tx, cartID, models.CartStatusExpired, nil, now); err != nil {
+			return nil, fmt.Errorf("failed to expire cart: %w", err)
+		}
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to expire cart: %w", err)
+		}
+		return nil, ErrCartExpired
+	}
+
+	return tx, nil
+}
+
+func (s *CartService) checkUPC(upc string) error {
+	_, err := s.storeProductRepo.RetrieveStoreProductByUPC(upc)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("UPC %s: %w", upc, ErrCartUnknownUPC)
+	}
+	return err
+}
+
+func (s *CartService) checkCard(cardNumber string) error {
+	_, err := s.customerCardRepo.RetrieveCustomerCardByCardNumber(cardNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("card %s: %w", cardNumber, ErrCartUnknownCard)
+	}
+	return err
+}
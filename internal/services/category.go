@@ -8,6 +8,7 @@ type CategoryRepo interface {
 	RetrieveCategories() ([]models.CategoryRetrieve, error)
 	DeleteCategory(id int) error
 	UpdateCategory(id int, c models.CategoryUpdate) error
+	SetCategoryVATRate(id int, rate *float64) error
 }
 
 type CategoryService struct {
@@ -37,3 +38,7 @@ func (s *CategoryService) DeleteCategory(id int) error {
 func (s *CategoryService) UpdateCategory(id int, c models.CategoryUpdate) error {
 	return s.repo.UpdateCategory(id, c)
 }
+
+func (s *CategoryService) SetCategoryVATRate(id int, rate *float64) error {
+	return s.repo.SetCategoryVATRate(id, rate)
+}
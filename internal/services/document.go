@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownUPC is returned when a line of a stock document names a store
+// product that does not exist.
+var ErrUnknownUPC = errors.New("store product not found")
+
+// Prefixes of the documents that move stock, used in their ledger references.
+const (
+	purchaseOrderPrefix = "PO"
+	goodsReceivedPrefix = "GRN"
+	stocktakePrefix     = "ST"
+	writeOffPrefix      = "WO"
+)
+
+// documentReference identifies a document in the stock ledger, e.g. WO-12.
+func documentReference(prefix string, documentID int) string {
+	return fmt.Sprintf("%s-%d", prefix, documentID)
+}
+
+// withLines loads the lines of every document in one call to loadLines and
+// builds a view of each document with its own lines, in the documents'
+// order. A document without lines gets an empty slice rather than nil.
+func withLines[D, L, V any](
+	documents []D,
+	documentID func(D) int,
+	lineDocumentID func(L) int,
+	loadLines func(documentIDs []int) ([]L, error),
+	view func(D, []L) V,
+) ([]V, error) {
+	views := make([]V, 0, len(documents))
+	if len(documents) == 0 {
+		return views, nil
+	}
+
+	documentIDs := make([]int, 0, len(documents))
+	for _, document := range documents {
+		documentIDs = append(documentIDs, documentID(document))
+	}
+	lines, err := loadLines(documentIDs)
+	if err != nil {
+		return nil, err
+	}
+	byDocument := make(map[int][]L, len(documents))
+	for _, line := range lines {
+		id := lineDocumentID(line)
+		byDocument[id] = append(byDocument[id], line)
+	}
+
+	for _, document := range documents {
+		documentLines := byDocument[documentID(document)]
+		if documentLines == nil {
+			documentLines = []L{}
+		}
+		views = append(views, view(document, documentLines))
+	}
+	return views, nil
+}
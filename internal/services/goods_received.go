@@ -0,0 +1,276 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrGoodsReceivedUnknownSupplier  = errors.New("supplier not found")
+	ErrGoodsReceivedDuplicateInvoice = errors.New("invoice is already booked")
+	ErrGoodsReceivedNoRegularUPC     = errors.New("product has no single regular store product, give the UPC")
+	ErrGoodsReceivedDuplicateUPC     = errors.New("UPC is received more than once")
+	ErrGoodsReceivedReversed         = errors.New("goods-received document is already reversed")
+	ErrGoodsReceivedStockSpent       = errors.New("received units are no longer in stock")
+)
+
+type GoodsReceivedRepo interface {
+	BeginTx() (*sql.Tx, error)
+	RetrievePostedGoodsReceivedByInvoiceTx(tx *sql.Tx, supplierID int, invoiceNumber string) (int, error)
+	CreateGoodsReceivedTx(tx *sql.Tx, c models.GoodsReceivedCreate) (int, error)
+	CreateGoodsReceivedLineTx(tx *sql.Tx, documentID int, l models.GoodsReceivedLineRecord) error
+	ReverseGoodsReceivedTx(tx *sql.Tx, documentID int, rv models.GoodsReceivedReverse, reversedAt time.Time) error
+	RetrieveGoodsReceivedByID(documentID int) (models.GoodsReceivedRetrieve, error)
+	RetrieveGoodsReceivedForUpdateTx(tx *sql.Tx, documentID int) (models.GoodsReceivedRetrieve, error)
+	RetrieveGoodsReceivedList(f models.GoodsReceivedFilter) ([]models.GoodsReceivedRetrieve, error)
+	RetrieveGoodsReceivedLines(documentIDs []int) ([]models.GoodsReceivedLine, error)
+	RetrieveGoodsReceivedLinesTx(tx *sql.Tx, documentID int) ([]models.GoodsReceivedLine, error)
+}
+
+type GoodsReceivedSupplierRepo interface {
+	RetrieveSupplierByID(supplierID int) (models.SupplierRetrieve, error)
+}
+
+type GoodsReceivedStoreProductRepo interface {
+	RetrieveStoreProductsByProductID(productID int) ([]models.StoreProductRetrieve, error)
+	LockStoreProductsTx(tx *sql.Tx, upcs []string) (map[string]models.StoreProductRetrieve, error)
+	UpdateProductDeliveryTx(tx *sql.Tx, m models.StockMovementCreate, newPrice *models.Money, expiryDate *string) error
+}
+
+// GoodsReceivedService books supplier deliveries of many lines as one
+// document, and reverses them.
+type GoodsReceivedService struct {
+	repo             GoodsReceivedRepo
+	supplierRepo     GoodsReceivedSupplierRepo
+	storeProductRepo GoodsReceivedStoreProductRepo
+}
+
+func NewGoodsReceivedService(repo GoodsReceivedRepo, supplierRepo GoodsReceivedSupplierRepo, storeProductRepo GoodsReceivedStoreProductRepo) *GoodsReceivedService {
+	return &GoodsReceivedService{
+		repo:             repo,
+		supplierRepo:     supplierRepo,
+		storeProductRepo: storeProductRepo,
+	}
+}
+
+// PostGoodsReceived books every line of the document in one transaction
+// through the delivery logic, so the stock ledger records each line as a
+// delivery referencing the document. Either all lines are booked or none.
+func (s *GoodsReceivedService) PostGoodsReceived(c models.GoodsReceivedCreate) (models.GoodsReceivedView, error) {
+	if _, err := s.supplierRepo.RetrieveSupplierByID(c.SupplierID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.GoodsReceivedView{}, fmt.Errorf("%w: %d", ErrGoodsReceivedUnknownSupplier, c.SupplierID)
+		}
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to check supplier %d: %w", c.SupplierID, err)
+	}
+
+	upcs := make([]string, 0, len(c.Lines))
+	seen := make(map[string]bool, len(c.Lines))
+	for _, line := range c.Lines {
+		upc, err := s.lineUPC(line)
+		if err != nil {
+			return models.GoodsReceivedView{}, err
+		}
+		if seen[upc] {
+			return models.GoodsReceivedView{}, fmt.Errorf("%w: %s", ErrGoodsReceivedDuplicateUPC, upc)
+		}
+		seen[upc] = true
+		upcs = append(upcs, upc)
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if documentID, err := s.repo.RetrievePostedGoodsReceivedByInvoiceTx(tx, c.SupplierID, c.InvoiceNumber); err == nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("%w: invoice %s is document %d", ErrGoodsReceivedDuplicateInvoice, c.InvoiceNumber, documentID)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to check invoice %s: %w", c.InvoiceNumber, err)
+	}
+
+	storeProducts, err := s.storeProductRepo.LockStoreProductsTx(tx, upcs)
+	if err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to lock store products: %w", err)
+	}
+
+	c.PostedAt = time.Now()
+	documentID, err := s.repo.CreateGoodsReceivedTx(tx, c)
+	if err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to create goods-received document: %w", err)
+	}
+
+	reference := documentReference(goodsReceivedPrefix, documentID)
+	for i, line := range c.Lines {
+		upc := upcs[i]
+		storeProduct, ok := storeProducts[upc]
+		if !ok {
+			return models.GoodsReceivedView{}, fmt.Errorf("%w: %s", ErrUnknownUPC, upc)
+		}
+
+		err := s.repo.CreateGoodsReceivedLineTx(tx, documentID, models.GoodsReceivedLineRecord{
+			LineNo:        i + 1,
+			UPC:           upc,
+			ProductID:     storeProduct.ProductID,
+			Quantity:      line.Quantity,
+			CostPrice:     line.CostPrice,
+			NewPrice:      line.NewPrice,
+			PreviousPrice: storeProduct.SellingPrice,
+			ExpiryDate:    line.ExpiryDate,
+		})
+		if err != nil {
+			return models.GoodsReceivedView{}, fmt.Errorf("failed to add line %d: %w", i+1, err)
+		}
+
+		err = s.storeProductRepo.UpdateProductDeliveryTx(tx, models.StockMovementCreate{
+			UPC:        upc,
+			Delta:      line.Quantity,
+			Kind:       models.StockMovementDelivery,
+			EmployeeId: &c.EmployeeId,
+			Reference:  &reference,
+		}, line.NewPrice, line.ExpiryDate)
+		if err != nil {
+			return models.GoodsReceivedView{}, fmt.Errorf("failed to book delivery of UPC %s: %w", upc, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to commit goods-received document: %w", err)
+	}
+
+	return s.GetGoodsReceived(documentID)
+}
+
+// lineUPC resolves the UPC a line is booked to. A line given by product goes
+// to the product's only regular UPC.
+func (s *GoodsReceivedService) lineUPC(line models.GoodsReceivedLineCreate) (string, error) {
+	if line.UPC != nil {
+		return *line.UPC, nil
+	}
+
+	storeProducts, err := s.storeProductRepo.RetrieveStoreProductsByProductID(*line.ProductID)
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve store products of product %d: %w", *line.ProductID, err)
+	}
+	var regular []string
+	for _, storeProduct := range storeProducts {
+		if !storeProduct.PromotionalProduct {
+			regular = append(regular, storeProduct.UPC)
+		}
+	}
+	if len(regular) != 1 {
+		return "", fmt.Errorf("%w: product %d has %d", ErrGoodsReceivedNoRegularUPC, *line.ProductID, len(regular))
+	}
+	return regular[0], nil
+}
+
+// ReverseGoodsReceived takes the units of a posted document back out of
+// stock and restores the selling prices it set, in one transaction. A price
+// changed again since the document was posted is left alone. The movements
+// are deliveries with negative deltas referencing the document. It fails
+// when some of the units have already been sold.
+func (s *GoodsReceivedService) ReverseGoodsReceived(documentID int, rv models.GoodsReceivedReverse) (models.GoodsReceivedView, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	document, err := s.repo.RetrieveGoodsReceivedForUpdateTx(tx, documentID)
+	if err != nil {
+		return models.GoodsReceivedView{}, err
+	}
+	if document.Status != models.GoodsReceivedStatusPosted {
+		return models.GoodsReceivedView{}, ErrGoodsReceivedReversed
+	}
+
+	lines, err := s.repo.RetrieveGoodsReceivedLinesTx(tx, documentID)
+	if err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to retrieve lines of document %d: %w", documentID, err)
+	}
+	upcs := make([]string, 0, len(lines))
+	for _, line := range lines {
+		upcs = append(upcs, line.UPC)
+	}
+	storeProducts, err := s.storeProductRepo.LockStoreProductsTx(tx, upcs)
+	if err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to lock store products: %w", err)
+	}
+
+	reference := documentReference(goodsReceivedPrefix, documentID)
+	for _, line := range lines {
+		storeProduct, ok := storeProducts[line.UPC]
+		if !ok {
+			return models.GoodsReceivedView{}, fmt.Errorf("%w: %s", ErrUnknownUPC, line.UPC)
+		}
+		if storeProduct.ProductsNumber < line.Quantity {
+			return models.GoodsReceivedView{}, fmt.Errorf("%w: UPC %s has %d of %d", ErrGoodsReceivedStockSpent, line.UPC, storeProduct.ProductsNumber, line.Quantity)
+		}
+
+		var price *models.Money
+		if line.NewPrice != nil && storeProduct.SellingPrice.Equal(*line.NewPrice) {
+			price = &line.PreviousPrice
+		}
+		err := s.storeProductRepo.UpdateProductDeliveryTx(tx, models.StockMovementCreate{
+			UPC:        line.UPC,
+			Delta:      -line.Quantity,
+			Kind:       models.StockMovementDelivery,
+			EmployeeId: &rv.EmployeeId,
+			Reason:     &rv.Reason,
+			Reference:  &reference,
+		}, price, nil)
+		if err != nil {
+			return models.GoodsReceivedView{}, fmt.Errorf("failed to reverse delivery of UPC %s: %w", line.UPC, err)
+		}
+	}
+
+	if err := s.repo.ReverseGoodsReceivedTx(tx, documentID, rv, time.Now()); err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to reverse document %d: %w", documentID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.GoodsReceivedView{}, fmt.Errorf("failed to commit reversal: %w", err)
+	}
+
+	return s.GetGoodsReceived(documentID)
+}
+
+func (s *GoodsReceivedService) GetGoodsReceived(documentID int) (models.GoodsReceivedView, error) {
+	document, err := s.repo.RetrieveGoodsReceivedByID(documentID)
+	if err != nil {
+		return models.GoodsReceivedView{}, err
+	}
+	views, err := s.withLines([]models.GoodsReceivedRetrieve{document})
+	if err != nil {
+		return models.GoodsReceivedView{}, err
+	}
+	return views[0], nil
+}
+
+// GetGoodsReceivedList lists the documents matching f with their lines.
+func (s *GoodsReceivedService) GetGoodsReceivedList(f models.GoodsReceivedFilter) ([]models.GoodsReceivedView, error) {
+	documents, err := s.repo.RetrieveGoodsReceivedList(f)
+	if err != nil {
+		return nil, err
+	}
+	return s.withLines(documents)
+}
+
+func (s *GoodsReceivedService) withLines(documents []models.GoodsReceivedRetrieve) ([]models.GoodsReceivedView, error) {
+	views, err := withLines(documents,
+		func(d models.GoodsReceivedRetrieve) int { return d.DocumentID },
+		func(l models.GoodsReceivedLine) int { return l.DocumentID },
+		s.repo.RetrieveGoodsReceivedLines,
+		func(d models.GoodsReceivedRetrieve, lines []models.GoodsReceivedLine) models.GoodsReceivedView {
+			return models.GoodsReceivedView{GoodsReceivedRetrieve: d, Lines: lines}
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve document lines: %w", err)
+	}
+	return views, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still in progress")
+)
+
+type IdempotencyRepo interface {
+	ClaimIdempotencyKey(c models.IdempotencyKeyCreate) (bool, error)
+	RetrieveIdempotencyKey(employeeID, key string) (models.IdempotencyKeyRetrieve, error)
+	SaveIdempotencyResponse(employeeID, key string, statusCode int, body []byte) error
+	DeleteIdempotencyKey(employeeID, key string) error
+	DeleteExpiredIdempotencyKeys(now time.Time) (int64, error)
+}
+
+type IdempotencyService struct {
+	repo IdempotencyRepo
+	cfg  *config.Config
+}
+
+func NewIdempotencyService(repo IdempotencyRepo, cfg *config.Config) *IdempotencyService {
+	return &IdempotencyService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// Reserve claims the key for a new request. It returns nil when the caller
+// should go on and handle the request, or the stored record whose response
+// has to be replayed instead.
+func (s *IdempotencyService) Reserve(employeeID, key, method, path, requestHash string) (*models.IdempotencyKeyRetrieve, error) {
+	now := time.Now()
+
+	// Expired keys are swept on the way in, a claim also reuses them in place
+	if _, err := s.repo.DeleteExpiredIdempotencyKeys(now); err != nil {
+		return nil, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	expiresAt := now.Add(s.cfg.IDEMPOTENCY_TTL)
+	claimed, err := s.repo.ClaimIdempotencyKey(models.IdempotencyKeyCreate{
+		Key:         &key,
+		EmployeeId:  &employeeID,
+		Method:      &method,
+		Path:        &path,
+		RequestHash: &requestHash,
+		CreatedAt:   &now,
+		ExpiresAt:   &expiresAt,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key: %w", err)
+	}
+	if claimed {
+		return nil, nil
+	}
+
+	record, err := s.repo.RetrieveIdempotencyKey(employeeID, key)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released between the claim and the read, the client may retry
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve idempotency key: %w", err)
+	}
+
+	if record.Method != method || record.Path != path || record.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyReused
+	}
+	if record.StatusCode == nil {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return &record, nil
+}
+
+func (s *IdempotencyService) Complete(employeeID, key string, statusCode int, body []byte) error {
+	return s.repo.SaveIdempotencyResponse(employeeID, key, statusCode, body)
+}
+
+// Release forgets the key so that a request which failed on the server side
+// can be retried with it.
+func (s *IdempotencyService) Release(employeeID, key string) error {
+	return s.repo.DeleteIdempotencyKey(employeeID, key)
+}
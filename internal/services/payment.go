@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrTendersDoNotCover = errors.New("tenders do not cover the receipt total")
+	ErrNonCashOverpaid   = errors.New("card and voucher tenders cannot exceed the amount due")
+)
+
+type PaymentRepo interface {
+	RetrievePaymentsByReceipt(receiptNumber string) ([]models.PaymentRetrieve, error)
+	RetrieveTenderTotals(from, to time.Time, employeeID string) ([]models.TenderTotal, error)
+}
+
+type PaymentService struct {
+	repo PaymentRepo
+}
+
+func NewPaymentService(repo PaymentRepo) *PaymentService {
+	return &PaymentService{
+		repo: repo,
+	}
+}
+
+func (s *PaymentService) GetPaymentsByReceipt(receiptNumber string) ([]models.PaymentRetrieve, error) {
+	return s.repo.RetrievePaymentsByReceipt(receiptNumber)
+}
+
+// GetTenderTotals returns per-tender totals per cashier for each day from
+// fromDay to toDay inclusive.
+func (s *PaymentService) GetTenderTotals(fromDay, toDay time.Time, employeeID string) ([]models.TenderTotal, error) {
+	return s.repo.RetrieveTenderTotals(fromDay, toDay.AddDate(0, 0, 1), employeeID)
+}
+
+// allocateTenders checks that the tenders cover the total and works out how
+// much of each is applied to it. Only cash can be overpaid: card and voucher
+// tenders must fit into the amount due, and the change is given back from
+// the cash tenders, starting with the last one. Tendered amounts are taken
+// to the kopeck.
+func allocateTenders(total models.Money, tenders []models.PaymentCreate) ([]models.PaymentRetrieve, models.Money, error) {
+	due := total.Round()
+
+	var cash, nonCash models.Money
+	for _, tender := range tenders {
+		if *tender.Tender == models.TenderCash {
+			cash = cash.Add(tender.Tendered.Round())
+		} else {
+			nonCash = nonCash.Add(tender.Tendered.Round())
+		}
+	}
+
+	if nonCash.Cmp(due) > 0 {
+		return nil, models.Money{}, fmt.Errorf("%w: %s by card or voucher for %s due", ErrNonCashOverpaid, nonCash, due)
+	}
+	paid := cash.Add(nonCash)
+	if paid.Cmp(due) < 0 {
+		return nil, models.Money{}, fmt.Errorf("%w: %s tendered for %s due", ErrTendersDoNotCover, paid, due)
+	}
+	change := paid.Sub(due)
+
+	payments := make([]models.PaymentRetrieve, len(tenders))
+	remaining := change
+	for i := len(tenders) - 1; i >= 0; i-- {
+		tendered := tenders[i].Tendered.Round()
+		amount := tendered
+		if *tenders[i].Tender == models.TenderCash && remaining.IsPositive() {
+			taken := models.MinMoney(remaining, tendered)
+			amount = tendered.Sub(taken)
+			remaining = remaining.Sub(taken)
+		}
+		payments[i] = models.PaymentRetrieve{
+			Tender:    *tenders[i].Tender,
+			Amount:    amount,
+			Tendered:  tendered,
+			Reference: tenders[i].Reference,
+		}
+	}
+
+	return payments, change, nil
+}
+
+// recordTenders takes the tenders as they were paid when they do not settle
+// total, as for an offline receipt re-priced after the till took the money.
+// No change is given, and the difference is returned to be looked into.
+func recordTenders(total models.Money, tenders []models.PaymentCreate) ([]models.PaymentRetrieve, *models.TenderMismatch) {
+	var paid models.Money
+	payments := make([]models.PaymentRetrieve, len(tenders))
+	for i, tender := range tenders {
+		tendered := tender.Tendered.Round()
+		paid = paid.Add(tendered)
+		payments[i] = models.PaymentRetrieve{
+			Tender:    *tender.Tender,
+			Amount:    tendered,
+			Tendered:  tendered,
+			Reference: tender.Reference,
+		}
+	}
+	return payments, &models.TenderMismatch{Due: total.Round(), Paid: paid}
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+const (
+	pdfFontFamily = "Body"
+	pdfLineHeight = 6.0
+)
+
+var ErrPDFFontMissing = errors.New("PDF font file not found, set PDF_FONT_PATH")
+
+type PDFReceiptSource interface {
+	GetReceiptPrint(receiptNumber string, width int) (models.ReceiptPrint, error)
+}
+
+// PDFService renders receipts and report tables as PDF documents. Text is
+// set in the TrueType font from PDF_FONT_PATH so that Cyrillic renders.
+type PDFService struct {
+	receiptSource PDFReceiptSource
+	cfg           *config.Config
+}
+
+func NewPDFService(receiptSource PDFReceiptSource, cfg *config.Config) *PDFService {
+	return &PDFService{
+		receiptSource: receiptSource,
+		cfg:           cfg,
+	}
+}
+
+func (s *PDFService) newDocument(orientation, title string) (*fpdf.Fpdf, error) {
+	regular, err := os.ReadFile(s.cfg.PDF_FONT_PATH)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPDFFontMissing, err)
+	}
+	bold := regular
+	if s.cfg.PDF_FONT_BOLD_PATH != "" {
+		if content, err := os.ReadFile(s.cfg.PDF_FONT_BOLD_PATH); err == nil {
+			bold = content
+		}
+	}
+
+	pdf := fpdf.New(orientation, "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "", regular)
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "B", bold)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator(s.cfg.STORE_NAME, true)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont(pdfFontFamily, "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	return pdf, pdf.Error()
+}
+
+func outputPDF(pdf *fpdf.Fpdf) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// RenderReceiptPDF lays out a single receipt: store header, sale lines,
+// totals and the VAT breakdown.
+func (s *PDFService) RenderReceiptPDF(receiptNumber string) ([]byte, error) {
+	doc, err := s.receiptSource.GetReceiptPrint(receiptNumber, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf, err := s.newDocument("P", "Receipt "+doc.ReceiptNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf.SetFont(pdfFontFamily, "B", 16)
+	pdf.CellFormat(0, 9, doc.StoreName, "", 1, "C", false, 0, "")
+	pdf.SetFont(pdfFontFamily, "", 10)
+	if doc.StoreAddress != "" {
+		pdf.CellFormat(0, pdfLineHeight, doc.StoreAddress, "", 1, "C", false, 0, "")
+	}
+	if doc.StoreTaxID != "" {
+		pdf.CellFormat(0, pdfLineHeight, "Tax ID "+doc.StoreTaxID, "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	info := [][2]string{
+		{"Receipt", doc.ReceiptNumber},
+		{"Date", doc.PrintDate.Format("2006-01-02 15:04")},
+		{"Cashier", doc.Cashier},
+	}
+	if doc.CardNumber != "" {
+		info = append(info, [2]string{"Customer card", doc.CardNumber})
+	}
+	for _, row := range info {
+		pdf.CellFormat(35, pdfLineHeight, row[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, pdfLineHeight, row[1], "", 1, "L", false, 0, "")
+	}
+	if doc.Voided {
+		pdf.SetFont(pdfFontFamily, "B", 12)
+		pdf.CellFormat(0, 8, "VOIDED", "", 1, "C", false, 0, "")
+		pdf.SetFont(pdfFontFamily, "", 10)
+	}
+	pdf.Ln(4)
+
+	headers := []string{"Product", "UPC", "Qty", "Price", "Total"}
+	rows := make([][]string, 0, len(doc.Lines))
+	for _, line := range doc.Lines {
+		rows = append(rows, []string{
+			line.ProductName,
+			line.UPC,
+			fmt.Sprintf("%d", line.Quantity),
+			line.SellingPrice.Round().String(),
+			line.TotalPrice.Round().String(),
+		})
+	}
+	drawTable(pdf, headers, rows, []float64{80, 32, 18, 25, 25}, []string{"L", "L", "R", "R", "R"})
+	pdf.Ln(4)
+
+	totals := [][2]string{}
+	if !doc.DiscountSum.IsZero() {
+		totals = append(totals,
+			[2]string{"Subtotal", doc.Subtotal.Round().String()},
+			[2]string{fmt.Sprintf("Card discount %d%%", doc.DiscountPercent), "-" + doc.DiscountSum.Round().String()},
+		)
+	}
+	for _, row := range totals {
+		pdf.CellFormat(155, pdfLineHeight, row[0], "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, pdfLineHeight, row[1], "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont(pdfFontFamily, "B", 12)
+	pdf.CellFormat(155, 8, "TOTAL", "", 0, "R", false, 0, "")
+	pdf.CellFormat(25, 8, doc.TotalSum.Round().String(), "", 1, "R", false, 0, "")
+	pdf.SetFont(pdfFontFamily, "", 10)
+	for _, vat := range doc.VAT {
+		pdf.CellFormat(155, pdfLineHeight, fmt.Sprintf("incl. VAT %s%% on %s", vat.Rate.Percent(), vat.Base.Round()), "", 0, "R", false, 0, "")
+		pdf.CellFormat(25, pdfLineHeight, vat.Amount.Round().String(), "", 1, "R", false, 0, "")
+	}
+
+	if len(doc.Payments) > 0 {
+		pdf.Ln(2)
+		for _, payment := range doc.Payments {
+			pdf.CellFormat(155, pdfLineHeight, "Paid "+payment.Tender, "", 0, "R", false, 0, "")
+			pdf.CellFormat(25, pdfLineHeight, payment.Tendered.Round().String(), "", 1, "R", false, 0, "")
+		}
+		if !doc.Change.IsZero() {
+			pdf.CellFormat(155, pdfLineHeight, "Change", "", 0, "R", false, 0, "")
+			pdf.CellFormat(25, pdfLineHeight, doc.Change.Round().String(), "", 1, "R", false, 0, "")
+		}
+	}
+
+	if pdf.Err() {
+		return nil, fmt.Errorf("failed to render PDF: %w", pdf.Error())
+	}
+	return outputPDF(pdf)
+}
+
+// RenderTablePDF renders a report as a table. rows must be a slice of
+// structs; columns are taken from their json tags in field order.
+func (s *PDFService) RenderTablePDF(title string, parameters map[string]any, rows any) ([]byte, error) {
+	headers, cells, err := tableFromStructs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	orientation := "P"
+	if len(headers) > 5 {
+		orientation = "L"
+	}
+	pdf, err := s.newDocument(orientation, title)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf.SetFont(pdfFontFamily, "B", 14)
+	pdf.MultiCell(0, 8, title, "", "L", false)
+	pdf.SetFont(pdfFontFamily, "", 9)
+	for _, key := range sortedKeys(parameters) {
+		pdf.CellFormat(0, 5, fmt.Sprintf("%s: %v", key, parameters[key]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(3)
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, _, right, _ := pdf.GetMargins()
+	widths, aligns := columnLayout(pdf, headers, cells, pageWidth-left-right)
+	drawTable(pdf, headers, cells, widths, aligns)
+
+	if len(cells) == 0 {
+		pdf.Ln(2)
+		pdf.CellFormat(0, pdfLineHeight, "No results", "", 1, "C", false, 0, "")
+	}
+
+	if pdf.Err() {
+		return nil, fmt.Errorf("failed to render PDF: %w", pdf.Error())
+	}
+	return outputPDF(pdf)
+}
+
+// drawTable prints a header row and wraps long cells onto several lines,
+// repeating the header after a page break.
+func drawTable(pdf *fpdf.Fpdf, headers []string, rows [][]string, widths []float64, aligns []string) {
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+
+	drawHeader := func() {
+		pdf.SetFont(pdfFontFamily, "B", 9)
+		pdf.SetFillColor(230, 230, 230)
+		for i, header := range headers {
+			pdf.CellFormat(widths[i], pdfLineHeight+1, header, "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont(pdfFontFamily, "", 9)
+	}
+	drawHeader()
+
+	for _, row := range rows {
+		lines := make([][]string, len(row))
+		height := 1
+		for i, cell := range row {
+			lines[i] = pdf.SplitText(cell, widths[i]-2)
+			if len(lines[i]) == 0 {
+				lines[i] = []string{""}
+			}
+			if len(lines[i]) > height {
+				height = len(lines[i])
+			}
+		}
+		rowHeight := float64(height) * (pdfLineHeight - 1)
+
+		if pdf.GetY()+rowHeight > pageHeight-bottom-10 {
+			pdf.AddPage()
+			drawHeader()
+		}
+
+		x, y := pdf.GetX(), pdf.GetY()
+		for i := range row {
+			pdf.Rect(x, y, widths[i], rowHeight, "D")
+			for j, text := range lines[i] {
+				pdf.SetXY(x, y+float64(j)*(pdfLineHeight-1))
+				pdf.CellFormat(widths[i], pdfLineHeight-1, text, "", 0, aligns[i], false, 0, "")
+			}
+			x += widths[i]
+		}
+		pdf.SetXY(pdf.GetX()-sum(widths), y+rowHeight)
+	}
+}
+
+// columnLayout shares the page width between columns by the length of their
+// widest cell and right-aligns numeric columns.
+func columnLayout(pdf *fpdf.Fpdf, headers []string, rows [][]string, total float64) ([]float64, []string) {
+	pdf.SetFont(pdfFontFamily, "", 9)
+	natural := make([]float64, len(headers))
+	aligns := make([]string, len(headers))
+	for i, header := range headers {
+		natural[i] = pdf.GetStringWidth(header) + 4
+		aligns[i] = "R"
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if w := pdf.GetStringWidth(cell) + 4; w > natural[i] {
+				natural[i] = min(w, total/2)
+			}
+			if !isNumeric(cell) {
+				aligns[i] = "L"
+			}
+		}
+	}
+
+	widths := make([]float64, len(headers))
+	scale := total / sum(natural)
+	for i := range natural {
+		widths[i] = natural[i] * scale
+	}
+	return widths, aligns
+}
+
+func tableFromStructs(rows any) ([]string, [][]string, error) {
+	value := reflect.ValueOf(rows)
+	if value.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("table rows must be a slice, got %T", rows)
+	}
+	elemType := value.Type().Elem()
+	if elemType.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("table rows must be structs, got %s", elemType)
+	}
+
+	var headers []string
+	var fields []int
+	for i := 0; i < elemType.NumField(); i++ {
+		field := elemType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		headers = append(headers, strings.ReplaceAll(name, "_", " "))
+		fields = append(fields, i)
+	}
+
+	cells := make([][]string, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		row := make([]string, 0, len(fields))
+		for _, field := range fields {
+			row = append(row, formatCell(value.Index(i).Field(field)))
+		}
+		cells = append(cells, row)
+	}
+
+	return headers, cells, nil
+}
+
+func formatCell(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if money, ok := v.Interface().(models.Money); ok {
+		return money.Round().String()
+	}
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf("%.2f", v.Float())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if (r < '0' || r > '9') && r != '.' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func sum(values []float64) float64 {
+	var total float64
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
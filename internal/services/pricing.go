@@ -0,0 +1,124 @@
+package services
+
+import (
+	"sort"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+// saleStoreProduct resolves the store product a scanned UPC is sold as. A
+// regular UPC whose upc_prom links a promotional UPC sells as that UPC, at
+// its price and from its stock, as long as it has the units; otherwise, and
+// for every other UPC, the scanned UPC itself is sold.
+func saleStoreProduct(scanned models.StoreProductRetrieve, promotion *models.StoreProductRetrieve, quantity int) models.StoreProductRetrieve {
+	if scanned.PromotionalProduct || promotion == nil || !promotion.PromotionalProduct {
+		return scanned
+	}
+	if promotion.ProductsNumber < quantity {
+		return scanned
+	}
+	return *promotion
+}
+
+// promotionOf looks up the UPC that storeProduct's upc_prom links to among
+// storeProducts, or nil when there is none.
+func promotionOf(storeProducts map[string]models.StoreProductRetrieve, storeProduct models.StoreProductRetrieve) *models.StoreProductRetrieve {
+	if storeProduct.UPCProm == nil {
+		return nil
+	}
+	promotion, ok := storeProducts[*storeProduct.UPCProm]
+	if !ok {
+		return nil
+	}
+	return &promotion
+}
+
+// priceLine prices a quantity of a store product at its current selling
+// price. A promotional UPC already carries its discounted selling_price.
+func priceLine(storeProduct models.StoreProductRetrieve, quantity int, vatRate models.Rate) models.ReceiptLine {
+	return models.ReceiptLine{
+		UPC:           storeProduct.UPC,
+		ProductNumber: quantity,
+		UnitPrice:     storeProduct.SellingPrice,
+		LineTotal:     storeProduct.SellingPrice.Mul(quantity),
+		Promotional:   storeProduct.PromotionalProduct,
+		VATRate:       vatRate,
+	}
+}
+
+// vatRateFor returns the rate configured on the UPC's product or category,
+// falling back to the store default when neither sets one.
+func vatRateFor(rates map[string]models.Rate, upc string, defaultRate models.Rate) models.Rate {
+	if rate, ok := rates[upc]; ok {
+		return rate
+	}
+	return defaultRate
+}
+
+// vatScale is the precision of the VAT stored on each sale line; it matches
+// the DECIMAL(13,4) columns so summing stored lines gives the receipt VAT.
+const vatScale = 4
+
+// lineVAT is the VAT charged on a line once the receipt discount is applied,
+// rounded to vatScale decimals.
+func lineVAT(lineTotal models.Money, discountPercent int, vatRate models.Rate) models.Money {
+	return lineTotal.Percent(100 - discountPercent).MulRate(vatRate).RoundTo(vatScale)
+}
+
+type receiptTotals struct {
+	Subtotal        models.Money
+	DiscountPercent int
+	DiscountSum     models.Money
+	TotalSum        models.Money
+	VAT             models.Money
+	VATBreakdown    []models.ReceiptVATLine
+}
+
+// calculateTotals applies the customer card percent to the whole receipt and
+// charges each line's VAT rate on its discounted amount. The VAT of every
+// line is written back to lines, and the breakdown is ordered by rate.
+//
+// Line totals are exact. The receipt amounts are rounded to kopecks, halves
+// away from zero: the subtotal, the discount on the rounded subtotal, and the
+// taxable base and VAT of each rate. The total is the subtotal less the
+// discount and the VAT is the sum of the rounded amounts per rate, so every
+// figure printed on the receipt adds up.
+func calculateTotals(lines []models.ReceiptLine, discountPercent int) receiptTotals {
+	var subtotal models.Money
+	// Rates are grouped by their string, which is the same for 0.2 and 0.2000
+	byRate := make(map[string]*models.ReceiptVATLine)
+	for i := range lines {
+		line := &lines[i]
+		line.VATSum = lineVAT(line.LineTotal, discountPercent, line.VATRate)
+		subtotal = subtotal.Add(line.LineTotal)
+
+		group, ok := byRate[line.VATRate.String()]
+		if !ok {
+			group = &models.ReceiptVATLine{Rate: line.VATRate}
+			byRate[line.VATRate.String()] = group
+		}
+		group.Base = group.Base.Add(line.LineTotal.Percent(100 - discountPercent))
+		group.Amount = group.Amount.Add(line.VATSum)
+	}
+
+	var vat models.Money
+	breakdown := make([]models.ReceiptVATLine, 0, len(byRate))
+	for _, group := range byRate {
+		group.Base = group.Base.Round()
+		group.Amount = group.Amount.Round()
+		vat = vat.Add(group.Amount)
+		breakdown = append(breakdown, *group)
+	}
+	sort.Slice(breakdown, func(i, j int) bool { return breakdown[i].Rate.Cmp(breakdown[j].Rate) > 0 })
+
+	subtotal = subtotal.Round()
+	discountSum := subtotal.Percent(discountPercent).Round()
+	return receiptTotals{
+		Subtotal:        subtotal,
+		DiscountPercent: discountPercent,
+		DiscountSum:     discountSum,
+		TotalSum:        subtotal.Sub(discountSum),
+		VAT:             vat,
+		VATBreakdown:    breakdown,
+	}
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+func money(t *testing.T, value string) models.Money {
+	t.Helper()
+	m, err := models.ParseMoney(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func rate(t *testing.T, value string) models.Rate {
+	t.Helper()
+	r, err := models.ParseRate(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func line(t *testing.T, price string, quantity int, vatRate string) models.ReceiptLine {
+	t.Helper()
+	unitPrice := money(t, price)
+	return models.ReceiptLine{
+		UnitPrice:     unitPrice,
+		ProductNumber: quantity,
+		LineTotal:     unitPrice.Mul(quantity),
+		VATRate:       rate(t, vatRate),
+	}
+}
+
+func TestLineVAT(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    string
+		discount int
+		rate     string
+		want     string
+	}{
+		{"whole amount", "10.00", 0, "0.2", "2"},
+		{"one kopeck", "0.01", 0, "0.2", "0.002"},
+		{"reduced rate", "1.00", 0, "0.07", "0.07"},
+		{"discounted", "33.33", 10, "0.2", "5.9994"},
+		{"rounds half away from zero", "0.0003", 0, "0.5", "0.0002"},
+		{"full discount", "50.00", 100, "0.2", "0"},
+		{"zero rate", "50.00", 0, "0", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lineVAT(money(t, tt.total), tt.discount, rate(t, tt.rate))
+			if !got.Equal(money(t, tt.want)) {
+				t.Errorf("lineVAT(%s, %d, %s) = %s, want %s", tt.total, tt.discount, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateTotals(t *testing.T) {
+	type vatLine struct {
+		rate, base, amount string
+	}
+	tests := []struct {
+		name      string
+		lines     []models.ReceiptLine
+		discount  int
+		subtotal  string
+		discSum   string
+		total     string
+		vat       string
+		breakdown []vatLine
+	}{
+		{
+			name:      "no lines",
+			subtotal:  "0",
+			discSum:   "0",
+			total:     "0",
+			vat:       "0",
+			breakdown: []vatLine{},
+		},
+		{
+			name:      "VAT rounded per rate",
+			lines:     []models.ReceiptLine{line(t, "33.33", 3, "0.2")},
+			subtotal:  "99.99",
+			discSum:   "0",
+			total:     "99.99",
+			vat:       "20.00",
+			breakdown: []vatLine{{"0.2", "99.99", "20.00"}},
+		},
+		{
+			name:      "discount rounded on the rounded subtotal",
+			lines:     []models.ReceiptLine{line(t, "33.33", 3, "0.2")},
+			discount:  5,
+			subtotal:  "99.99",
+			discSum:   "5.00",
+			total:     "94.99",
+			vat:       "19.00",
+			breakdown: []vatLine{{"0.2", "94.99", "19.00"}},
+		},
+		{
+			name: "rates grouped by value and ordered high to low",
+			lines: []models.ReceiptLine{
+				line(t, "1.10", 1, "0.07"),
+				line(t, "10.00", 1, "0.2"),
+				line(t, "5.00", 2, "0.2000"),
+			},
+			subtotal: "21.10",
+			discSum:  "0",
+			total:    "21.10",
+			vat:      "4.08",
+			breakdown: []vatLine{
+				{"0.2", "20.00", "4.00"},
+				{"0.07", "1.10", "0.08"},
+			},
+		},
+		{
+			name:      "full discount",
+			lines:     []models.ReceiptLine{line(t, "12.34", 2, "0.2")},
+			discount:  100,
+			subtotal:  "24.68",
+			discSum:   "24.68",
+			total:     "0",
+			vat:       "0",
+			breakdown: []vatLine{{"0.2", "0", "0"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := calculateTotals(tt.lines, tt.discount)
+			checks := []struct {
+				field string
+				got   models.Money
+				want  string
+			}{
+				{"subtotal", got.Subtotal, tt.subtotal},
+				{"discount", got.DiscountSum, tt.discSum},
+				{"total", got.TotalSum, tt.total},
+				{"VAT", got.VAT, tt.vat},
+			}
+			for _, c := range checks {
+				if !c.got.Equal(money(t, c.want)) {
+					t.Errorf("%s = %s, want %s", c.field, c.got, c.want)
+				}
+			}
+
+			if len(got.VATBreakdown) != len(tt.breakdown) {
+				t.Fatalf("breakdown has %d rates, want %d", len(got.VATBreakdown), len(tt.breakdown))
+			}
+			for i, want := range tt.breakdown {
+				group := got.VATBreakdown[i]
+				if !group.Rate.Equal(rate(t, want.rate)) || !group.Base.Equal(money(t, want.base)) || !group.Amount.Equal(money(t, want.amount)) {
+					t.Errorf("breakdown[%d] = %s on %s: %s, want %s on %s: %s", i, group.Rate, group.Base, group.Amount, want.rate, want.base, want.amount)
+				}
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ type ProductRepo interface {
 	RetrieveProducts() ([]models.ProductRetrieve, error)
 	UpdateProduct(id int, p models.ProductUpdate) error
 	DeleteProduct(id int) error
+	SetProductVATRate(id int, rate *float64) error
 }
 
 type ProductService struct {
@@ -49,3 +50,7 @@ func (s *ProductService) UpdateProduct(id int, p models.ProductUpdate) error {
 func (s *ProductService) DeleteProduct(id int) error {
 	return s.repo.DeleteProduct(id)
 }
+
+func (s *ProductService) SetProductVATRate(id int, rate *float64) error {
+	return s.repo.SetProductVATRate(id, rate)
+}
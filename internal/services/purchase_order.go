@@ -0,0 +1,350 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrPurchaseOrderNotDraft        = errors.New("purchase order is no longer a draft")
+	ErrPurchaseOrderNotReceivable   = errors.New("purchase order is not awaiting delivery")
+	ErrPurchaseOrderNotCancellable  = errors.New("purchase order can no longer be cancelled")
+	ErrPurchaseOrderUnknownSupplier = errors.New("supplier not found")
+	ErrPurchaseOrderUnknownProduct  = errors.New("product not found")
+	ErrPurchaseOrderDuplicateLine   = errors.New("product is ordered more than once")
+	ErrPurchaseOrderNotOrdered      = errors.New("product is not on the purchase order")
+	ErrPurchaseOrderOverReceived    = errors.New("more units received than are outstanding")
+)
+
+type PurchaseOrderRepo interface {
+	BeginTx() (*sql.Tx, error)
+	CreatePurchaseOrderTx(tx *sql.Tx, c models.PurchaseOrderCreate) (int, error)
+	CreatePurchaseOrderLineTx(tx *sql.Tx, orderID int, l models.PurchaseOrderLineCreate) error
+	DeletePurchaseOrderLinesTx(tx *sql.Tx, orderID int) error
+	UpdatePurchaseOrderTx(tx *sql.Tx, orderID, supplierID int, note *string, updatedAt time.Time) error
+	SetPurchaseOrderStatusTx(tx *sql.Tx, orderID int, status string, updatedAt time.Time) error
+	RetrievePurchaseOrderByID(orderID int) (models.PurchaseOrderRetrieve, error)
+	RetrievePurchaseOrderForUpdateTx(tx *sql.Tx, orderID int) (models.PurchaseOrderRetrieve, error)
+	RetrievePurchaseOrders(f models.PurchaseOrderFilter) ([]models.PurchaseOrderRetrieve, error)
+	RetrievePurchaseOrderLines(orderID int) ([]models.PurchaseOrderLine, error)
+	RetrievePurchaseOrderLinesTx(tx *sql.Tx, orderID int) ([]models.PurchaseOrderLine, error)
+	RetrievePurchaseOrderDeliveries(orderID int) ([]models.PurchaseOrderDelivery, error)
+	CreatePurchaseOrderDeliveryTx(tx *sql.Tx, orderID int, productID int, l models.PurchaseOrderReceiveLine, employeeID string, receivedAt time.Time) error
+}
+
+type PurchaseOrderSupplierRepo interface {
+	RetrieveSupplierByID(supplierID int) (models.SupplierRetrieve, error)
+}
+
+type PurchaseOrderProductRepo interface {
+	RetrieveProductByID(id int) (models.ProductRetrieve, error)
+}
+
+type PurchaseOrderStoreProductRepo interface {
+	LockStoreProductsTx(tx *sql.Tx, upcs []string) (map[string]models.StoreProductRetrieve, error)
+}
+
+type PurchaseOrderDelivery interface {
+	UpdateProductDeliveryTx(tx *sql.Tx, upc string, quantityChange int, newPrice *models.Money, expiryDate *string, employeeID string, reference *string) error
+}
+
+// PurchaseOrderService keeps the orders placed with suppliers and books the
+// goods received against them.
+type PurchaseOrderService struct {
+	repo             PurchaseOrderRepo
+	supplierRepo     PurchaseOrderSupplierRepo
+	productRepo      PurchaseOrderProductRepo
+	storeProductRepo PurchaseOrderStoreProductRepo
+	delivery         PurchaseOrderDelivery
+}
+
+func NewPurchaseOrderService(repo PurchaseOrderRepo, supplierRepo PurchaseOrderSupplierRepo, productRepo PurchaseOrderProductRepo, storeProductRepo PurchaseOrderStoreProductRepo, delivery PurchaseOrderDelivery) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		repo:             repo,
+		supplierRepo:     supplierRepo,
+		productRepo:      productRepo,
+		storeProductRepo: storeProductRepo,
+		delivery:         delivery,
+	}
+}
+
+// CreatePurchaseOrder drafts an order with the supplier.
+func (s *PurchaseOrderService) CreatePurchaseOrder(c models.PurchaseOrderCreate) (models.PurchaseOrderView, error) {
+	if err := s.checkSupplier(c.SupplierID); err != nil {
+		return models.PurchaseOrderView{}, err
+	}
+	if err := s.checkLines(c.Lines); err != nil {
+		return models.PurchaseOrderView{}, err
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	c.CreatedAt = time.Now()
+	orderID, err := s.repo.CreatePurchaseOrderTx(tx, c)
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to create purchase order: %w", err)
+	}
+	for _, line := range c.Lines {
+		if err := s.repo.CreatePurchaseOrderLineTx(tx, orderID, line); err != nil {
+			return models.PurchaseOrderView{}, fmt.Errorf("failed to add product %d: %w", line.ProductID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to commit purchase order: %w", err)
+	}
+
+	return s.GetPurchaseOrder(orderID)
+}
+
+func (s *PurchaseOrderService) checkSupplier(supplierID int) error {
+	if _, err := s.supplierRepo.RetrieveSupplierByID(supplierID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: %d", ErrPurchaseOrderUnknownSupplier, supplierID)
+		}
+		return fmt.Errorf("failed to check supplier %d: %w", supplierID, err)
+	}
+	return nil
+}
+
+func (s *PurchaseOrderService) checkLines(lines []models.PurchaseOrderLineCreate) error {
+	seen := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		if seen[line.ProductID] {
+			return fmt.Errorf("%w: %d", ErrPurchaseOrderDuplicateLine, line.ProductID)
+		}
+		seen[line.ProductID] = true
+
+		if _, err := s.productRepo.RetrieveProductByID(line.ProductID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: %d", ErrPurchaseOrderUnknownProduct, line.ProductID)
+			}
+			return fmt.Errorf("failed to check product %d: %w", line.ProductID, err)
+		}
+	}
+	return nil
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrder(orderID int) (models.PurchaseOrderView, error) {
+	order, err := s.repo.RetrievePurchaseOrderByID(orderID)
+	if err != nil {
+		return models.PurchaseOrderView{}, err
+	}
+	lines, err := s.repo.RetrievePurchaseOrderLines(orderID)
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to retrieve lines of purchase order %d: %w", orderID, err)
+	}
+	deliveries, err := s.repo.RetrievePurchaseOrderDeliveries(orderID)
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to retrieve deliveries of purchase order %d: %w", orderID, err)
+	}
+
+	view := models.PurchaseOrderView{
+		PurchaseOrderRetrieve: order,
+		Lines:                 lines,
+		Deliveries:            deliveries,
+	}
+	if view.Lines == nil {
+		view.Lines = []models.PurchaseOrderLine{}
+	}
+	if view.Deliveries == nil {
+		view.Deliveries = []models.PurchaseOrderDelivery{}
+	}
+	return view, nil
+}
+
+func (s *PurchaseOrderService) GetPurchaseOrders(f models.PurchaseOrderFilter) ([]models.PurchaseOrderRetrieve, error) {
+	return s.repo.RetrievePurchaseOrders(f)
+}
+
+// UpdatePurchaseOrder changes the supplier, note or lines of a draft.
+func (s *PurchaseOrderService) UpdatePurchaseOrder(orderID int, u models.PurchaseOrderUpdate) (models.PurchaseOrderView, error) {
+	if u.SupplierID != nil {
+		if err := s.checkSupplier(*u.SupplierID); err != nil {
+			return models.PurchaseOrderView{}, err
+		}
+	}
+	if u.Lines != nil {
+		if err := s.checkLines(u.Lines); err != nil {
+			return models.PurchaseOrderView{}, err
+		}
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	order, err := s.repo.RetrievePurchaseOrderForUpdateTx(tx, orderID)
+	if err != nil {
+		return models.PurchaseOrderView{}, err
+	}
+	if order.Status != models.PurchaseOrderStatusDraft {
+		return models.PurchaseOrderView{}, ErrPurchaseOrderNotDraft
+	}
+
+	supplierID := order.SupplierID
+	if u.SupplierID != nil {
+		supplierID = *u.SupplierID
+	}
+	note := order.Note
+	if u.Note != nil {
+		note = u.Note
+	}
+	if err := s.repo.UpdatePurchaseOrderTx(tx, orderID, supplierID, note, time.Now()); err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to update purchase order %d: %w", orderID, err)
+	}
+
+	if u.Lines != nil {
+		if err := s.repo.DeletePurchaseOrderLinesTx(tx, orderID); err != nil {
+			return models.PurchaseOrderView{}, fmt.Errorf("failed to clear lines of purchase order %d: %w", orderID, err)
+		}
+		for _, line := range u.Lines {
+			if err := s.repo.CreatePurchaseOrderLineTx(tx, orderID, line); err != nil {
+				return models.PurchaseOrderView{}, fmt.Errorf("failed to add product %d: %w", line.ProductID, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to commit purchase order: %w", err)
+	}
+
+	return s.GetPurchaseOrder(orderID)
+}
+
+// SendPurchaseOrder marks a draft as sent to the supplier; from then on it
+// can be received but no longer changed.
+func (s *PurchaseOrderService) SendPurchaseOrder(orderID int) (models.PurchaseOrderView, error) {
+	return s.setStatus(orderID, models.PurchaseOrderStatusSent, func(status string) error {
+		if status != models.PurchaseOrderStatusDraft {
+			return ErrPurchaseOrderNotDraft
+		}
+		return nil
+	})
+}
+
+// CancelPurchaseOrder cancels an order that is not fully received. Units
+// already received of a partially received order stay in stock; only the
+// outstanding rest is cancelled.
+func (s *PurchaseOrderService) CancelPurchaseOrder(orderID int) (models.PurchaseOrderView, error) {
+	return s.setStatus(orderID, models.PurchaseOrderStatusCancelled, func(status string) error {
+		if status == models.PurchaseOrderStatusReceived || status == models.PurchaseOrderStatusCancelled {
+			return ErrPurchaseOrderNotCancellable
+		}
+		return nil
+	})
+}
+
+func (s *PurchaseOrderService) setStatus(orderID int, status string, allowed func(current string) error) (models.PurchaseOrderView, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	order, err := s.repo.RetrievePurchaseOrderForUpdateTx(tx, orderID)
+	if err != nil {
+		return models.PurchaseOrderView{}, err
+	}
+	if err := allowed(order.Status); err != nil {
+		return models.PurchaseOrderView{}, err
+	}
+	if err := s.repo.SetPurchaseOrderStatusTx(tx, orderID, status, time.Now()); err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to update purchase order %d: %w", orderID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to commit purchase order: %w", err)
+	}
+
+	return s.GetPurchaseOrder(orderID)
+}
+
+// ReceivePurchaseOrder books goods delivered against a sent order in one
+// transaction. Every line is added to the stock of its UPC through the
+// delivery logic, so the stock ledger records it as a delivery referencing
+// the order. The UPC must belong to an ordered product, and no product may
+// receive more than is outstanding. The order ends up received once every
+// line is complete, and partially received until then.
+func (s *PurchaseOrderService) ReceivePurchaseOrder(orderID int, r models.PurchaseOrderReceive) (models.PurchaseOrderView, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	order, err := s.repo.RetrievePurchaseOrderForUpdateTx(tx, orderID)
+	if err != nil {
+		return models.PurchaseOrderView{}, err
+	}
+	if order.Status != models.PurchaseOrderStatusSent && order.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return models.PurchaseOrderView{}, ErrPurchaseOrderNotReceivable
+	}
+
+	lines, err := s.repo.RetrievePurchaseOrderLinesTx(tx, orderID)
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to retrieve lines of purchase order %d: %w", orderID, err)
+	}
+	outstanding := make(map[int]int, len(lines))
+	for _, line := range lines {
+		outstanding[line.ProductID] = line.Quantity - line.ReceivedQuantity
+	}
+
+	upcs := make([]string, 0, len(r.Lines))
+	for _, line := range r.Lines {
+		upcs = append(upcs, line.UPC)
+	}
+	storeProducts, err := s.storeProductRepo.LockStoreProductsTx(tx, upcs)
+	if err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to lock store products: %w", err)
+	}
+
+	reference := documentReference(purchaseOrderPrefix, orderID)
+	now := time.Now()
+	for _, line := range r.Lines {
+		storeProduct, ok := storeProducts[line.UPC]
+		if !ok {
+			return models.PurchaseOrderView{}, fmt.Errorf("%w: %s", ErrUnknownUPC, line.UPC)
+		}
+		remaining, ok := outstanding[storeProduct.ProductID]
+		if !ok {
+			return models.PurchaseOrderView{}, fmt.Errorf("%w: UPC %s is product %d", ErrPurchaseOrderNotOrdered, line.UPC, storeProduct.ProductID)
+		}
+		if line.Quantity > remaining {
+			return models.PurchaseOrderView{}, fmt.Errorf("%w: product %d has %d outstanding, %d received", ErrPurchaseOrderOverReceived, storeProduct.ProductID, remaining, line.Quantity)
+		}
+		outstanding[storeProduct.ProductID] = remaining - line.Quantity
+
+		err := s.delivery.UpdateProductDeliveryTx(tx, line.UPC, line.Quantity, line.SellingPrice, line.ExpiryDate, r.EmployeeId, &reference)
+		if err != nil {
+			return models.PurchaseOrderView{}, fmt.Errorf("failed to book delivery of UPC %s: %w", line.UPC, err)
+		}
+		err = s.repo.CreatePurchaseOrderDeliveryTx(tx, orderID, storeProduct.ProductID, line, r.EmployeeId, now)
+		if err != nil {
+			return models.PurchaseOrderView{}, fmt.Errorf("failed to record delivery of UPC %s: %w", line.UPC, err)
+		}
+	}
+
+	status := models.PurchaseOrderStatusReceived
+	for _, remaining := range outstanding {
+		if remaining > 0 {
+			status = models.PurchaseOrderStatusPartiallyReceived
+			break
+		}
+	}
+	if err := s.repo.SetPurchaseOrderStatusTx(tx, orderID, status, now); err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to update purchase order %d: %w", orderID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.PurchaseOrderView{}, fmt.Errorf("failed to commit delivery: %w", err)
+	}
+
+	return s.GetPurchaseOrder(orderID)
+}
@@ -1,115 +1,663 @@
 package services
 
 import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/velosypedno/zlagoda/internal/config"
 	"github.com/velosypedno/zlagoda/internal/models"
 )
 
 type ReceiptRepo interface {
+	BeginTx() (*sql.Tx, error)
 	CreateReceipt(c models.ReceiptCreate) (string, error)
+	CreateReceiptTx(tx *sql.Tx, c models.ReceiptCreate) (string, error)
+	NextReceiptSequenceTx(tx *sql.Tx, registerID string) (int, error)
 	RetrieveReceiptByReceiptNumber(receiptNumber string) (models.ReceiptRetrieve, error)
-	RetrieveReceipts() ([]models.ReceiptRetrieve, error)
+	RetrieveReceipts(f models.ReceiptFilter, p models.ReceiptPage) ([]models.ReceiptRetrieve, error)
+	RetrieveReceiptForUpdateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptRetrieve, error)
+	RetrieveReceiptVAT(receiptNumber string) ([]models.ReceiptVATLine, error)
+	VoidReceiptTx(tx *sql.Tx, receiptNumber string, v models.ReceiptVoid) error
 	DeleteReceipt(receiptNumber string) error
-	UpdateReceipt(receiptNumber string, c models.ReceiptUpdate) error
+	UpdateReceiptTx(tx *sql.Tx, receiptNumber string, c models.ReceiptUpdate) error
+	UpdateReceiptTotalsTx(tx *sql.Tx, receiptNumber string, t models.ReceiptTotals) error
+	RetrieveReceiptEditStateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptEditState, error)
 }
 
 type SaleRepoInterface interface {
-	CreateSale(s models.SaleCreate) error
+	CreateSaleTx(tx *sql.Tx, s models.SaleCreate) error
+	RetrieveSalesByReceipt(receiptNumber string) ([]models.SaleRetrieve, error)
+	RetrieveSalesByReceiptTx(tx *sql.Tx, receiptNumber string) ([]models.SaleRetrieve, error)
+	UpdateSaleVATTx(tx *sql.Tx, upc, receiptNumber string, vatSum models.Money) error
+}
+
+type ReceiptReturnRepoInterface interface {
+	RetrieveReturnableSalesTx(tx *sql.Tx, receiptNumber string) ([]models.ReturnableSale, error)
 }
 
 type StoreProductRepoInterface interface {
-	CheckStockAvailability(upc string, requiredQuantity int) (bool, error)
-	UpdateProductQuantity(upc string, quantityChange int) error
+	LockStoreProductsWithPromotionsTx(tx *sql.Tx, upcs []string) (map[string]models.StoreProductRetrieve, error)
+	RetrieveVATRatesTx(tx *sql.Tx, upcs []string) (map[string]models.Rate, error)
+	UpdateProductQuantityTx(tx *sql.Tx, m models.StockMovementCreate) error
+	UpdateProductQuantityUncheckedTx(tx *sql.Tx, m models.StockMovementCreate) error
+}
+
+type CustomerCardRepoInterface interface {
+	RetrieveCustomerCardByCardNumberTx(tx *sql.Tx, cardNumber string) (models.CustomerCardRetrieve, error)
+}
+
+type ReceiptPaymentRepoInterface interface {
+	CreatePaymentTx(tx *sql.Tx, p models.PaymentRetrieve) (int, error)
+}
+
+type ReceiptShiftRepoInterface interface {
+	RetrieveOpenShiftByEmployeeTx(tx *sql.Tx, employeeID string) (models.ShiftRetrieve, error)
+	RetrieveShiftAtTx(tx *sql.Tx, employeeID string, at time.Time) (models.ShiftRetrieve, error)
 }
 
 type ReceiptService struct {
 	receiptRepo      ReceiptRepo
 	saleRepo         SaleRepoInterface
 	storeProductRepo StoreProductRepoInterface
+	customerCardRepo CustomerCardRepoInterface
+	returnRepo       ReceiptReturnRepoInterface
+	paymentRepo      ReceiptPaymentRepoInterface
+	shiftRepo        ReceiptShiftRepoInterface
+	cfg              *config.Config
 }
 
-func NewReceiptService(receiptRepo ReceiptRepo, saleRepo SaleRepoInterface, storeProductRepo StoreProductRepoInterface) *ReceiptService {
+func NewReceiptService(receiptRepo ReceiptRepo, saleRepo SaleRepoInterface, storeProductRepo StoreProductRepoInterface, customerCardRepo CustomerCardRepoInterface, returnRepo ReceiptReturnRepoInterface, paymentRepo ReceiptPaymentRepoInterface, shiftRepo ReceiptShiftRepoInterface, cfg *config.Config) *ReceiptService {
 	return &ReceiptService{
 		receiptRepo:      receiptRepo,
 		saleRepo:         saleRepo,
 		storeProductRepo: storeProductRepo,
+		customerCardRepo: customerCardRepo,
+		returnRepo:       returnRepo,
+		paymentRepo:      paymentRepo,
+		shiftRepo:        shiftRepo,
+		cfg:              cfg,
 	}
 }
 
+var (
+	ErrReceiptVoided        = errors.New("receipt is voided")
+	ErrReceiptHasSales      = errors.New("receipt has sales and must be voided instead of deleted")
+	ErrInvalidReceiptSort   = errors.New("invalid receipt sort field")
+	ErrInvalidReceiptCursor = errors.New("invalid receipt cursor")
+	ErrUnknownCustomerCard  = errors.New("customer card not found")
+	ErrReceiptLinesFinal    = errors.New("receipt lines can no longer be changed")
+	ErrReceiptPaidMismatch  = errors.New("receipt total would no longer match its payments")
+)
+
+const (
+	DefaultReceiptPageSize = 50
+	MaxReceiptPageSize     = 200
+)
+
+// CreateReceipt writes an empty receipt into the cashier's open shift. Its
+// discount is the card's percent and its totals are zero until sale lines are
+// added to it.
 func (s *ReceiptService) CreateReceipt(c models.ReceiptCreate) (string, error) {
-	return s.receiptRepo.CreateReceipt(c)
+	tx, err := s.receiptRepo.BeginTx()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	shift, err := s.openShiftTx(tx, *c.EmployeeId)
+	if err != nil {
+		return "", err
+	}
+	c.ShiftID = &shift.ShiftID
+	if c.ReceiptNumber, err = s.receiptNumberTx(tx, shift); err != nil {
+		return "", err
+	}
+
+	discountPercent, err := s.cardDiscountTx(tx, c.CardNumber)
+	if err != nil {
+		return "", err
+	}
+	var zero models.Money
+	c.DiscountPercent = &discountPercent
+	c.TotalSum, c.VAT, c.DiscountSum = &zero, &zero, &zero
+
+	receiptNumber, err := s.receiptRepo.CreateReceiptTx(tx, c)
+	if err != nil {
+		return "", err
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to commit receipt: %w", err)
+	}
+	return receiptNumber, nil
+}
+
+// openShiftTx returns the cashier's open shift, locked against closing until
+// tx ends, or ErrNoOpenShift.
+func (s *ReceiptService) openShiftTx(tx *sql.Tx, employeeID string) (models.ShiftRetrieve, error) {
+	shift, err := s.shiftRepo.RetrieveOpenShiftByEmployeeTx(tx, employeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ShiftRetrieve{}, ErrNoOpenShift
+	}
+	if err != nil {
+		return models.ShiftRetrieve{}, fmt.Errorf("failed to retrieve open shift: %w", err)
+	}
+	return shift, nil
+}
+
+// shiftAtTx returns the cashier's shift that was open at the given time, even
+// if it has closed since, locked like openShiftTx, or ErrNoShiftAtTime.
+func (s *ReceiptService) shiftAtTx(tx *sql.Tx, employeeID string, at time.Time) (models.ShiftRetrieve, error) {
+	shift, err := s.shiftRepo.RetrieveShiftAtTx(tx, employeeID, at)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ShiftRetrieve{}, fmt.Errorf("%w: %s", ErrNoShiftAtTime, at.Format(time.RFC3339))
+	}
+	if err != nil {
+		return models.ShiftRetrieve{}, fmt.Errorf("failed to retrieve shift at %s: %w", at.Format(time.RFC3339), err)
+	}
+	return shift, nil
 }
 
 func (s *ReceiptService) GetReceiptByReceiptNumber(receiptNumber string) (models.ReceiptRetrieve, error) {
 	return s.receiptRepo.RetrieveReceiptByReceiptNumber(receiptNumber)
 }
 
-func (s *ReceiptService) GetReceipts() ([]models.ReceiptRetrieve, error) {
-	return s.receiptRepo.RetrieveReceipts()
+func (s *ReceiptService) GetReceiptVAT(receiptNumber string) ([]models.ReceiptVATLine, error) {
+	return s.receiptRepo.RetrieveReceiptVAT(receiptNumber)
+}
+
+// GetReceipts returns one page of receipts matching f together with the
+// cursor of the next page, which is empty on the last page. A non-empty
+// cursor must come from a listing with the same sort and direction.
+func (s *ReceiptService) GetReceipts(f models.ReceiptFilter, sort string, desc bool, limit int, cursor string) ([]models.ReceiptRetrieve, string, error) {
+	if _, err := receiptSortValue(models.ReceiptRetrieve{}, sort); err != nil {
+		return nil, "", err
+	}
+	if limit <= 0 || limit > MaxReceiptPageSize {
+		limit = DefaultReceiptPageSize
+	}
+
+	page := models.ReceiptPage{Sort: sort, Desc: desc, Limit: limit + 1}
+	if cursor != "" {
+		after, err := decodeReceiptCursor(cursor)
+		if err != nil || after.Sort != sort || after.Desc != desc {
+			return nil, "", ErrInvalidReceiptCursor
+		}
+		page.After = &after
+	}
+
+	receipts, err := s.receiptRepo.RetrieveReceipts(f, page)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(receipts) <= limit {
+		return receipts, "", nil
+	}
+
+	receipts = receipts[:limit]
+	last := receipts[limit-1]
+	value, _ := receiptSortValue(last, sort)
+	next, err := encodeReceiptCursor(models.ReceiptCursor{
+		Sort:          sort,
+		Desc:          desc,
+		Value:         value,
+		ReceiptNumber: *last.ReceiptNumber,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return receipts, next, nil
+}
+
+// receiptSortValue renders the sort key of a receipt the way the listing
+// query compares it, NULLs included.
+func receiptSortValue(r models.ReceiptRetrieve, sort string) (string, error) {
+	switch sort {
+	case "print_date":
+		if r.PrintDate == nil {
+			return "", nil
+		}
+		return r.PrintDate.Format(time.RFC3339Nano), nil
+	case "sum_total":
+		if r.TotalSum == nil {
+			return "", nil
+		}
+		return r.TotalSum.String(), nil
+	case "employee_id":
+		if r.EmployeeId == nil {
+			return "", nil
+		}
+		return *r.EmployeeId, nil
+	case "card_number":
+		if r.CardNumber == nil {
+			return "", nil
+		}
+		return *r.CardNumber, nil
+	case "voided_at":
+		if r.VoidedAt == nil {
+			return "-infinity", nil
+		}
+		return r.VoidedAt.Format(time.RFC3339Nano), nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrInvalidReceiptSort, sort)
+	}
+}
+
+func encodeReceiptCursor(c models.ReceiptCursor) (string, error) {
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func decodeReceiptCursor(cursor string) (models.ReceiptCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return models.ReceiptCursor{}, err
+	}
+	var c models.ReceiptCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return models.ReceiptCursor{}, err
+	}
+	if err := checkReceiptSortValue(c.Value, c.Sort); err != nil {
+		return models.ReceiptCursor{}, err
+	}
+	return c, nil
+}
+
+// checkReceiptSortValue makes sure a cursor value parses as the type the
+// listing query casts it to, the way receiptSortValue renders it, so that a
+// stale or tampered cursor is rejected before it reaches the database.
+func checkReceiptSortValue(value, sort string) error {
+	var err error
+	switch sort {
+	case "print_date":
+		_, err = time.Parse(time.RFC3339Nano, value)
+	case "sum_total":
+		_, err = models.ParseMoney(value)
+	case "employee_id", "card_number":
+	case "voided_at":
+		if value != "-infinity" {
+			_, err = time.Parse(time.RFC3339Nano, value)
+		}
+	default:
+		err = fmt.Errorf("%w: %q", ErrInvalidReceiptSort, sort)
+	}
+	return err
 }
 
+// DeleteReceipt only removes receipts without sale lines; anything that has
+// touched stock has to go through VoidReceipt.
 func (s *ReceiptService) DeleteReceipt(receiptNumber string) error {
+	sales, err := s.saleRepo.RetrieveSalesByReceipt(receiptNumber)
+	if err != nil {
+		return err
+	}
+	if len(sales) > 0 {
+		return ErrReceiptHasSales
+	}
 	return s.receiptRepo.DeleteReceipt(receiptNumber)
 }
 
+// cardDiscountTx returns the discount percent of the customer card, or 0
+// without a card.
+func (s *ReceiptService) cardDiscountTx(tx *sql.Tx, cardNumber *string) (int, error) {
+	if cardNumber == nil {
+		return 0, nil
+	}
+	card, err := s.customerCardRepo.RetrieveCustomerCardByCardNumberTx(tx, *cardNumber)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownCustomerCard, *cardNumber)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve customer card %s: %w", *cardNumber, err)
+	}
+	if card.Percent == nil {
+		return 0, nil
+	}
+	return *card.Percent, nil
+}
+
+// UpdateReceipt changes the receipt header. Fields left nil keep their
+// value. A different card changes the discount, so the totals and line VAT
+// are derived again.
 func (s *ReceiptService) UpdateReceipt(receiptNumber string, c models.ReceiptUpdate) error {
-	return s.receiptRepo.UpdateReceipt(receiptNumber, c)
+	tx, err := s.receiptRepo.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	receipt, err := s.receiptRepo.RetrieveReceiptForUpdateTx(tx, receiptNumber)
+	if err != nil {
+		return err
+	}
+	if receipt.VoidedAt != nil {
+		return ErrReceiptVoided
+	}
+
+	if c.EmployeeId == nil {
+		c.EmployeeId = receipt.EmployeeId
+	}
+	if c.PrintDate == nil {
+		c.PrintDate = receipt.PrintDate
+	}
+	c.DiscountPercent = receipt.DiscountPercent
+	if c.CardNumber == nil {
+		c.CardNumber = receipt.CardNumber
+	} else if receipt.CardNumber == nil || *c.CardNumber != *receipt.CardNumber {
+		discountPercent, err := s.cardDiscountTx(tx, c.CardNumber)
+		if err != nil {
+			return err
+		}
+		c.DiscountPercent = &discountPercent
+	}
+
+	if err := s.receiptRepo.UpdateReceiptTx(tx, receiptNumber, c); err != nil {
+		return err
+	}
+	receipt.DiscountPercent = c.DiscountPercent
+	if _, err := recalculateReceiptTx(tx, s.receiptRepo, s.saleRepo, receipt); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-func (s *ReceiptService) CreateReceiptComplete(c models.ReceiptCreateComplete, vatRate float64) (string, error) {
-	// Validate stock availability first
-	for _, item := range c.Items {
-		available, err := s.storeProductRepo.CheckStockAvailability(*item.UPC, *item.ProductNumber)
+// VoidReceipt marks the receipt as voided and puts every unit that has not
+// already been returned back into stock, all in one transaction.
+func (s *ReceiptService) VoidReceipt(receiptNumber string, v models.ReceiptVoid) error {
+	tx, err := s.receiptRepo.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	receipt, err := s.receiptRepo.RetrieveReceiptForUpdateTx(tx, receiptNumber)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve receipt %s: %w", receiptNumber, err)
+	}
+	if receipt.VoidedAt != nil {
+		return ErrReceiptVoided
+	}
+
+	sales, err := s.returnRepo.RetrieveReturnableSalesTx(tx, receiptNumber)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve sales: %w", err)
+	}
+
+	if err := s.receiptRepo.VoidReceiptTx(tx, receiptNumber, v); err != nil {
+		return fmt.Errorf("failed to void receipt: %w", err)
+	}
+
+	for _, sale := range sales {
+		remaining := sale.ProductNumber - sale.Returned
+		if remaining <= 0 {
+			continue
+		}
+		err = s.storeProductRepo.UpdateProductQuantityTx(tx, models.StockMovementCreate{
+			UPC:        sale.UPC,
+			Delta:      remaining,
+			Kind:       models.StockMovementVoid,
+			EmployeeId: v.VoidedBy,
+			Reason:     v.Reason,
+			Reference:  &receiptNumber,
+		})
 		if err != nil {
-			return "", fmt.Errorf("failed to check stock for UPC %s: %w", *item.UPC, err)
+			return fmt.Errorf("failed to restore stock for UPC %s: %w", sale.UPC, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit void: %w", err)
+	}
+
+	return nil
+}
+
+// InsufficientStockError is returned by CreateReceiptComplete when one or more
+// UPCs do not have enough units on hand. Nothing is written in that case.
+type InsufficientStockError struct {
+	Shortages []models.StockShortage
+}
+
+func (e *InsufficientStockError) Error() string {
+	upcs := make([]string, 0, len(e.Shortages))
+	for _, shortage := range e.Shortages {
+		upcs = append(upcs, shortage.UPC)
+	}
+	return fmt.Sprintf("insufficient stock for UPC %s", strings.Join(upcs, ", "))
+}
+
+// mergeReceiptItems folds repeated UPCs into a single line, keeping the order
+// in which they were first scanned.
+func mergeReceiptItems(items []models.ReceiptItem) []models.ReceiptItem {
+	merged := make([]models.ReceiptItem, 0, len(items))
+	index := make(map[string]int, len(items))
+	for _, item := range items {
+		if i, ok := index[*item.UPC]; ok {
+			quantity := *merged[i].ProductNumber + *item.ProductNumber
+			merged[i].ProductNumber = &quantity
+			continue
+		}
+		index[*item.UPC] = len(merged)
+		merged = append(merged, item)
+	}
+	return merged
+}
+
+// CreateReceiptComplete writes the receipt, its sales and the stock decrements
+// in a single transaction. The affected store_product rows are locked for the
+// duration of the checkout so that concurrent receipts cannot oversell a UPC.
+// Unit prices are always taken from store_product, never from the client, and
+// the receipt is only written when its tenders cover the total. Each line is
+// taxed at its product or category rate, or defaultVATRate when neither is set.
+// The receipt goes into the cashier's open shift and is rejected with
+// ErrNoOpenShift when there is none.
+func (s *ReceiptService) CreateReceiptComplete(c models.ReceiptCreateComplete, defaultVATRate models.Rate) (models.ReceiptCompleteResult, error) {
+	tx, err := s.receiptRepo.BeginTx()
+	if err != nil {
+		return models.ReceiptCompleteResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := s.CreateReceiptCompleteTx(tx, c, defaultVATRate)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ReceiptCompleteResult{}, fmt.Errorf("failed to commit receipt: %w", err)
+	}
+
+	return result, nil
+}
+
+// CreateReceiptCompleteTx is the checkout itself, run inside a transaction
+// owned by the caller.
+func (s *ReceiptService) CreateReceiptCompleteTx(tx *sql.Tx, c models.ReceiptCreateComplete, defaultVATRate models.Rate) (models.ReceiptCompleteResult, error) {
+	shift, err := s.openShiftTx(tx, *c.EmployeeId)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, err
+	}
+	result, _, err := s.checkoutTx(tx, shift, c, defaultVATRate, false)
+	return result, err
+}
+
+// CreateOfflineReceiptTx checks out a receipt a till completed while offline.
+// The goods have already left the store, so a UPC whose stock does not cover
+// the sale is sold anyway: its stock goes negative and the shortage is
+// returned to be flagged. Unknown UPCs are still rejected. The receipt goes
+// into the cashier's shift at its print date, which may have closed since.
+func (s *ReceiptService) CreateOfflineReceiptTx(tx *sql.Tx, c models.ReceiptCreateComplete, defaultVATRate models.Rate) (models.ReceiptCompleteResult, []models.StockShortage, error) {
+	shift, err := s.shiftAtTx(tx, *c.EmployeeId, *c.PrintDate)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, nil, err
+	}
+	return s.checkoutTx(tx, shift, c, defaultVATRate, true)
+}
+
+// checkoutTx writes the receipt into shift with its sales and payments and
+// takes the units out of stock. Without overdraw a shortage rejects the
+// whole receipt.
+func (s *ReceiptService) checkoutTx(tx *sql.Tx, shift models.ShiftRetrieve, c models.ReceiptCreateComplete, defaultVATRate models.Rate, overdraw bool) (models.ReceiptCompleteResult, []models.StockShortage, error) {
+	items := mergeReceiptItems(c.Items)
+
+	scanned := make([]string, 0, len(items))
+	for _, item := range items {
+		scanned = append(scanned, *item.UPC)
+	}
+	storeProducts, err := s.storeProductRepo.LockStoreProductsWithPromotionsTx(tx, scanned)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, nil, fmt.Errorf("failed to lock store products: %w", err)
+	}
+
+	// Sell each scanned UPC as the UPC it resolves to, then merge again in
+	// case the promotional UPC was also scanned on its own
+	for i, item := range items {
+		storeProduct, ok := storeProducts[*item.UPC]
+		if !ok {
+			continue
+		}
+		upc := saleStoreProduct(storeProduct, promotionOf(storeProducts, storeProduct), *item.ProductNumber).UPC
+		items[i].UPC = &upc
+	}
+	items = mergeReceiptItems(items)
+
+	upcs := make([]string, 0, len(items))
+	for _, item := range items {
+		upcs = append(upcs, *item.UPC)
+	}
+
+	// Validate stock availability against the locked rows
+	var shortages, overdrawn []models.StockShortage
+	for _, item := range items {
+		storeProduct, ok := storeProducts[*item.UPC]
+		if ok && storeProduct.ProductsNumber >= *item.ProductNumber {
+			continue
+		}
+		shortage := models.StockShortage{
+			UPC:       *item.UPC,
+			Requested: *item.ProductNumber,
+			Available: storeProduct.ProductsNumber,
 		}
-		if !available {
-			return "", fmt.Errorf("insufficient stock for UPC %s", *item.UPC)
+		if ok && overdraw {
+			overdrawn = append(overdrawn, shortage)
+			continue
 		}
+		shortages = append(shortages, shortage)
+	}
+	if len(shortages) > 0 {
+		return models.ReceiptCompleteResult{}, nil, &InsufficientStockError{Shortages: shortages}
+	}
+
+	rates, err := s.storeProductRepo.RetrieveVATRatesTx(tx, upcs)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, nil, fmt.Errorf("failed to resolve VAT rates: %w", err)
+	}
+
+	lines := make([]models.ReceiptLine, 0, len(items))
+	for _, item := range items {
+		vatRate := vatRateFor(rates, *item.UPC, defaultVATRate)
+		lines = append(lines, priceLine(storeProducts[*item.UPC], *item.ProductNumber, vatRate))
+	}
+
+	discountPercent, err := s.cardDiscountTx(tx, c.CardNumber)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, nil, err
 	}
+	totals := calculateTotals(lines, discountPercent)
 
-	// Calculate totals
-	var totalSum float64 = 0
-	for _, item := range c.Items {
-		totalSum += float64(*item.ProductNumber) * *item.SellingPrice
+	// An offline receipt was paid at the till's prices, so tenders that no
+	// longer settle the total are kept as paid and flagged instead
+	payments, change, err := allocateTenders(totals.TotalSum, c.Payments)
+	var mismatch *models.TenderMismatch
+	if overdraw && (errors.Is(err, ErrTendersDoNotCover) || errors.Is(err, ErrNonCashOverpaid)) {
+		payments, mismatch = recordTenders(totals.TotalSum, c.Payments)
+		change, err = models.Money{}, nil
+	}
+	if err != nil {
+		return models.ReceiptCompleteResult{}, nil, err
 	}
-	vat := vatRate * totalSum
 
 	// Create receipt
+	number, err := s.receiptNumberTx(tx, shift)
+	if err != nil {
+		return models.ReceiptCompleteResult{}, nil, err
+	}
 	receipt := models.ReceiptCreate{
-		EmployeeId: c.EmployeeId,
-		CardNumber: c.CardNumber,
-		PrintDate:  c.PrintDate,
-		TotalSum:   &totalSum,
-		VAT:        &vat,
+		ReceiptNumber:   number,
+		EmployeeId:      c.EmployeeId,
+		CardNumber:      c.CardNumber,
+		PrintDate:       c.PrintDate,
+		TotalSum:        &totals.TotalSum,
+		VAT:             &totals.VAT,
+		DiscountPercent: &totals.DiscountPercent,
+		DiscountSum:     &totals.DiscountSum,
+		ShiftID:         &shift.ShiftID,
 	}
 
-	receiptNumber, err := s.receiptRepo.CreateReceipt(receipt)
+	receiptNumber, err := s.receiptRepo.CreateReceiptTx(tx, receipt)
 	if err != nil {
-		return "", fmt.Errorf("failed to create receipt: %w", err)
+		return models.ReceiptCompleteResult{}, nil, fmt.Errorf("failed to create receipt: %w", err)
 	}
 
-	// Create sales using existing SaleRepo
-	for _, item := range c.Items {
+	for _, line := range lines {
 		sale := models.SaleCreate{
-			UPC:           *item.UPC,
+			UPC:           line.UPC,
 			ReceiptNumber: receiptNumber,
-			ProductNumber: *item.ProductNumber,
-			SellingPrice:  *item.SellingPrice,
+			ProductNumber: line.ProductNumber,
+			SellingPrice:  line.UnitPrice,
+			VATRate:       line.VATRate,
+			VATSum:        line.VATSum,
 		}
 
-		err = s.saleRepo.CreateSale(sale)
+		err = s.saleRepo.CreateSaleTx(tx, sale)
 		if err != nil {
-			return "", fmt.Errorf("failed to create sale for UPC %s: %w", *item.UPC, err)
+			return models.ReceiptCompleteResult{}, nil, fmt.Errorf("failed to create sale for UPC %s: %w", line.UPC, err)
 		}
 
-		// Update stock using existing StoreProductRepo
-		err = s.storeProductRepo.UpdateProductQuantity(*item.UPC, -*item.ProductNumber)
+		movement := models.StockMovementCreate{
+			UPC:        line.UPC,
+			Delta:      -line.ProductNumber,
+			Kind:       models.StockMovementSale,
+			EmployeeId: c.EmployeeId,
+			Reference:  &receiptNumber,
+		}
+		if overdraw {
+			err = s.storeProductRepo.UpdateProductQuantityUncheckedTx(tx, movement)
+		} else {
+			err = s.storeProductRepo.UpdateProductQuantityTx(tx, movement)
+		}
 		if err != nil {
-			return "", fmt.Errorf("failed to update stock for UPC %s: %w", *item.UPC, err)
+			return models.ReceiptCompleteResult{}, nil, fmt.Errorf("failed to update stock for UPC %s: %w", line.UPC, err)
 		}
 	}
 
-	return receiptNumber, nil
+	for i := range payments {
+		payments[i].ReceiptNumber = receiptNumber
+		payments[i].PaymentID, err = s.paymentRepo.CreatePaymentTx(tx, payments[i])
+		if err != nil {
+			return models.ReceiptCompleteResult{}, nil, fmt.Errorf("failed to record %s payment: %w", payments[i].Tender, err)
+		}
+	}
+
+	return models.ReceiptCompleteResult{
+		ReceiptNumber:   receiptNumber,
+		Subtotal:        totals.Subtotal,
+		DiscountPercent: totals.DiscountPercent,
+		DiscountSum:     totals.DiscountSum,
+		TotalSum:        totals.TotalSum,
+		VAT:             totals.VAT,
+		VATBreakdown:    totals.VATBreakdown,
+		Lines:           lines,
+		Payments:        payments,
+		Change:          change,
+		TenderMismatch:  mismatch,
+	}, overdrawn, nil
 }
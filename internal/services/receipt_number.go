@@ -0,0 +1,84 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+const (
+	// receiptNumberLength is the width of receipt.receipt_number.
+	receiptNumberLength = 10
+	// minReceiptSequenceDigits leaves room for 9999999 receipts per register,
+	// which caps sequentially numbered register IDs at two characters. A
+	// three character ID such as R01 would leave only 999999 numbers for the
+	// lifetime of the register, since numbers do not restart.
+	minReceiptSequenceDigits = 7
+	MaxSequentialRegisterID  = receiptNumberLength - 1 - minReceiptSequenceDigits
+	// receiptNumberWarnPercent is the share of a register's numbers after
+	// which every receiptNumberWarnEvery-th number logs a warning.
+	receiptNumberWarnPercent = 90
+	receiptNumberWarnEvery   = 1000
+)
+
+var (
+	ErrRegisterIDTooLong       = fmt.Errorf("register ID must be at most %d characters with sequential receipt numbering", MaxSequentialRegisterID)
+	ErrReceiptNumbersExhausted = errors.New("register has run out of receipt numbers")
+)
+
+// receiptSequenceCapacity is the largest sequence number that fits beside
+// registerID, or 0 if there is no room for a sequence at all.
+func receiptSequenceCapacity(registerID string) int {
+	capacity := 0
+	for digits := receiptNumberLength - len(registerID) - 1; digits > 0; digits-- {
+		capacity = capacity*10 + 9
+	}
+	return capacity
+}
+
+// formatReceiptNumber writes the register ID and the zero padded sequence
+// number into the full width of a receipt number, e.g. R1-0000123.
+func formatReceiptNumber(registerID string, number int) (string, error) {
+	if number > receiptSequenceCapacity(registerID) {
+		return "", fmt.Errorf("%w: %s", ErrReceiptNumbersExhausted, registerID)
+	}
+	digits := receiptNumberLength - len(registerID) - 1
+	return fmt.Sprintf("%s-%0*d", registerID, digits, number), nil
+}
+
+// warnReceiptNumbers logs while a register is running low on numbers, so it
+// can be given a new ID long before checkout on it stops.
+func warnReceiptNumbers(registerID string, number int) {
+	capacity := receiptSequenceCapacity(registerID)
+	left := capacity - number
+	if number*100 < capacity*receiptNumberWarnPercent || left < 0 || left%receiptNumberWarnEvery != 0 {
+		return
+	}
+	log.Printf("[ReceiptService] Register %s has %d of %d receipt numbers left", registerID, left, capacity)
+}
+
+// receiptNumberTx picks the number of a receipt written into shift. With
+// sequential numbering it is the register's next number, taken inside tx so
+// the sequence has no gaps; with random numbering it is left to the repo.
+// A register that has run out of numbers gets ErrReceiptNumbersExhausted,
+// and the rolled back transaction gives the sequence value back.
+func (s *ReceiptService) receiptNumberTx(tx *sql.Tx, shift models.ShiftRetrieve) (*string, error) {
+	if s.cfg.RECEIPT_NUMBERING != config.ReceiptNumberingSequential {
+		return nil, nil
+	}
+
+	number, err := s.receiptRepo.NextReceiptSequenceTx(tx, shift.RegisterId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take receipt number on register %s: %w", shift.RegisterId, err)
+	}
+	receiptNumber, err := formatReceiptNumber(shift.RegisterId, number)
+	if err != nil {
+		return nil, err
+	}
+	warnReceiptNumbers(shift.RegisterId, number)
+	return &receiptNumber, nil
+}
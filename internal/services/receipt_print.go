@@ -0,0 +1,279 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+	"github.com/velosypedno/zlagoda/internal/utils"
+)
+
+// DefaultReceiptTemplate is used when RECEIPT_TEMPLATE is not set. Layout
+// helpers take the column width from the request, see receiptTemplateFuncs.
+const DefaultReceiptTemplate = `{{center .StoreName}}
+{{if .StoreAddress}}{{center .StoreAddress}}
+{{end}}{{if .StoreTaxID}}{{center (printf "Tax ID %s" .StoreTaxID)}}
+{{end}}{{rule "="}}
+{{columns "Receipt" .ReceiptNumber}}
+{{columns "Date" (.PrintDate.Format "2006-01-02 15:04")}}
+{{columns "Cashier" .Cashier}}
+{{if .Voided}}{{center "*** VOIDED ***"}}
+{{end}}{{rule "-"}}
+{{range .Lines}}{{wrap .ProductName}}
+{{columns (printf "  %d x %s" .Quantity (money .SellingPrice)) (money .TotalPrice)}}
+{{end}}{{rule "-"}}
+{{if not .DiscountSum.IsZero}}{{columns "Subtotal" (money .Subtotal)}}
+{{columns (printf "Card discount %d%%" .DiscountPercent) (printf "-%s" (money .DiscountSum))}}
+{{end}}{{columns "TOTAL" (money .TotalSum)}}
+{{range .VAT}}{{columns (printf "incl. VAT %s%%" (percent .Rate)) (money .Amount)}}
+{{end}}{{if .CardNumber}}{{columns "Card" .CardNumber}}
+{{end}}{{if .Payments}}{{rule "-"}}
+{{range .Payments}}{{columns (printf "Paid %s" .Tender) (money .Tendered)}}
+{{end}}{{if not .Change.IsZero}}{{columns "Change" (money .Change)}}
+{{end}}{{end}}{{rule "="}}
+{{center "Thank you for your purchase!"}}
+`
+
+const (
+	minReceiptWidth = 24
+	maxReceiptWidth = 64
+)
+
+var ErrInvalidReceiptWidth = fmt.Errorf("receipt width must be between %d and %d", minReceiptWidth, maxReceiptWidth)
+
+type PrintReceiptRepo interface {
+	RetrieveReceiptByReceiptNumber(receiptNumber string) (models.ReceiptRetrieve, error)
+	RetrieveReceiptVAT(receiptNumber string) ([]models.ReceiptVATLine, error)
+}
+
+type PrintSaleRepo interface {
+	RetrieveSalesWithDetailsByReceipt(receiptNumber string) ([]models.SaleWithDetails, error)
+}
+
+type PrintEmployeeRepo interface {
+	RetrieveEmployeeById(id string) (models.EmployeeRetrieve, error)
+}
+
+type PrintPaymentRepo interface {
+	RetrievePaymentsByReceipt(receiptNumber string) ([]models.PaymentRetrieve, error)
+}
+
+// ReceiptPrintService renders receipts for thermal till printers.
+type ReceiptPrintService struct {
+	receiptRepo  PrintReceiptRepo
+	saleRepo     PrintSaleRepo
+	employeeRepo PrintEmployeeRepo
+	paymentRepo  PrintPaymentRepo
+	cfg          *config.Config
+}
+
+func NewReceiptPrintService(receiptRepo PrintReceiptRepo, saleRepo PrintSaleRepo, employeeRepo PrintEmployeeRepo, paymentRepo PrintPaymentRepo, cfg *config.Config) *ReceiptPrintService {
+	return &ReceiptPrintService{
+		receiptRepo:  receiptRepo,
+		saleRepo:     saleRepo,
+		employeeRepo: employeeRepo,
+		paymentRepo:  paymentRepo,
+		cfg:          cfg,
+	}
+}
+
+// GetReceiptPrint collects the receipt, its sale lines and the cashier into
+// the data the template is rendered from. A zero width uses RECEIPT_WIDTH.
+func (s *ReceiptPrintService) GetReceiptPrint(receiptNumber string, width int) (models.ReceiptPrint, error) {
+	if width == 0 {
+		width = s.cfg.RECEIPT_WIDTH
+	}
+	if width < minReceiptWidth || width > maxReceiptWidth {
+		return models.ReceiptPrint{}, ErrInvalidReceiptWidth
+	}
+
+	receipt, err := s.receiptRepo.RetrieveReceiptByReceiptNumber(receiptNumber)
+	if err != nil {
+		return models.ReceiptPrint{}, err
+	}
+
+	sales, err := s.saleRepo.RetrieveSalesWithDetailsByReceipt(receiptNumber)
+	if err != nil {
+		return models.ReceiptPrint{}, fmt.Errorf("failed to retrieve sales: %w", err)
+	}
+
+	doc := models.ReceiptPrint{
+		StoreName:     s.cfg.STORE_NAME,
+		StoreAddress:  s.cfg.STORE_ADDRESS,
+		StoreTaxID:    s.cfg.STORE_TAX_ID,
+		ReceiptNumber: receiptNumber,
+		Voided:        receipt.VoidedAt != nil,
+		Width:         width,
+	}
+	if receipt.PrintDate != nil {
+		doc.PrintDate = receipt.PrintDate.In(s.cfg.STORE_LOCATION)
+	}
+	if receipt.CardNumber != nil {
+		doc.CardNumber = *receipt.CardNumber
+	}
+	if receipt.TotalSum != nil {
+		doc.TotalSum = *receipt.TotalSum
+	}
+	if receipt.DiscountPercent != nil {
+		doc.DiscountPercent = *receipt.DiscountPercent
+	}
+	if receipt.DiscountSum != nil {
+		doc.DiscountSum = *receipt.DiscountSum
+	}
+
+	if receipt.EmployeeId != nil {
+		employee, err := s.employeeRepo.RetrieveEmployeeById(*receipt.EmployeeId)
+		if err != nil {
+			return models.ReceiptPrint{}, fmt.Errorf("failed to retrieve cashier: %w", err)
+		}
+		doc.Cashier = strings.TrimSpace(fmt.Sprintf("%s %s", stringOrEmpty(employee.Surname), stringOrEmpty(employee.Name)))
+	}
+
+	for _, sale := range sales {
+		doc.Lines = append(doc.Lines, models.ReceiptPrintLine{
+			UPC:          sale.UPC,
+			ProductName:  sale.ProductName,
+			Quantity:     sale.ProductNumber,
+			SellingPrice: sale.SellingPrice,
+			TotalPrice:   sale.TotalPrice,
+		})
+		doc.Subtotal = doc.Subtotal.Add(sale.TotalPrice)
+	}
+
+	doc.VAT, err = s.receiptRepo.RetrieveReceiptVAT(receiptNumber)
+	if err != nil {
+		return models.ReceiptPrint{}, fmt.Errorf("failed to retrieve VAT breakdown: %w", err)
+	}
+
+	doc.Payments, err = s.paymentRepo.RetrievePaymentsByReceipt(receiptNumber)
+	if err != nil {
+		return models.ReceiptPrint{}, fmt.Errorf("failed to retrieve payments: %w", err)
+	}
+	for _, payment := range doc.Payments {
+		doc.Change = doc.Change.Add(payment.Tendered.Sub(payment.Amount))
+	}
+
+	return doc, nil
+}
+
+// RenderReceiptText lays the receipt out as fixed-width text.
+func (s *ReceiptPrintService) RenderReceiptText(receiptNumber string, width int) (string, error) {
+	doc, err := s.GetReceiptPrint(receiptNumber, width)
+	if err != nil {
+		return "", err
+	}
+
+	source := DefaultReceiptTemplate
+	if s.cfg.RECEIPT_TEMPLATE != "" {
+		content, err := os.ReadFile(s.cfg.RECEIPT_TEMPLATE)
+		if err != nil {
+			return "", fmt.Errorf("failed to read receipt template: %w", err)
+		}
+		source = string(content)
+	}
+
+	tmpl, err := template.New("receipt").Funcs(receiptTemplateFuncs(doc.Width)).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse receipt template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, doc); err != nil {
+		return "", fmt.Errorf("failed to render receipt: %w", err)
+	}
+
+	return buf.String(), nil
+}
+
+// RenderReceiptESCPOS renders the text layout and wraps it in the ESC/POS
+// commands a thermal printer expects.
+func (s *ReceiptPrintService) RenderReceiptESCPOS(receiptNumber string, width int) ([]byte, error) {
+	text, err := s.RenderReceiptText(receiptNumber, width)
+	if err != nil {
+		return nil, err
+	}
+	return utils.EncodeESCPOS(text, s.cfg.RECEIPT_ESCPOS_CODEPAGE)
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// receiptTemplateFuncs are the layout helpers available to receipt
+// templates. Lengths are counted in runes so Cyrillic lines up.
+func receiptTemplateFuncs(width int) template.FuncMap {
+	return template.FuncMap{
+		"rule": func(char string) string {
+			return strings.Repeat(char, width)
+		},
+		"center": func(text string) string {
+			text = truncateRunes(text, width)
+			pad := (width - utf8.RuneCountInString(text)) / 2
+			return strings.Repeat(" ", pad) + text
+		},
+		"columns": func(left, right string) string {
+			right = truncateRunes(right, width)
+			left = truncateRunes(left, width-utf8.RuneCountInString(right)-1)
+			pad := width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+			return left + strings.Repeat(" ", pad) + right
+		},
+		"wrap": func(text string) string {
+			return strings.Join(wrapRunes(text, width), "\n")
+		},
+		"money": func(amount models.Money) string {
+			return amount.Round().String()
+		},
+		"percent": func(rate models.Rate) string {
+			return rate.Percent()
+		},
+	}
+}
+
+func truncateRunes(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n])
+}
+
+// wrapRunes breaks text into lines of at most width runes, on spaces where
+// possible.
+func wrapRunes(text string, width int) []string {
+	var lines []string
+	var current []rune
+	for _, word := range strings.Fields(text) {
+		runes := []rune(word)
+		for len(runes) > width {
+			if len(current) > 0 {
+				lines = append(lines, string(current))
+				current = nil
+			}
+			lines = append(lines, string(runes[:width]))
+			runes = runes[width:]
+		}
+		if len(current) > 0 && len(current)+1+len(runes) > width {
+			lines = append(lines, string(current))
+			current = nil
+		}
+		if len(current) > 0 {
+			current = append(current, ' ')
+		}
+		current = append(current, runes...)
+	}
+	if len(current) > 0 {
+		lines = append(lines, string(current))
+	}
+	return lines
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type ReceiptSyncRepo interface {
+	BeginTx() (*sql.Tx, error)
+	RetrieveSyncedReceiptNumber(clientID string) (string, error)
+	RetrieveSyncedReceiptNumberTx(tx *sql.Tx, clientID string) (string, error)
+	CreateReceiptSyncTx(tx *sql.Tx, clientID, receiptNumber string, syncedAt time.Time) error
+	CreateReceiptSyncConflictTx(tx *sql.Tx, clientID string, s models.StockShortage) error
+	RetrieveReceiptSyncConflicts() ([]models.ReceiptSyncConflict, error)
+	CreateReceiptSyncTenderMismatchTx(tx *sql.Tx, clientID string, m models.TenderMismatch) error
+	RetrieveReceiptSyncTenderMismatches() ([]models.ReceiptSyncTenderMismatch, error)
+}
+
+type ReceiptSyncCheckout interface {
+	CreateOfflineReceiptTx(tx *sql.Tx, c models.ReceiptCreateComplete, defaultVATRate models.Rate) (models.ReceiptCompleteResult, []models.StockShortage, error)
+}
+
+// ReceiptSyncService uploads the receipts a till queued while it was offline.
+type ReceiptSyncService struct {
+	repo     ReceiptSyncRepo
+	checkout ReceiptSyncCheckout
+	cfg      *config.Config
+}
+
+func NewReceiptSyncService(repo ReceiptSyncRepo, checkout ReceiptSyncCheckout, cfg *config.Config) *ReceiptSyncService {
+	return &ReceiptSyncService{
+		repo:     repo,
+		checkout: checkout,
+		cfg:      cfg,
+	}
+}
+
+// SyncReceipts applies the offline receipts in the order given, each in its
+// own transaction, so that one that fails does not hold back the rest.
+// Receipts whose client ID was synced before are reported as duplicates and
+// left alone, which makes a batch safe to upload again.
+func (s *ReceiptSyncService) SyncReceipts(items []models.ReceiptSyncItem) []models.ReceiptSyncResult {
+	results := make([]models.ReceiptSyncResult, 0, len(items))
+	for _, item := range items {
+		result, err := s.syncReceipt(item)
+		if err != nil {
+			result = s.failedSync(item.ClientID, err)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (s *ReceiptSyncService) syncReceipt(item models.ReceiptSyncItem) (models.ReceiptSyncResult, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.ReceiptSyncResult{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	receiptNumber, err := s.repo.RetrieveSyncedReceiptNumberTx(tx, item.ClientID)
+	if err == nil {
+		return models.ReceiptSyncResult{
+			ClientID:      item.ClientID,
+			Status:        models.ReceiptSyncStatusDuplicate,
+			ReceiptNumber: &receiptNumber,
+		}, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return models.ReceiptSyncResult{}, fmt.Errorf("failed to check client ID %s: %w", item.ClientID, err)
+	}
+
+	receipt, overdrawn, err := s.checkout.CreateOfflineReceiptTx(tx, item.Receipt, s.cfg.VAT_RATE)
+	if err != nil {
+		return models.ReceiptSyncResult{}, err
+	}
+	if err := s.repo.CreateReceiptSyncTx(tx, item.ClientID, receipt.ReceiptNumber, time.Now()); err != nil {
+		return models.ReceiptSyncResult{}, fmt.Errorf("failed to record client ID %s: %w", item.ClientID, err)
+	}
+	for _, shortage := range overdrawn {
+		if err := s.repo.CreateReceiptSyncConflictTx(tx, item.ClientID, shortage); err != nil {
+			return models.ReceiptSyncResult{}, fmt.Errorf("failed to flag UPC %s: %w", shortage.UPC, err)
+		}
+	}
+	if receipt.TenderMismatch != nil {
+		if err := s.repo.CreateReceiptSyncTenderMismatchTx(tx, item.ClientID, *receipt.TenderMismatch); err != nil {
+			return models.ReceiptSyncResult{}, fmt.Errorf("failed to flag payments of %s: %w", item.ClientID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return models.ReceiptSyncResult{}, fmt.Errorf("failed to commit receipt: %w", err)
+	}
+
+	result := models.ReceiptSyncResult{
+		ClientID:      item.ClientID,
+		Status:        models.ReceiptSyncStatusCreated,
+		ReceiptNumber: &receipt.ReceiptNumber,
+		TotalSum:      &receipt.TotalSum,
+	}
+	if len(overdrawn) > 0 || receipt.TenderMismatch != nil {
+		result.Status = models.ReceiptSyncStatusConflict
+		result.Conflicts = overdrawn
+		result.TenderMismatch = receipt.TenderMismatch
+	}
+	return result, nil
+}
+
+// failedSync reports a receipt that could not be written. An upload of the
+// same receipt running at the same time makes this one fail on the client
+// ID; it is then a duplicate rather than a failure.
+func (s *ReceiptSyncService) failedSync(clientID string, err error) models.ReceiptSyncResult {
+	if receiptNumber, lookupErr := s.repo.RetrieveSyncedReceiptNumber(clientID); lookupErr == nil {
+		return models.ReceiptSyncResult{
+			ClientID:      clientID,
+			Status:        models.ReceiptSyncStatusDuplicate,
+			ReceiptNumber: &receiptNumber,
+		}
+	}
+
+	message := err.Error()
+	result := models.ReceiptSyncResult{
+		ClientID: clientID,
+		Status:   models.ReceiptSyncStatusFailed,
+		Error:    &message,
+	}
+	var stockErr *InsufficientStockError
+	if errors.As(err, &stockErr) {
+		result.Conflicts = stockErr.Shortages
+	}
+	return result
+}
+
+func (s *ReceiptSyncService) GetConflicts() ([]models.ReceiptSyncConflict, error) {
+	return s.repo.RetrieveReceiptSyncConflicts()
+}
+
+func (s *ReceiptSyncService) GetTenderMismatches() ([]models.ReceiptSyncTenderMismatch, error) {
+	return s.repo.RetrieveReceiptSyncTenderMismatches()
+}
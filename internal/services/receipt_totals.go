@@ -0,0 +1,213 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+// receiptTotalsUpdater and saleLinesRepo are what recalculateReceiptTx
+// needs from the receipt and sale repos.
+type receiptTotalsUpdater interface {
+	UpdateReceiptTotalsTx(tx *sql.Tx, receiptNumber string, t models.ReceiptTotals) error
+	RetrieveReceiptEditStateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptEditState, error)
+}
+
+type saleLinesRepo interface {
+	RetrieveSalesByReceiptTx(tx *sql.Tx, receiptNumber string) ([]models.SaleRetrieve, error)
+	UpdateSaleVATTx(tx *sql.Tx, upc, receiptNumber string, vatSum models.Money) error
+}
+
+type ReceiptTotalsReceiptRepo interface {
+	receiptTotalsUpdater
+	BeginTx() (*sql.Tx, error)
+	RetrieveReceiptForUpdateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptRetrieve, error)
+	RetrieveReceiptTotals() ([]models.ReceiptRetrieve, error)
+}
+
+type ReceiptTotalsSaleRepo interface {
+	saleLinesRepo
+	RetrieveAllSales() ([]models.SaleRetrieve, error)
+}
+
+// deriveReceiptTotals prices the stored sale lines at their selling price and
+// VAT rate with the receipt's discount, the same way checkout does. The
+// returned lines carry the VAT each sale should store.
+func deriveReceiptTotals(sales []models.SaleRetrieve, discountPercent int) (models.ReceiptTotals, []models.ReceiptLine) {
+	lines := make([]models.ReceiptLine, 0, len(sales))
+	for _, sale := range sales {
+		lines = append(lines, models.ReceiptLine{
+			UPC:           sale.UPC,
+			ProductNumber: sale.ProductNumber,
+			UnitPrice:     sale.SellingPrice,
+			LineTotal:     sale.SellingPrice.Mul(sale.ProductNumber),
+			VATRate:       sale.VATRate,
+		})
+	}
+
+	totals := calculateTotals(lines, discountPercent)
+	return models.ReceiptTotals{
+		TotalSum:    totals.TotalSum,
+		VAT:         totals.VAT,
+		DiscountSum: totals.DiscountSum,
+	}, lines
+}
+
+func storedReceiptTotals(receipt models.ReceiptRetrieve) models.ReceiptTotals {
+	var totals models.ReceiptTotals
+	if receipt.TotalSum != nil {
+		totals.TotalSum = *receipt.TotalSum
+	}
+	if receipt.VAT != nil {
+		totals.VAT = *receipt.VAT
+	}
+	if receipt.DiscountSum != nil {
+		totals.DiscountSum = *receipt.DiscountSum
+	}
+	return totals
+}
+
+func receiptDiscountPercent(receipt models.ReceiptRetrieve) int {
+	if receipt.DiscountPercent == nil {
+		return 0
+	}
+	return *receipt.DiscountPercent
+}
+
+func sameReceiptTotals(a, b models.ReceiptTotals) bool {
+	return a.TotalSum.Equal(b.TotalSum) && a.VAT.Equal(b.VAT) && a.DiscountSum.Equal(b.DiscountSum)
+}
+
+// recalculateReceiptTx derives the totals of a receipt locked by tx from its
+// sale lines and stores them, rewriting the VAT of every line that no longer
+// matches the receipt's discount. A paid receipt keeps its total: new totals
+// that its payments no longer cover are rejected.
+func recalculateReceiptTx(tx *sql.Tx, receipts receiptTotalsUpdater, sales saleLinesRepo, receipt models.ReceiptRetrieve) (models.ReceiptTotals, error) {
+	receiptNumber := *receipt.ReceiptNumber
+	current, err := sales.RetrieveSalesByReceiptTx(tx, receiptNumber)
+	if err != nil {
+		return models.ReceiptTotals{}, fmt.Errorf("failed to retrieve sales of receipt %s: %w", receiptNumber, err)
+	}
+
+	totals, lines := deriveReceiptTotals(current, receiptDiscountPercent(receipt))
+	for i, line := range lines {
+		if line.VATSum.Equal(current[i].VATSum) {
+			continue
+		}
+		if err := sales.UpdateSaleVATTx(tx, line.UPC, receiptNumber, line.VATSum); err != nil {
+			return models.ReceiptTotals{}, fmt.Errorf("failed to update VAT of sale %s: %w", line.UPC, err)
+		}
+	}
+
+	state, err := receipts.RetrieveReceiptEditStateTx(tx, receiptNumber)
+	if err != nil {
+		return models.ReceiptTotals{}, fmt.Errorf("failed to retrieve payments of receipt %s: %w", receiptNumber, err)
+	}
+	if state.Payments > 0 && !totals.TotalSum.Round().Equal(state.Paid) {
+		return models.ReceiptTotals{}, fmt.Errorf("%w: receipt %s was paid %s, its lines total %s", ErrReceiptPaidMismatch, receiptNumber, state.Paid, totals.TotalSum.Round())
+	}
+
+	if err := receipts.UpdateReceiptTotalsTx(tx, receiptNumber, totals); err != nil {
+		return models.ReceiptTotals{}, fmt.Errorf("failed to update totals of receipt %s: %w", receiptNumber, err)
+	}
+	return totals, nil
+}
+
+// ReceiptTotalsService finds and repairs receipts whose stored totals have
+// drifted from their sale lines.
+type ReceiptTotalsService struct {
+	receiptRepo ReceiptTotalsReceiptRepo
+	saleRepo    ReceiptTotalsSaleRepo
+}
+
+func NewReceiptTotalsService(receiptRepo ReceiptTotalsReceiptRepo, saleRepo ReceiptTotalsSaleRepo) *ReceiptTotalsService {
+	return &ReceiptTotalsService{
+		receiptRepo: receiptRepo,
+		saleRepo:    saleRepo,
+	}
+}
+
+// FindMismatches checks every receipt against the totals derived from its
+// lines.
+func (s *ReceiptTotalsService) FindMismatches() ([]models.ReceiptTotalsMismatch, error) {
+	receipts, err := s.receiptRepo.RetrieveReceiptTotals()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve receipts: %w", err)
+	}
+	sales, err := s.saleRepo.RetrieveAllSales()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve sales: %w", err)
+	}
+
+	salesByReceipt := make(map[string][]models.SaleRetrieve)
+	for _, sale := range sales {
+		salesByReceipt[sale.ReceiptNumber] = append(salesByReceipt[sale.ReceiptNumber], sale)
+	}
+
+	mismatches := []models.ReceiptTotalsMismatch{}
+	for _, receipt := range receipts {
+		lines := salesByReceipt[*receipt.ReceiptNumber]
+		derived, priced := deriveReceiptTotals(lines, receiptDiscountPercent(receipt))
+		linesVAT := 0
+		for i, line := range priced {
+			if !line.VATSum.Equal(lines[i].VATSum) {
+				linesVAT++
+			}
+		}
+
+		stored := storedReceiptTotals(receipt)
+		if linesVAT == 0 && sameReceiptTotals(stored, derived) {
+			continue
+		}
+		mismatches = append(mismatches, models.ReceiptTotalsMismatch{
+			ReceiptNumber: *receipt.ReceiptNumber,
+			Stored:        stored,
+			Derived:       derived,
+			LinesVAT:      linesVAT,
+		})
+	}
+	return mismatches, nil
+}
+
+// RepairMismatches recalculates every mismatched receipt, each in its own
+// transaction, and returns what was repaired. A receipt that fails stops the
+// repair; the ones before it stay repaired.
+func (s *ReceiptTotalsService) RepairMismatches() ([]models.ReceiptTotalsMismatch, error) {
+	mismatches, err := s.FindMismatches()
+	if err != nil {
+		return nil, err
+	}
+
+	repaired := []models.ReceiptTotalsMismatch{}
+	for _, mismatch := range mismatches {
+		fixed, err := s.repairReceipt(mismatch)
+		if err != nil {
+			return repaired, err
+		}
+		repaired = append(repaired, fixed)
+	}
+	return repaired, nil
+}
+
+func (s *ReceiptTotalsService) repairReceipt(mismatch models.ReceiptTotalsMismatch) (models.ReceiptTotalsMismatch, error) {
+	tx, err := s.receiptRepo.BeginTx()
+	if err != nil {
+		return mismatch, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	receipt, err := s.receiptRepo.RetrieveReceiptForUpdateTx(tx, mismatch.ReceiptNumber)
+	if err != nil {
+		return mismatch, fmt.Errorf("failed to retrieve receipt %s: %w", mismatch.ReceiptNumber, err)
+	}
+	mismatch.Stored = storedReceiptTotals(receipt)
+	mismatch.Derived, err = recalculateReceiptTx(tx, s.receiptRepo, s.saleRepo, receipt)
+	if err != nil {
+		return mismatch, err
+	}
+	if err := tx.Commit(); err != nil {
+		return mismatch, fmt.Errorf("failed to commit receipt %s: %w", mismatch.ReceiptNumber, err)
+	}
+	return mismatch, nil
+}
@@ -36,7 +36,7 @@ func validate(e models.EmployeeCreate) error {
 	}
 
 	// salary ≥ 0
-	if e.Salary == nil || *e.Salary < 0 {
+	if e.Salary == nil || e.Salary.IsNegative() {
 		return errors.New("salary must be non-negative")
 	}
 
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var ErrReturnExceedsSold = errors.New("return quantity exceeds units left on the receipt")
+
+type ReturnRepo interface {
+	BeginTx() (*sql.Tx, error)
+	RetrieveReturnableSalesTx(tx *sql.Tx, receiptNumber string) ([]models.ReturnableSale, error)
+	CreateReturnTx(tx *sql.Tx, c models.ReturnCreate) (string, error)
+	RetrieveReturnByReturnNumber(returnNumber string) (models.ReturnRetrieve, error)
+	RetrieveReturns() ([]models.ReturnRetrieve, error)
+	RetrieveReturnsByReceipt(receiptNumber string) ([]models.ReturnRetrieve, error)
+}
+
+type ReturnReceiptRepo interface {
+	RetrieveReceiptForUpdateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptRetrieve, error)
+}
+
+type ReturnStoreProductRepo interface {
+	UpdateProductQuantityTx(tx *sql.Tx, m models.StockMovementCreate) error
+}
+
+type ReturnShiftRepo interface {
+	RetrieveOpenShiftByEmployeeTx(tx *sql.Tx, employeeID string) (models.ShiftRetrieve, error)
+}
+
+type ReturnService struct {
+	returnRepo       ReturnRepo
+	receiptRepo      ReturnReceiptRepo
+	storeProductRepo ReturnStoreProductRepo
+	shiftRepo        ReturnShiftRepo
+}
+
+func NewReturnService(returnRepo ReturnRepo, receiptRepo ReturnReceiptRepo, storeProductRepo ReturnStoreProductRepo, shiftRepo ReturnShiftRepo) *ReturnService {
+	return &ReturnService{
+		returnRepo:       returnRepo,
+		receiptRepo:      receiptRepo,
+		storeProductRepo: storeProductRepo,
+		shiftRepo:        shiftRepo,
+	}
+}
+
+// CreateReturn posts a return document against an existing receipt. Returned
+// units go back to stock, and the refund is priced at the original selling
+// price less the discount that was applied to the receipt, with VAT at the
+// rate each line was sold at. A return taken by a cashier with an open shift
+// is refunded from that shift's drawer and counted in its reports.
+func (s *ReturnService) CreateReturn(c models.ReturnCreate) (models.ReturnRetrieve, error) {
+	tx, err := s.returnRepo.BeginTx()
+	if err != nil {
+		return models.ReturnRetrieve{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	receipt, err := s.receiptRepo.RetrieveReceiptForUpdateTx(tx, *c.ReceiptNumber)
+	if err != nil {
+		return models.ReturnRetrieve{}, fmt.Errorf("failed to retrieve receipt %s: %w", *c.ReceiptNumber, err)
+	}
+	if receipt.VoidedAt != nil {
+		return models.ReturnRetrieve{}, ErrReceiptVoided
+	}
+
+	sales, err := s.returnRepo.RetrieveReturnableSalesTx(tx, *c.ReceiptNumber)
+	if err != nil {
+		return models.ReturnRetrieve{}, fmt.Errorf("failed to retrieve sales: %w", err)
+	}
+	salesByUPC := make(map[string]models.ReturnableSale, len(sales))
+	for _, sale := range sales {
+		salesByUPC[sale.UPC] = sale
+	}
+
+	requested := make(map[string]int, len(c.Items))
+	for _, item := range c.Items {
+		sale, ok := salesByUPC[*item.UPC]
+		if !ok {
+			return models.ReturnRetrieve{}, fmt.Errorf("UPC %s was not sold on receipt %s: %w", *item.UPC, *c.ReceiptNumber, ErrReturnExceedsSold)
+		}
+		requested[*item.UPC] += *item.ProductNumber
+		if requested[*item.UPC] > sale.ProductNumber-sale.Returned {
+			return models.ReturnRetrieve{}, fmt.Errorf("UPC %s: %d requested, %d returnable: %w",
+				*item.UPC, requested[*item.UPC], sale.ProductNumber-sale.Returned, ErrReturnExceedsSold)
+		}
+	}
+
+	var items []models.ReturnItem
+	var lines []models.ReceiptLine
+	for _, sale := range sales {
+		quantity, ok := requested[sale.UPC]
+		if !ok {
+			continue
+		}
+		upc, price := sale.UPC, sale.SellingPrice
+		items = append(items, models.ReturnItem{
+			UPC:           &upc,
+			ProductNumber: &quantity,
+			SellingPrice:  &price,
+		})
+		lines = append(lines, models.ReceiptLine{
+			UPC:           upc,
+			ProductNumber: quantity,
+			UnitPrice:     price,
+			LineTotal:     price.Mul(quantity),
+			VATRate:       sale.VATRate,
+		})
+	}
+
+	var discountPercent int = 0
+	if receipt.DiscountPercent != nil {
+		discountPercent = *receipt.DiscountPercent
+	}
+	totals := calculateTotals(lines, discountPercent)
+
+	c.Items = items
+	c.TotalSum = &totals.TotalSum
+	c.VAT = &totals.VAT
+
+	shift, err := s.shiftRepo.RetrieveOpenShiftByEmployeeTx(tx, *c.EmployeeId)
+	if err == nil {
+		c.ShiftID = &shift.ShiftID
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return models.ReturnRetrieve{}, fmt.Errorf("failed to retrieve open shift: %w", err)
+	}
+
+	returnNumber, err := s.returnRepo.CreateReturnTx(tx, c)
+	if err != nil {
+		return models.ReturnRetrieve{}, fmt.Errorf("failed to create return: %w", err)
+	}
+
+	for _, item := range items {
+		err = s.storeProductRepo.UpdateProductQuantityTx(tx, models.StockMovementCreate{
+			UPC:        *item.UPC,
+			Delta:      *item.ProductNumber,
+			Kind:       models.StockMovementReturn,
+			EmployeeId: c.EmployeeId,
+			Reason:     c.Reason,
+			Reference:  &returnNumber,
+		})
+		if err != nil {
+			return models.ReturnRetrieve{}, fmt.Errorf("failed to restore stock for UPC %s: %w", *item.UPC, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.ReturnRetrieve{}, fmt.Errorf("failed to commit return: %w", err)
+	}
+
+	return s.returnRepo.RetrieveReturnByReturnNumber(returnNumber)
+}
+
+func (s *ReturnService) GetReturnByReturnNumber(returnNumber string) (models.ReturnRetrieve, error) {
+	return s.returnRepo.RetrieveReturnByReturnNumber(returnNumber)
+}
+
+func (s *ReturnService) GetReturns() ([]models.ReturnRetrieve, error) {
+	return s.returnRepo.RetrieveReturns()
+}
+
+func (s *ReturnService) GetReturnsByReceipt(receiptNumber string) ([]models.ReturnRetrieve, error) {
+	return s.returnRepo.RetrieveReturnsByReceipt(receiptNumber)
+}
@@ -1,38 +1,159 @@
 package services
 
-import "github.com/velosypedno/zlagoda/internal/models"
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
 
 type SaleRepo interface {
-	CreateSale(s models.SaleCreate) error
+	CreateSaleTx(tx *sql.Tx, s models.SaleCreate) error
 	RetrieveSaleByKey(upc, receiptNumber string) (models.SaleRetrieve, error)
+	RetrieveSaleByKeyTx(tx *sql.Tx, upc, receiptNumber string) (models.SaleRetrieve, error)
 	RetrieveSalesByReceipt(receiptNumber string) ([]models.SaleRetrieve, error)
+	RetrieveSalesByReceiptTx(tx *sql.Tx, receiptNumber string) ([]models.SaleRetrieve, error)
 	RetrieveSalesByUPC(upc string) ([]models.SaleRetrieve, error)
 	RetrieveAllSales() ([]models.SaleRetrieve, error)
 	RetrieveSalesWithDetails() ([]models.SaleWithDetails, error)
 	RetrieveSalesWithDetailsByReceipt(receiptNumber string) ([]models.SaleWithDetails, error)
-	UpdateSale(upc, receiptNumber string, s models.SaleUpdate) error
-	DeleteSale(upc, receiptNumber string) error
-	DeleteSalesByReceipt(receiptNumber string) error
-	GetReceiptTotal(receiptNumber string) (float64, error)
-	GetSalesStatsByProduct(productID int, startDate, endDate string) (int, float64, error)
-	GetTopSellingProducts(limit int) ([]struct {
-		ProductID    int     `json:"product_id"`
-		ProductName  string  `json:"product_name"`
-		TotalSold    int     `json:"total_sold"`
-		TotalRevenue float64 `json:"total_revenue"`
+	UpdateSaleTx(tx *sql.Tx, upc, receiptNumber string, s models.SaleUpdate) error
+	UpdateSaleVATTx(tx *sql.Tx, upc, receiptNumber string, vatSum models.Money) error
+	DeleteSaleTx(tx *sql.Tx, upc, receiptNumber string) error
+	DeleteSalesByReceiptTx(tx *sql.Tx, receiptNumber string) error
+	GetSalesStatsByProduct(productID int, startDate, endDate string) (int, models.Money, error)
+	GetTopSellingProducts(limit int, includeVoided bool) ([]struct {
+		ProductID    int          `json:"product_id"`
+		ProductName  string       `json:"product_name"`
+		TotalSold    int          `json:"total_sold"`
+		TotalRevenue models.Money `json:"total_revenue"`
 	}, error)
 }
 
+type SaleStoreProductRepo interface {
+	RetrieveVATRates(upcs []string) (map[string]models.Rate, error)
+	LockStoreProductsTx(tx *sql.Tx, upcs []string) (map[string]models.StoreProductRetrieve, error)
+	UpdateProductQuantityTx(tx *sql.Tx, m models.StockMovementCreate) error
+}
+
+type SaleReceiptRepo interface {
+	BeginTx() (*sql.Tx, error)
+	RetrieveReceiptByReceiptNumber(receiptNumber string) (models.ReceiptRetrieve, error)
+	RetrieveReceiptForUpdateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptRetrieve, error)
+	UpdateReceiptTotalsTx(tx *sql.Tx, receiptNumber string, t models.ReceiptTotals) error
+	RetrieveReceiptEditStateTx(tx *sql.Tx, receiptNumber string) (models.ReceiptEditState, error)
+}
+
 type SaleService struct {
-	repo SaleRepo
+	repo             SaleRepo
+	storeProductRepo SaleStoreProductRepo
+	receiptRepo      SaleReceiptRepo
+	cfg              *config.Config
 }
 
-func NewSaleService(repo SaleRepo) *SaleService {
-	return &SaleService{repo: repo}
+func NewSaleService(repo SaleRepo, storeProductRepo SaleStoreProductRepo, receiptRepo SaleReceiptRepo, cfg *config.Config) *SaleService {
+	return &SaleService{
+		repo:             repo,
+		storeProductRepo: storeProductRepo,
+		receiptRepo:      receiptRepo,
+		cfg:              cfg,
+	}
 }
 
+// changeReceiptLines runs change on the lines of a receipt and then derives
+// the receipt's totals from them, in one transaction. The receipt row is
+// locked first, so concurrent line changes are applied one after another.
+// Only a receipt still being built can change: one that is paid, has
+// returns or belongs to a closed shift is final.
+func (s *SaleService) changeReceiptLines(receiptNumber string, change func(tx *sql.Tx, receipt models.ReceiptRetrieve) error) error {
+	tx, err := s.receiptRepo.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	receipt, err := s.receiptRepo.RetrieveReceiptForUpdateTx(tx, receiptNumber)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve receipt %s: %w", receiptNumber, err)
+	}
+	if receipt.VoidedAt != nil {
+		return ErrReceiptVoided
+	}
+	state, err := s.receiptRepo.RetrieveReceiptEditStateTx(tx, receiptNumber)
+	if err != nil {
+		return fmt.Errorf("failed to check receipt %s: %w", receiptNumber, err)
+	}
+	switch {
+	case state.Payments > 0:
+		return fmt.Errorf("%w: receipt %s is paid", ErrReceiptLinesFinal, receiptNumber)
+	case state.Returned:
+		return fmt.Errorf("%w: receipt %s has returns", ErrReceiptLinesFinal, receiptNumber)
+	case state.ShiftClosed:
+		return fmt.Errorf("%w: the shift of receipt %s is closed", ErrReceiptLinesFinal, receiptNumber)
+	}
+
+	if err := change(tx, receipt); err != nil {
+		return err
+	}
+	if _, err := recalculateReceiptTx(tx, s.receiptRepo, s.repo, receipt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// sellStockTx takes quantity more units of upc out of stock for the line of
+// the receipt, or puts them back when quantity is negative.
+func (s *SaleService) sellStockTx(tx *sql.Tx, receiptNumber, upc string, quantity int) error {
+	if quantity == 0 {
+		return nil
+	}
+	storeProducts, err := s.storeProductRepo.LockStoreProductsTx(tx, []string{upc})
+	if err != nil {
+		return fmt.Errorf("failed to lock store product %s: %w", upc, err)
+	}
+	storeProduct, ok := storeProducts[upc]
+	if !ok {
+		return fmt.Errorf("store product %s: %w", upc, sql.ErrNoRows)
+	}
+	if storeProduct.ProductsNumber < quantity {
+		return &InsufficientStockError{Shortages: []models.StockShortage{{
+			UPC:       upc,
+			Requested: quantity,
+			Available: storeProduct.ProductsNumber,
+		}}}
+	}
+
+	reason := "receipt line changed"
+	err = s.storeProductRepo.UpdateProductQuantityTx(tx, models.StockMovementCreate{
+		UPC:       upc,
+		Delta:     -quantity,
+		Kind:      models.StockMovementSale,
+		Reason:    &reason,
+		Reference: &receiptNumber,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update stock for UPC %s: %w", upc, err)
+	}
+	return nil
+}
+
+// CreateSale stores the line with the VAT rate currently resolved for its UPC,
+// takes its units out of stock and recalculates the receipt.
 func (s *SaleService) CreateSale(sale models.SaleCreate) error {
-	return s.repo.CreateSale(sale)
+	rates, err := s.storeProductRepo.RetrieveVATRates([]string{sale.UPC})
+	if err != nil {
+		return fmt.Errorf("failed to resolve VAT rate for UPC %s: %w", sale.UPC, err)
+	}
+	sale.VATRate = vatRateFor(rates, sale.UPC, s.cfg.VAT_RATE)
+
+	return s.changeReceiptLines(sale.ReceiptNumber, func(tx *sql.Tx, receipt models.ReceiptRetrieve) error {
+		sale.VATSum = lineVAT(sale.SellingPrice.Mul(sale.ProductNumber), receiptDiscountPercent(receipt), sale.VATRate)
+		if err := s.repo.CreateSaleTx(tx, sale); err != nil {
+			return err
+		}
+		return s.sellStockTx(tx, sale.ReceiptNumber, sale.UPC, sale.ProductNumber)
+	})
 }
 
 func (s *SaleService) GetSaleByKey(upc, receiptNumber string) (models.SaleRetrieve, error) {
@@ -59,31 +180,92 @@ func (s *SaleService) GetSalesWithDetailsByReceipt(receiptNumber string) ([]mode
 	return s.repo.RetrieveSalesWithDetailsByReceipt(receiptNumber)
 }
 
+// UpdateSale changes the quantity or price of a line, moves the change in
+// quantity through stock and recalculates the receipt. The line keeps the
+// VAT rate stored when it was sold, so later rate changes do not rewrite
+// past receipts.
 func (s *SaleService) UpdateSale(upc, receiptNumber string, sale models.SaleUpdate) error {
-	return s.repo.UpdateSale(upc, receiptNumber, sale)
+	return s.changeReceiptLines(receiptNumber, func(tx *sql.Tx, receipt models.ReceiptRetrieve) error {
+		current, err := s.repo.RetrieveSaleByKeyTx(tx, upc, receiptNumber)
+		if err != nil {
+			return err
+		}
+
+		quantity, price := current.ProductNumber, current.SellingPrice
+		if sale.ProductNumber != nil {
+			quantity = *sale.ProductNumber
+		}
+		if sale.SellingPrice != nil {
+			price = *sale.SellingPrice
+		}
+		vatSum := lineVAT(price.Mul(quantity), receiptDiscountPercent(receipt), current.VATRate)
+		sale.VATSum = &vatSum
+
+		if err := s.repo.UpdateSaleTx(tx, upc, receiptNumber, sale); err != nil {
+			return err
+		}
+		return s.sellStockTx(tx, receiptNumber, upc, quantity-current.ProductNumber)
+	})
 }
 
+// DeleteSale removes a line and puts its units back into stock.
 func (s *SaleService) DeleteSale(upc, receiptNumber string) error {
-	return s.repo.DeleteSale(upc, receiptNumber)
+	return s.changeReceiptLines(receiptNumber, func(tx *sql.Tx, receipt models.ReceiptRetrieve) error {
+		current, err := s.repo.RetrieveSaleByKeyTx(tx, upc, receiptNumber)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.DeleteSaleTx(tx, upc, receiptNumber); err != nil {
+			return err
+		}
+		return s.sellStockTx(tx, receiptNumber, upc, -current.ProductNumber)
+	})
 }
 
+// DeleteSalesByReceipt removes every line of the receipt and puts their
+// units back into stock.
 func (s *SaleService) DeleteSalesByReceipt(receiptNumber string) error {
-	return s.repo.DeleteSalesByReceipt(receiptNumber)
+	return s.changeReceiptLines(receiptNumber, func(tx *sql.Tx, receipt models.ReceiptRetrieve) error {
+		current, err := s.repo.RetrieveSalesByReceiptTx(tx, receiptNumber)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve sales of receipt %s: %w", receiptNumber, err)
+		}
+		if err := s.repo.DeleteSalesByReceiptTx(tx, receiptNumber); err != nil {
+			return err
+		}
+		for _, sale := range current {
+			if err := s.sellStockTx(tx, receiptNumber, sale.UPC, -sale.ProductNumber); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (s *SaleService) GetReceiptTotal(receiptNumber string) (float64, error) {
-	return s.repo.GetReceiptTotal(receiptNumber)
+// GetReceiptTotal derives the receipt total from its sale lines and discount,
+// the figure stored as the receipt's sum_total.
+func (s *SaleService) GetReceiptTotal(receiptNumber string) (models.Money, error) {
+	receipt, err := s.receiptRepo.RetrieveReceiptByReceiptNumber(receiptNumber)
+	if err != nil {
+		return models.Money{}, err
+	}
+	sales, err := s.repo.RetrieveSalesByReceipt(receiptNumber)
+	if err != nil {
+		return models.Money{}, err
+	}
+	totals, _ := deriveReceiptTotals(sales, receiptDiscountPercent(receipt))
+	return totals.TotalSum, nil
 }
 
-func (s *SaleService) GetSalesStatsByProduct(productID int, startDate, endDate string) (int, float64, error) {
+func (s *SaleService) GetSalesStatsByProduct(productID int, startDate, endDate string) (int, models.Money, error) {
 	return s.repo.GetSalesStatsByProduct(productID, startDate, endDate)
 }
 
-func (s *SaleService) GetTopSellingProducts(limit int) ([]struct {
-	ProductID    int     `json:"product_id"`
-	ProductName  string  `json:"product_name"`
-	TotalSold    int     `json:"total_sold"`
-	TotalRevenue float64 `json:"total_revenue"`
+func (s *SaleService) GetTopSellingProducts(limit int, includeVoided bool) ([]struct {
+	ProductID    int          `json:"product_id"`
+	ProductName  string       `json:"product_name"`
+	TotalSold    int          `json:"total_sold"`
+	TotalRevenue models.Money `json:"total_revenue"`
 }, error) {
-	return s.repo.GetTopSellingProducts(limit)
+	return s.repo.GetTopSellingProducts(limit, includeVoided)
 }
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/config"
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrNoOpenShift      = errors.New("cashier has no open shift")
+	ErrNoShiftAtTime    = errors.New("cashier had no shift at that time")
+	ErrShiftAlreadyOpen = errors.New("a shift is already open")
+	ErrShiftClosed      = errors.New("shift is closed")
+	ErrShiftNotClosed   = errors.New("shift is still open")
+)
+
+type ShiftRepo interface {
+	BeginTx() (*sql.Tx, error)
+	CreateShift(c models.ShiftCreate) (int, error)
+	RetrieveShiftByID(shiftID int) (models.ShiftRetrieve, error)
+	RetrieveShiftForUpdateTx(tx *sql.Tx, shiftID int) (models.ShiftRetrieve, error)
+	RetrieveOpenShiftByEmployee(employeeID string) (models.ShiftRetrieve, error)
+	RetrieveOpenShiftByRegister(registerID string) (models.ShiftRetrieve, error)
+	RetrieveShifts(f models.ShiftFilter) ([]models.ShiftRetrieve, error)
+	CloseShiftTx(tx *sql.Tx, shiftID int, closedBy string, closedAt time.Time) error
+	RetrieveShiftReport(shiftID int) (models.ShiftReport, error)
+	RetrieveShiftReportTx(tx *sql.Tx, shiftID int) (models.ShiftReport, error)
+}
+
+type ShiftService struct {
+	repo ShiftRepo
+	cfg  *config.Config
+}
+
+func NewShiftService(repo ShiftRepo, cfg *config.Config) *ShiftService {
+	return &ShiftService{
+		repo: repo,
+		cfg:  cfg,
+	}
+}
+
+// OpenShift starts a shift for the cashier on the register with the cash
+// float put into the drawer. Neither may already have an open shift.
+func (s *ShiftService) OpenShift(c models.ShiftCreate) (models.ShiftRetrieve, error) {
+	if s.cfg.RECEIPT_NUMBERING == config.ReceiptNumberingSequential && len(*c.RegisterId) > MaxSequentialRegisterID {
+		return models.ShiftRetrieve{}, ErrRegisterIDTooLong
+	}
+	if _, err := s.repo.RetrieveOpenShiftByRegister(*c.RegisterId); err == nil {
+		return models.ShiftRetrieve{}, fmt.Errorf("%w on register %s", ErrShiftAlreadyOpen, *c.RegisterId)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return models.ShiftRetrieve{}, fmt.Errorf("failed to check register %s: %w", *c.RegisterId, err)
+	}
+	if _, err := s.repo.RetrieveOpenShiftByEmployee(*c.EmployeeId); err == nil {
+		return models.ShiftRetrieve{}, fmt.Errorf("%w for cashier %s", ErrShiftAlreadyOpen, *c.EmployeeId)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return models.ShiftRetrieve{}, fmt.Errorf("failed to check cashier %s: %w", *c.EmployeeId, err)
+	}
+
+	now := time.Now()
+	c.OpenedAt = &now
+	shiftID, err := s.repo.CreateShift(c)
+	if err != nil {
+		return models.ShiftRetrieve{}, fmt.Errorf("failed to open shift: %w", err)
+	}
+	return s.repo.RetrieveShiftByID(shiftID)
+}
+
+func (s *ShiftService) GetShiftByID(shiftID int) (models.ShiftRetrieve, error) {
+	return s.repo.RetrieveShiftByID(shiftID)
+}
+
+// GetOpenShift returns the cashier's open shift, or ErrNoOpenShift.
+func (s *ShiftService) GetOpenShift(employeeID string) (models.ShiftRetrieve, error) {
+	shift, err := s.repo.RetrieveOpenShiftByEmployee(employeeID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.ShiftRetrieve{}, ErrNoOpenShift
+	}
+	return shift, err
+}
+
+func (s *ShiftService) GetShifts(f models.ShiftFilter) ([]models.ShiftRetrieve, error) {
+	return s.repo.RetrieveShifts(f)
+}
+
+// GetXReport reports the totals of an open shift so far without closing it.
+func (s *ShiftService) GetXReport(shiftID int) (models.ShiftReport, error) {
+	shift, err := s.repo.RetrieveShiftByID(shiftID)
+	if err != nil {
+		return models.ShiftReport{}, err
+	}
+	if shift.ClosedAt != nil {
+		return models.ShiftReport{}, ErrShiftClosed
+	}
+
+	report, err := s.repo.RetrieveShiftReport(shiftID)
+	if err != nil {
+		return models.ShiftReport{}, fmt.Errorf("failed to sum shift %d: %w", shiftID, err)
+	}
+	return completeShiftReport(report, shift, models.ShiftReportX, time.Now()), nil
+}
+
+// GetZReport reprints the report of a closed shift as of its closing time.
+func (s *ShiftService) GetZReport(shiftID int) (models.ShiftReport, error) {
+	shift, err := s.repo.RetrieveShiftByID(shiftID)
+	if err != nil {
+		return models.ShiftReport{}, err
+	}
+	if shift.ClosedAt == nil {
+		return models.ShiftReport{}, ErrShiftNotClosed
+	}
+
+	report, err := s.repo.RetrieveShiftReport(shiftID)
+	if err != nil {
+		return models.ShiftReport{}, fmt.Errorf("failed to sum shift %d: %w", shiftID, err)
+	}
+	return completeShiftReport(report, shift, models.ShiftReportZ, *shift.ClosedAt), nil
+}
+
+// CloseShift takes the Z-report and closes the shift in one transaction. The
+// shift row is locked first, so receipts still being written into the shift
+// are either in the report or rejected.
+func (s *ShiftService) CloseShift(shiftID int, closedBy string) (models.ShiftReport, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.ShiftReport{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	shift, err := s.repo.RetrieveShiftForUpdateTx(tx, shiftID)
+	if err != nil {
+		return models.ShiftReport{}, err
+	}
+	if shift.ClosedAt != nil {
+		return models.ShiftReport{}, ErrShiftClosed
+	}
+
+	report, err := s.repo.RetrieveShiftReportTx(tx, shiftID)
+	if err != nil {
+		return models.ShiftReport{}, fmt.Errorf("failed to sum shift %d: %w", shiftID, err)
+	}
+
+	now := time.Now()
+	if err := s.repo.CloseShiftTx(tx, shiftID, closedBy, now); err != nil {
+		return models.ShiftReport{}, fmt.Errorf("failed to close shift %d: %w", shiftID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.ShiftReport{}, fmt.Errorf("failed to commit shift close: %w", err)
+	}
+
+	shift.ClosedAt, shift.ClosedBy = &now, &closedBy
+	return completeShiftReport(report, shift, models.ShiftReportZ, now), nil
+}
+
+// completeShiftReport fills in the derived figures. Returns are refunded out
+// of the drawer, so the cash expected at the end of the shift is the opening
+// float plus the cash applied to receipts less the refunds.
+func completeShiftReport(report models.ShiftReport, shift models.ShiftRetrieve, kind string, at time.Time) models.ShiftReport {
+	report.Kind = kind
+	report.GeneratedAt = at
+	report.Shift = shift
+	if report.VAT == nil {
+		report.VAT = []models.ReceiptVATLine{}
+	}
+	if report.Tenders == nil {
+		report.Tenders = []models.ShiftTenderTotal{}
+	}
+
+	report.NetTotal = report.SalesTotal.Sub(report.ReturnsTotal)
+	report.ExpectedCash = shift.OpeningFloat.Sub(report.ReturnsTotal)
+	for _, tender := range report.Tenders {
+		if tender.Tender == models.TenderCash {
+			report.ExpectedCash = report.ExpectedCash.Add(tender.Amount)
+		}
+	}
+	return report
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrStockAlertResolved = errors.New("stock alert is already resolved")
+)
+
+type StockAlertRepo interface {
+	RetrieveReorderLevels(productID int) (models.ReorderLevels, error)
+	SetReorderLevels(productID int, levels *models.ReorderLevels) error
+	RetrieveLowStock(f models.LowStockFilter) ([]models.LowStockProduct, error)
+	RetrieveStockAlertByID(alertID int) (models.StockAlert, error)
+	RetrieveStockAlerts(status string) ([]models.StockAlert, error)
+	ResolveStockAlert(alertID int, employeeID string, resolvedAt time.Time) (bool, error)
+}
+
+type StockAlertProductRepo interface {
+	RetrieveProductByID(id int) (models.ProductRetrieve, error)
+}
+
+// StockAlertService keeps products' reorder levels, reports the UPCs below
+// them and works the queue of low-stock alerts. Alerts are raised by the
+// operations that change stock.
+type StockAlertService struct {
+	repo        StockAlertRepo
+	productRepo StockAlertProductRepo
+}
+
+func NewStockAlertService(repo StockAlertRepo, productRepo StockAlertProductRepo) *StockAlertService {
+	return &StockAlertService{
+		repo:        repo,
+		productRepo: productRepo,
+	}
+}
+
+func (s *StockAlertService) GetReorderLevels(productID int) (models.ReorderLevels, error) {
+	return s.repo.RetrieveReorderLevels(productID)
+}
+
+// SetReorderLevels sets the product's levels, or clears them when levels is
+// nil. It fails with sql.ErrNoRows for an unknown product.
+func (s *StockAlertService) SetReorderLevels(productID int, levels *models.ReorderLevels) error {
+	if _, err := s.productRepo.RetrieveProductByID(productID); err != nil {
+		return err
+	}
+	return s.repo.SetReorderLevels(productID, levels)
+}
+
+// GetLowStock lists the UPCs below their product's min_stock with what is
+// missing and how fast they sold over the last f.Days days.
+func (s *StockAlertService) GetLowStock(f models.LowStockFilter) ([]models.LowStockProduct, error) {
+	products, err := s.repo.RetrieveLowStock(f)
+	if err != nil {
+		return nil, err
+	}
+	for i := range products {
+		product := &products[i]
+		product.Shortfall = product.MinStock - product.ProductsNumber
+		product.Days = f.Days
+		if product.UnitsSold < 0 {
+			product.UnitsSold = 0
+		}
+		if f.Days > 0 {
+			product.DailyVelocity = float64(product.UnitsSold) / float64(f.Days)
+		}
+		if product.DailyVelocity > 0 {
+			cover := float64(product.ProductsNumber) / product.DailyVelocity
+			product.DaysOfCover = &cover
+		}
+	}
+	return products, nil
+}
+
+func (s *StockAlertService) GetStockAlert(alertID int) (models.StockAlert, error) {
+	return s.repo.RetrieveStockAlertByID(alertID)
+}
+
+func (s *StockAlertService) GetStockAlerts(status string) ([]models.StockAlert, error) {
+	return s.repo.RetrieveStockAlerts(status)
+}
+
+// ResolveStockAlert closes an open alert on behalf of the employee, once the
+// shortfall has been dealt with.
+func (s *StockAlertService) ResolveStockAlert(alertID int, employeeID string) (models.StockAlert, error) {
+	resolved, err := s.repo.ResolveStockAlert(alertID, employeeID, time.Now())
+	if err != nil {
+		return models.StockAlert{}, fmt.Errorf("failed to resolve stock alert %d: %w", alertID, err)
+	}
+	alert, err := s.repo.RetrieveStockAlertByID(alertID)
+	if err != nil {
+		return models.StockAlert{}, err
+	}
+	if !resolved {
+		return models.StockAlert{}, ErrStockAlertResolved
+	}
+	return alert, nil
+}
@@ -0,0 +1,30 @@
+package services
+
+import (
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type StockBatchRepo interface {
+	RetrieveStockBatchesByUPC(upc string) ([]models.StockBatch, error)
+	RetrieveExpiringBatches(days int) ([]models.ExpiringBatch, error)
+}
+
+// StockBatchService reads stock batches. Batches are only ever written by
+// the operations that change stock.
+type StockBatchService struct {
+	repo StockBatchRepo
+}
+
+func NewStockBatchService(repo StockBatchRepo) *StockBatchService {
+	return &StockBatchService{repo: repo}
+}
+
+func (s *StockBatchService) GetBatchesByUPC(upc string) ([]models.StockBatch, error) {
+	return s.repo.RetrieveStockBatchesByUPC(upc)
+}
+
+// GetExpiringBatches lists the batches on hand expiring within days from
+// today, and those already expired, for marking down.
+func (s *StockBatchService) GetExpiringBatches(days int) ([]models.ExpiringBatch, error) {
+	return s.repo.RetrieveExpiringBatches(days)
+}
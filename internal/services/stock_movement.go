@@ -0,0 +1,49 @@
+package services
+
+import (
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+type StockMovementRepo interface {
+	RetrieveStockMovementsByUPC(upc string) ([]models.StockMovementRetrieve, error)
+	RetrieveStockLedgerChecks() ([]models.StockLedgerCheck, error)
+}
+
+// StockMovementService reads the stock ledger. Movements are only ever
+// written by the operations that change stock.
+type StockMovementService struct {
+	repo StockMovementRepo
+}
+
+func NewStockMovementService(repo StockMovementRepo) *StockMovementService {
+	return &StockMovementService{repo: repo}
+}
+
+func (s *StockMovementService) GetMovementsByUPC(upc string) ([]models.StockMovementRetrieve, error) {
+	return s.repo.RetrieveStockMovementsByUPC(upc)
+}
+
+// VerifyStock rebuilds every UPC's stock from the ledger. A UPC matches when
+// both the sum of its movements and the balance of its latest movement equal
+// its stock; a UPC without movements matches only with no stock. With
+// mismatchedOnly the matching UPCs are left out.
+func (s *StockMovementService) VerifyStock(mismatchedOnly bool) ([]models.StockLedgerCheck, error) {
+	checks, err := s.repo.RetrieveStockLedgerChecks()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []models.StockLedgerCheck{}
+	for _, check := range checks {
+		lastBalance := 0
+		if check.LastBalance != nil {
+			lastBalance = *check.LastBalance
+		}
+		check.Matches = check.LedgerStock == check.Stock && lastBalance == check.Stock
+		if mismatchedOnly && check.Matches {
+			continue
+		}
+		result = append(result, check)
+	}
+	return result, nil
+}
@@ -0,0 +1,275 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrStocktakeUnknownCategory = errors.New("category not found")
+	ErrStocktakeNotOpen         = errors.New("stocktake is not open")
+	ErrStocktakeOutOfScope      = errors.New("store product not found or outside the stocktake's category")
+	ErrStocktakeNoCounts        = errors.New("stocktake has no counts")
+)
+
+type StocktakeRepo interface {
+	BeginTx() (*sql.Tx, error)
+	CreateStocktake(c models.StocktakeCreate) (int, error)
+	RetrieveStocktakeByID(stocktakeID int) (models.StocktakeRetrieve, error)
+	RetrieveStocktakeForUpdateTx(tx *sql.Tx, stocktakeID int) (models.StocktakeRetrieve, error)
+	RetrieveStocktakes(status string) ([]models.StocktakeRetrieve, error)
+	CreateStocktakeCountTx(tx *sql.Tx, s models.StocktakeRetrieve, employeeID string, c models.StocktakeCountCreate, countedAt time.Time) (int, error)
+	DeleteStocktakeCountTx(tx *sql.Tx, stocktakeID int, countID int) (bool, error)
+	RetrieveStocktakeCounts(stocktakeID int) ([]models.StocktakeCount, error)
+	RetrieveStocktakeCountTotalsTx(tx *sql.Tx, stocktakeID int) ([]models.StocktakeLineRecord, error)
+	CreateStocktakeLineTx(tx *sql.Tx, stocktakeID int, l models.StocktakeLineRecord) error
+	CloseStocktakeTx(tx *sql.Tx, stocktakeID int, status string, employeeID string, closedAt time.Time) error
+	RetrieveOpenStocktakeLines(s models.StocktakeRetrieve) ([]models.StocktakeLine, error)
+	RetrieveStocktakeLines(stocktakeID int) ([]models.StocktakeLine, error)
+}
+
+type StocktakeCategoryRepo interface {
+	RetrieveCategoryByID(id int) (models.CategoryRetrieve, error)
+}
+
+type StocktakeStoreProductRepo interface {
+	LockStoreProductsTx(tx *sql.Tx, upcs []string) (map[string]models.StoreProductRetrieve, error)
+	UpdateProductQuantityTx(tx *sql.Tx, m models.StockMovementCreate) error
+}
+
+// StocktakeService runs stocktakes: employees count shelf stock, and
+// approving the stocktake brings the system stock in line with the counts.
+type StocktakeService struct {
+	repo             StocktakeRepo
+	categoryRepo     StocktakeCategoryRepo
+	storeProductRepo StocktakeStoreProductRepo
+}
+
+func NewStocktakeService(repo StocktakeRepo, categoryRepo StocktakeCategoryRepo, storeProductRepo StocktakeStoreProductRepo) *StocktakeService {
+	return &StocktakeService{
+		repo:             repo,
+		categoryRepo:     categoryRepo,
+		storeProductRepo: storeProductRepo,
+	}
+}
+
+// OpenStocktake starts a stocktake of the category, or of the whole store
+// when c.CategoryID is nil.
+func (s *StocktakeService) OpenStocktake(c models.StocktakeCreate) (models.StocktakeView, error) {
+	if c.CategoryID != nil {
+		if _, err := s.categoryRepo.RetrieveCategoryByID(*c.CategoryID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return models.StocktakeView{}, fmt.Errorf("%w: %d", ErrStocktakeUnknownCategory, *c.CategoryID)
+			}
+			return models.StocktakeView{}, fmt.Errorf("failed to check category %d: %w", *c.CategoryID, err)
+		}
+	}
+
+	c.OpenedAt = time.Now()
+	stocktakeID, err := s.repo.CreateStocktake(c)
+	if err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to create stocktake: %w", err)
+	}
+	return s.GetStocktake(stocktakeID)
+}
+
+// AddCounts records counts made by the employee in an open stocktake. A UPC
+// may be counted any number of times; its counts add up.
+func (s *StocktakeService) AddCounts(stocktakeID int, employeeID string, counts []models.StocktakeCountCreate) (models.StocktakeView, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stocktake, err := s.openStocktakeTx(tx, stocktakeID)
+	if err != nil {
+		return models.StocktakeView{}, err
+	}
+
+	countedAt := time.Now()
+	for _, count := range counts {
+		_, err := s.repo.CreateStocktakeCountTx(tx, stocktake, employeeID, count, countedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.StocktakeView{}, fmt.Errorf("%w: %s", ErrStocktakeOutOfScope, count.UPC)
+		}
+		if err != nil {
+			return models.StocktakeView{}, fmt.Errorf("failed to add count of UPC %s: %w", count.UPC, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to commit counts: %w", err)
+	}
+	return s.GetStocktake(stocktakeID)
+}
+
+// DeleteCount removes a mistaken count from an open stocktake.
+func (s *StocktakeService) DeleteCount(stocktakeID int, countID int) error {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := s.openStocktakeTx(tx, stocktakeID); err != nil {
+		return err
+	}
+	deleted, err := s.repo.DeleteStocktakeCountTx(tx, stocktakeID, countID)
+	if err != nil {
+		return fmt.Errorf("failed to delete count %d: %w", countID, err)
+	}
+	if !deleted {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// ApproveStocktake posts, in one transaction, an adjustment of every counted
+// UPC by its variance against the stock recorded when it was first counted,
+// and keeps the counts and variances as the stocktake's lines. Sales and
+// deliveries since the count stay on top of the counted stock. UPCs in scope
+// that were not counted are left alone.
+func (s *StocktakeService) ApproveStocktake(stocktakeID int, employeeID string) (models.StocktakeView, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := s.openStocktakeTx(tx, stocktakeID); err != nil {
+		return models.StocktakeView{}, err
+	}
+
+	totals, err := s.repo.RetrieveStocktakeCountTotalsTx(tx, stocktakeID)
+	if err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to merge counts: %w", err)
+	}
+	if len(totals) == 0 {
+		return models.StocktakeView{}, ErrStocktakeNoCounts
+	}
+	upcs := make([]string, 0, len(totals))
+	for _, total := range totals {
+		upcs = append(upcs, total.UPC)
+	}
+	storeProducts, err := s.storeProductRepo.LockStoreProductsTx(tx, upcs)
+	if err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to lock store products: %w", err)
+	}
+
+	reference := documentReference(stocktakePrefix, stocktakeID)
+	reason := "stocktake"
+	for _, line := range totals {
+		storeProduct, ok := storeProducts[line.UPC]
+		if !ok {
+			return models.StocktakeView{}, fmt.Errorf("%w: %s", ErrUnknownUPC, line.UPC)
+		}
+		line.ProductID = storeProduct.ProductID
+		line.Variance = line.Counted - line.SystemStock
+
+		if err := s.repo.CreateStocktakeLineTx(tx, stocktakeID, line); err != nil {
+			return models.StocktakeView{}, fmt.Errorf("failed to add line of UPC %s: %w", line.UPC, err)
+		}
+		if line.Variance == 0 {
+			continue
+		}
+		err := s.storeProductRepo.UpdateProductQuantityTx(tx, models.StockMovementCreate{
+			UPC:        line.UPC,
+			Delta:      line.Variance,
+			Kind:       models.StockMovementAdjustment,
+			EmployeeId: &employeeID,
+			Reason:     &reason,
+			Reference:  &reference,
+		})
+		if err != nil {
+			return models.StocktakeView{}, fmt.Errorf("failed to adjust UPC %s: %w", line.UPC, err)
+		}
+	}
+
+	if err := s.repo.CloseStocktakeTx(tx, stocktakeID, models.StocktakeStatusApproved, employeeID, time.Now()); err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to approve stocktake %d: %w", stocktakeID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to commit stocktake: %w", err)
+	}
+	return s.GetStocktake(stocktakeID)
+}
+
+// CancelStocktake closes an open stocktake without touching stock; its
+// counts are kept.
+func (s *StocktakeService) CancelStocktake(stocktakeID int, employeeID string) (models.StocktakeView, error) {
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := s.openStocktakeTx(tx, stocktakeID); err != nil {
+		return models.StocktakeView{}, err
+	}
+	if err := s.repo.CloseStocktakeTx(tx, stocktakeID, models.StocktakeStatusCancelled, employeeID, time.Now()); err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to cancel stocktake %d: %w", stocktakeID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to commit stocktake: %w", err)
+	}
+	return s.GetStocktake(stocktakeID)
+}
+
+// openStocktakeTx locks the stocktake and checks that it is still open.
+func (s *StocktakeService) openStocktakeTx(tx *sql.Tx, stocktakeID int) (models.StocktakeRetrieve, error) {
+	stocktake, err := s.repo.RetrieveStocktakeForUpdateTx(tx, stocktakeID)
+	if err != nil {
+		return models.StocktakeRetrieve{}, err
+	}
+	if stocktake.Status != models.StocktakeStatusOpen {
+		return models.StocktakeRetrieve{}, fmt.Errorf("%w: stocktake %d is %s", ErrStocktakeNotOpen, stocktakeID, stocktake.Status)
+	}
+	return stocktake, nil
+}
+
+// GetStocktake returns the stocktake with its counts and lines: the live
+// variance of every UPC in scope while it is open, the posted lines once it
+// is approved.
+func (s *StocktakeService) GetStocktake(stocktakeID int) (models.StocktakeView, error) {
+	stocktake, err := s.repo.RetrieveStocktakeByID(stocktakeID)
+	if err != nil {
+		return models.StocktakeView{}, err
+	}
+
+	var lines []models.StocktakeLine
+	if stocktake.Status == models.StocktakeStatusOpen {
+		lines, err = s.repo.RetrieveOpenStocktakeLines(stocktake)
+	} else {
+		lines, err = s.repo.RetrieveStocktakeLines(stocktakeID)
+	}
+	if err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to retrieve stocktake lines: %w", err)
+	}
+	counts, err := s.repo.RetrieveStocktakeCounts(stocktakeID)
+	if err != nil {
+		return models.StocktakeView{}, fmt.Errorf("failed to retrieve stocktake counts: %w", err)
+	}
+
+	view := models.StocktakeView{
+		StocktakeRetrieve: stocktake,
+		Lines:             lines,
+		Counts:            counts,
+	}
+	if view.Lines == nil {
+		view.Lines = []models.StocktakeLine{}
+	}
+	if view.Counts == nil {
+		view.Counts = []models.StocktakeCount{}
+	}
+	return view, nil
+}
+
+func (s *StocktakeService) GetStocktakes(status string) ([]models.StocktakeRetrieve, error) {
+	return s.repo.RetrieveStocktakes(status)
+}
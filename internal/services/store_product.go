@@ -1,25 +1,37 @@
 package services
 
 import (
+	"database/sql"
 	"fmt"
 
 	"github.com/velosypedno/zlagoda/internal/models"
 )
 
 type StoreProductRepo interface {
-	CreateStoreProduct(sp models.StoreProductCreate) (string, error)
+	CreateStoreProduct(sp models.StoreProductCreate, m models.StockMovementCreate) (string, error)
 	RetrieveStoreProductByUPC(upc string) (models.StoreProductRetrieve, error)
 	RetrieveStoreProducts() ([]models.StoreProductRetrieve, error)
 	RetrieveStoreProductsWithDetails() ([]models.StoreProductWithDetails, error)
 	RetrieveStoreProductsByProductID(productID int) ([]models.StoreProductRetrieve, error)
 	RetrievePromotionalProducts() ([]models.StoreProductRetrieve, error)
-	UpdateStoreProduct(upc string, sp models.StoreProductUpdate) error
+	UpdateStoreProduct(upc string, sp models.StoreProductUpdate, m models.StockMovementCreate) error
 	DeleteStoreProduct(upc string) error
-	UpdateProductQuantity(upc string, quantityChange int) error
+	UpdateProductQuantity(m models.StockMovementCreate) error
 	CheckStockAvailability(upc string, requiredQuantity int) (bool, error)
 	RetrieveStoreProductsByCategory(categoryID int) ([]models.StoreProductWithDetails, error)
 	RetrieveStoreProductsByName(name string) ([]models.StoreProductWithDetails, error)
-	UpdateProductDelivery(upc string, quantityChange int, newPrice *float64) error
+	UpdateProductDelivery(m models.StockMovementCreate, newPrice *models.Money, expiryDate *string) error
+	UpdateProductDeliveryTx(tx *sql.Tx, m models.StockMovementCreate, newPrice *models.Money, expiryDate *string) error
+}
+
+// promotionalPricePercent is the share of the regular price a promotional
+// UPC sells at.
+const promotionalPricePercent = 80
+
+// promotionalPrice is the selling price of the promotional UPC of a product,
+// rounded to kopecks.
+func promotionalPrice(regular models.Money) models.Money {
+	return regular.Percent(promotionalPricePercent).Round()
 }
 
 type StoreProductService struct {
@@ -30,7 +42,11 @@ func NewStoreProductService(repo StoreProductRepo) *StoreProductService {
 	return &StoreProductService{repo: repo}
 }
 
-func (s *StoreProductService) CreateStoreProduct(sp models.StoreProductCreate) (string, error) {
+// CreateStoreProduct adds a UPC. A regular UPC is a supply: its units are
+// also added to the other UPCs of the product, which take its price. A
+// promotional UPC moves the units of its regular UPC to promotion. Every
+// stock change is recorded against employeeID.
+func (s *StoreProductService) CreateStoreProduct(sp models.StoreProductCreate, employeeID string) (string, error) {
 	// if promotional product is set, checks whether it is valid
 	if sp.UPCProm != nil {
 		promotionalProduct, err := s.repo.RetrieveStoreProductByUPC(*sp.UPCProm)
@@ -45,33 +61,50 @@ func (s *StoreProductService) CreateStoreProduct(sp models.StoreProductCreate) (
 		}
 	}
 
+	if sp.PromotionalProduct {
+		return s.repo.CreateStoreProduct(sp, models.StockMovementCreate{
+			Kind:       models.StockMovementPromoTransfer,
+			EmployeeId: &employeeID,
+			Reference:  sp.UPCProm,
+		})
+	}
+
 	// supply handling
-	if !sp.PromotionalProduct {
-		storeProductsWithSameProductID, err := s.repo.RetrieveStoreProductsByProductID(sp.ProductID)
+	storeProductsWithSameProductID, err := s.repo.RetrieveStoreProductsByProductID(sp.ProductID)
+	if err != nil {
+		return "", err
+	}
+	upc, err := s.repo.CreateStoreProduct(sp, models.StockMovementCreate{
+		Kind:       models.StockMovementDelivery,
+		EmployeeId: &employeeID,
+	})
+	if err != nil {
+		return "", err
+	}
+	for _, storeProduct := range storeProductsWithSameProductID {
+		var newProductsNumber int = storeProduct.ProductsNumber + sp.ProductsNumber
+		updated := models.StoreProductUpdate{
+			UPCProm:            storeProduct.UPCProm,
+			ProductID:          &storeProduct.ProductID,
+			ProductsNumber:     &newProductsNumber,
+			PromotionalProduct: &storeProduct.PromotionalProduct,
+		}
+		if !storeProduct.PromotionalProduct {
+			updated.SellingPrice = &sp.SellingPrice
+		} else {
+			var promotionalSellingPrice models.Money = promotionalPrice(sp.SellingPrice)
+			updated.SellingPrice = &promotionalSellingPrice
+		}
+		err = s.repo.UpdateStoreProduct(storeProduct.UPC, updated, models.StockMovementCreate{
+			Kind:       models.StockMovementDelivery,
+			EmployeeId: &employeeID,
+			Reference:  &upc,
+		})
 		if err != nil {
 			return "", err
 		}
-		for _, storeProduct := range storeProductsWithSameProductID {
-			var newProductsNumber int = storeProduct.ProductsNumber + sp.ProductsNumber
-			updated := models.StoreProductUpdate{
-				UPCProm:            storeProduct.UPCProm,
-				ProductID:          &storeProduct.ProductID,
-				ProductsNumber:     &newProductsNumber,
-				PromotionalProduct: &storeProduct.PromotionalProduct,
-			}
-			if !storeProduct.PromotionalProduct {
-				updated.SellingPrice = &sp.SellingPrice
-			} else {
-				var promotionalSellingPrice float64 = 0.8 * sp.SellingPrice
-				updated.SellingPrice = &promotionalSellingPrice
-			}
-			err = s.repo.UpdateStoreProduct(storeProduct.UPC, updated)
-			if err != nil {
-				return "", err
-			}
-		}
 	}
-	return s.repo.CreateStoreProduct(sp)
+	return upc, nil
 }
 
 func (s *StoreProductService) GetStoreProductByUPC(upc string) (models.StoreProductRetrieve, error) {
@@ -94,7 +127,16 @@ func (s *StoreProductService) GetPromotionalProducts() ([]models.StoreProductRet
 	return s.repo.RetrievePromotionalProducts()
 }
 
-func (s *StoreProductService) UpdateStoreProduct(upc string, sp models.StoreProductUpdate) error {
+// UpdateStoreProduct changes a UPC and carries its stock and price over to
+// the other UPCs of its product. Stock changes are recorded as adjustments by
+// employeeID.
+func (s *StoreProductService) UpdateStoreProduct(upc string, sp models.StoreProductUpdate, employeeID string) error {
+	adjustment := models.StockMovementCreate{
+		Kind:       models.StockMovementAdjustment,
+		EmployeeId: &employeeID,
+		Reference:  &upc,
+	}
+
 	storeProductCorrentState, err := s.repo.RetrieveStoreProductByUPC(upc)
 	if err != nil {
 		return err
@@ -114,7 +156,7 @@ func (s *StoreProductService) UpdateStoreProduct(upc string, sp models.StoreProd
 		newProductsNumber = *sp.ProductsNumber
 	}
 
-	var newSellingPrice float64
+	var newSellingPrice models.Money
 	if sp.SellingPrice != nil {
 		newSellingPrice = *sp.SellingPrice
 	}
@@ -139,26 +181,33 @@ func (s *StoreProductService) UpdateStoreProduct(upc string, sp models.StoreProd
 			if !storeProduct.PromotionalProduct {
 				updated.SellingPrice = &newSellingPrice
 			} else {
-				var promotionalSellingPrice float64 = 0.8 * newSellingPrice
+				var promotionalSellingPrice models.Money = promotionalPrice(newSellingPrice)
 				updated.SellingPrice = &promotionalSellingPrice
 			}
 		}
 
-		err = s.repo.UpdateStoreProduct(storeProduct.UPC, updated)
+		err = s.repo.UpdateStoreProduct(storeProduct.UPC, updated, adjustment)
 		if err != nil {
 			return err
 		}
 	}
 
-	return s.repo.UpdateStoreProduct(upc, sp)
+	return s.repo.UpdateStoreProduct(upc, sp, adjustment)
 }
 
 func (s *StoreProductService) DeleteStoreProduct(upc string) error {
 	return s.repo.DeleteStoreProduct(upc)
 }
 
-func (s *StoreProductService) UpdateProductQuantity(upc string, quantityChange int) error {
-	return s.repo.UpdateProductQuantity(upc, quantityChange)
+// UpdateProductQuantity is a manual stock adjustment by employeeID.
+func (s *StoreProductService) UpdateProductQuantity(upc string, quantityChange int, employeeID string, reason *string) error {
+	return s.repo.UpdateProductQuantity(models.StockMovementCreate{
+		UPC:        upc,
+		Delta:      quantityChange,
+		Kind:       models.StockMovementAdjustment,
+		EmployeeId: &employeeID,
+		Reason:     reason,
+	})
 }
 
 func (s *StoreProductService) CheckStockAvailability(upc string, requiredQuantity int) (bool, error) {
@@ -173,6 +222,27 @@ func (s *StoreProductService) GetStoreProductsByName(name string) ([]models.Stor
 	return s.repo.RetrieveStoreProductsByName(name)
 }
 
-func (s *StoreProductService) UpdateProductDelivery(upc string, quantityChange int, newPrice *float64) error {
-	return s.repo.UpdateProductDelivery(upc, quantityChange, newPrice)
+// UpdateProductDelivery books a delivery received by employeeID; reference
+// is the supplier's document, such as an invoice number. The units form a
+// batch expiring on expiryDate, when it is known.
+func (s *StoreProductService) UpdateProductDelivery(upc string, quantityChange int, newPrice *models.Money, expiryDate *string, employeeID string, reference *string) error {
+	return s.repo.UpdateProductDelivery(models.StockMovementCreate{
+		UPC:        upc,
+		Delta:      quantityChange,
+		Kind:       models.StockMovementDelivery,
+		EmployeeId: &employeeID,
+		Reference:  reference,
+	}, newPrice, expiryDate)
+}
+
+// UpdateProductDeliveryTx books a delivery like UpdateProductDelivery as part
+// of tx, for documents that receive several UPCs at once.
+func (s *StoreProductService) UpdateProductDeliveryTx(tx *sql.Tx, upc string, quantityChange int, newPrice *models.Money, expiryDate *string, employeeID string, reference *string) error {
+	return s.repo.UpdateProductDeliveryTx(tx, models.StockMovementCreate{
+		UPC:        upc,
+		Delta:      quantityChange,
+		Kind:       models.StockMovementDelivery,
+		EmployeeId: &employeeID,
+		Reference:  reference,
+	}, newPrice, expiryDate)
 }
@@ -0,0 +1,39 @@
+package services
+
+import "github.com/velosypedno/zlagoda/internal/models"
+
+type SupplierRepo interface {
+	CreateSupplier(s models.SupplierCreate) (int, error)
+	RetrieveSupplierByID(supplierID int) (models.SupplierRetrieve, error)
+	RetrieveSuppliers() ([]models.SupplierRetrieve, error)
+	UpdateSupplier(supplierID int, s models.SupplierUpdate) error
+	DeleteSupplier(supplierID int) error
+}
+
+type SupplierService struct {
+	repo SupplierRepo
+}
+
+func NewSupplierService(repo SupplierRepo) *SupplierService {
+	return &SupplierService{repo: repo}
+}
+
+func (s *SupplierService) CreateSupplier(c models.SupplierCreate) (int, error) {
+	return s.repo.CreateSupplier(c)
+}
+
+func (s *SupplierService) GetSupplierByID(supplierID int) (models.SupplierRetrieve, error) {
+	return s.repo.RetrieveSupplierByID(supplierID)
+}
+
+func (s *SupplierService) GetSuppliers() ([]models.SupplierRetrieve, error) {
+	return s.repo.RetrieveSuppliers()
+}
+
+func (s *SupplierService) UpdateSupplier(supplierID int, u models.SupplierUpdate) error {
+	return s.repo.UpdateSupplier(supplierID, u)
+}
+
+func (s *SupplierService) DeleteSupplier(supplierID int) error {
+	return s.repo.DeleteSupplier(supplierID)
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/velosypedno/zlagoda/internal/models"
+)
+
+var (
+	ErrWriteOffDuplicateUPC      = errors.New("UPC is written off more than once")
+	ErrWriteOffInsufficientStock = errors.New("not enough stock to write off")
+)
+
+type WriteOffRepo interface {
+	BeginTx() (*sql.Tx, error)
+	CreateWriteOffTx(tx *sql.Tx, c models.WriteOffCreate) (int, error)
+	CreateWriteOffLineTx(tx *sql.Tx, writeOffID int, l models.WriteOffLineRecord) error
+	RetrieveWriteOffByID(writeOffID int) (models.WriteOffRetrieve, error)
+	RetrieveWriteOffs(f models.WriteOffFilter) ([]models.WriteOffRetrieve, error)
+	RetrieveWriteOffLines(writeOffIDs []int) ([]models.WriteOffLine, error)
+	RetrieveWriteOffReport(from, to time.Time) ([]models.WriteOffReportRow, error)
+}
+
+type WriteOffStoreProductRepo interface {
+	LockStoreProductsTx(tx *sql.Tx, upcs []string) (map[string]models.StoreProductRetrieve, error)
+	UpdateProductQuantityTx(tx *sql.Tx, m models.StockMovementCreate) error
+}
+
+// WriteOffService books goods that leave stock without being sold and
+// reports what they were worth.
+type WriteOffService struct {
+	repo             WriteOffRepo
+	storeProductRepo WriteOffStoreProductRepo
+}
+
+func NewWriteOffService(repo WriteOffRepo, storeProductRepo WriteOffStoreProductRepo) *WriteOffService {
+	return &WriteOffService{
+		repo:             repo,
+		storeProductRepo: storeProductRepo,
+	}
+}
+
+// CreateWriteOff takes every line out of stock in one transaction, valuing
+// each at its UPC's current selling price. The ledger records the lines as
+// write-off movements with the reason, referencing the document.
+func (s *WriteOffService) CreateWriteOff(c models.WriteOffCreate) (models.WriteOffView, error) {
+	upcs := make([]string, 0, len(c.Lines))
+	seen := make(map[string]bool, len(c.Lines))
+	for _, line := range c.Lines {
+		if seen[line.UPC] {
+			return models.WriteOffView{}, fmt.Errorf("%w: %s", ErrWriteOffDuplicateUPC, line.UPC)
+		}
+		seen[line.UPC] = true
+		upcs = append(upcs, line.UPC)
+	}
+
+	tx, err := s.repo.BeginTx()
+	if err != nil {
+		return models.WriteOffView{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	storeProducts, err := s.storeProductRepo.LockStoreProductsTx(tx, upcs)
+	if err != nil {
+		return models.WriteOffView{}, fmt.Errorf("failed to lock store products: %w", err)
+	}
+
+	c.WrittenOffAt = time.Now()
+	writeOffID, err := s.repo.CreateWriteOffTx(tx, c)
+	if err != nil {
+		return models.WriteOffView{}, fmt.Errorf("failed to create write-off: %w", err)
+	}
+
+	reference := documentReference(writeOffPrefix, writeOffID)
+	for i, line := range c.Lines {
+		storeProduct, ok := storeProducts[line.UPC]
+		if !ok {
+			return models.WriteOffView{}, fmt.Errorf("%w: %s", ErrUnknownUPC, line.UPC)
+		}
+		if storeProduct.ProductsNumber < line.Quantity {
+			return models.WriteOffView{}, fmt.Errorf("%w: UPC %s has %d of %d", ErrWriteOffInsufficientStock, line.UPC, storeProduct.ProductsNumber, line.Quantity)
+		}
+
+		err := s.repo.CreateWriteOffLineTx(tx, writeOffID, models.WriteOffLineRecord{
+			LineNo:    i + 1,
+			UPC:       line.UPC,
+			ProductID: storeProduct.ProductID,
+			Quantity:  line.Quantity,
+			UnitPrice: storeProduct.SellingPrice,
+		})
+		if err != nil {
+			return models.WriteOffView{}, fmt.Errorf("failed to add line %d: %w", i+1, err)
+		}
+
+		err = s.storeProductRepo.UpdateProductQuantityTx(tx, models.StockMovementCreate{
+			UPC:        line.UPC,
+			Delta:      -line.Quantity,
+			Kind:       models.StockMovementWriteOff,
+			EmployeeId: &c.EmployeeId,
+			Reason:     &c.Reason,
+			Reference:  &reference,
+		})
+		if err != nil {
+			return models.WriteOffView{}, fmt.Errorf("failed to write off UPC %s: %w", line.UPC, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return models.WriteOffView{}, fmt.Errorf("failed to commit write-off: %w", err)
+	}
+
+	return s.GetWriteOff(writeOffID)
+}
+
+func (s *WriteOffService) GetWriteOff(writeOffID int) (models.WriteOffView, error) {
+	writeOff, err := s.repo.RetrieveWriteOffByID(writeOffID)
+	if err != nil {
+		return models.WriteOffView{}, err
+	}
+	views, err := s.withLines([]models.WriteOffRetrieve{writeOff})
+	if err != nil {
+		return models.WriteOffView{}, err
+	}
+	return views[0], nil
+}
+
+// GetWriteOffs lists the write-offs matching f with their lines.
+func (s *WriteOffService) GetWriteOffs(f models.WriteOffFilter) ([]models.WriteOffView, error) {
+	writeOffs, err := s.repo.RetrieveWriteOffs(f)
+	if err != nil {
+		return nil, err
+	}
+	return s.withLines(writeOffs)
+}
+
+func (s *WriteOffService) withLines(writeOffs []models.WriteOffRetrieve) ([]models.WriteOffView, error) {
+	views, err := withLines(writeOffs,
+		func(w models.WriteOffRetrieve) int { return w.WriteOffID },
+		func(l models.WriteOffLine) int { return l.WriteOffID },
+		s.repo.RetrieveWriteOffLines,
+		func(w models.WriteOffRetrieve, lines []models.WriteOffLine) models.WriteOffView {
+			return models.WriteOffView{WriteOffRetrieve: w, Lines: lines}
+		},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve write-off lines: %w", err)
+	}
+	return views, nil
+}
+
+// GetWriteOffReport sums the write-offs of the month starting at month by
+// category and reason, with the month's totals.
+func (s *WriteOffService) GetWriteOffReport(month time.Time) (models.WriteOffReport, error) {
+	rows, err := s.repo.RetrieveWriteOffReport(month, month.AddDate(0, 1, 0))
+	if err != nil {
+		return models.WriteOffReport{}, err
+	}
+
+	report := models.WriteOffReport{
+		Month: month.Format("2006-01"),
+		Rows:  rows,
+	}
+	if report.Rows == nil {
+		report.Rows = []models.WriteOffReportRow{}
+	}
+	for _, row := range rows {
+		report.Quantity += row.Quantity
+		report.Value = report.Value.Add(row.Value)
+	}
+	return report, nil
+}
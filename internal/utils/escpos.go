@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// cp866Fallbacks normalises line endings and maps the Ukrainian letters that
+// CP866 lacks to look-alikes.
+var cp866Fallbacks = strings.NewReplacer(
+	"\r\n", "\n",
+	"І", "I",
+	"і", "i",
+	"Ґ", "Г",
+	"ґ", "г",
+)
+
+// ESC/POS control sequences used for till receipts.
+var (
+	escposInit    = []byte{0x1B, 0x40}
+	escposFeedCut = []byte{0x1D, 0x56, 0x42, 0x03}
+)
+
+// EncodeESCPOS turns a rendered receipt into a printer byte stream: printer
+// reset, code page selection, the text in CP866 so that Cyrillic prints, and
+// a feed with a partial cut. codePage is the printer's table number for PC866
+// (17 on most Epson-compatible printers). Characters CP866 cannot represent
+// are replaced rather than failing the whole receipt.
+func EncodeESCPOS(text string, codePage byte) ([]byte, error) {
+	encoder := encoding.ReplaceUnsupported(charmap.CodePage866.NewEncoder())
+	encoded, err := encoder.String(cp866Fallbacks.Replace(text))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode receipt text: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(escposInit)
+	buf.Write([]byte{0x1B, 0x74, codePage})
+	buf.WriteString(encoded)
+	if !strings.HasSuffix(encoded, "\n") {
+		buf.WriteByte('\n')
+	}
+	buf.Write(escposFeedCut)
+
+	return buf.Bytes(), nil
+}
@@ -3,8 +3,9 @@ package utils
 import (
 	"crypto/rand"
 	"fmt"
-	"strconv"
-	"strings"
+
+	"github.com/shopspring/decimal"
+	"github.com/velosypedno/zlagoda/internal/models"
 )
 
 func GenerateUPC(length int) (string, error) {
@@ -35,21 +36,12 @@ func GenerateID(length int) (string, error) {
 	return string(b), nil
 }
 
-func IsDecimalValid(value float64) bool {
-	if value < 0 {
+// IsDecimalValid reports whether value is a non-negative amount that fits a
+// DECIMAL(13,4) column: at most nine digits before the point and four after.
+func IsDecimalValid(value models.Money) bool {
+	d := value.Decimal()
+	if d.IsNegative() {
 		return false
 	}
-
-	var valueStr string = strconv.FormatFloat(value, 'f', -1, 64)
-
-	var parts []string = strings.Split(valueStr, ".")
-	var beforeDecimal int = len(parts[0])
-	var afterDecimal int = 0
-
-	if len(parts) > 1 {
-		decimalPart := strings.TrimRight(parts[1], "0")
-		afterDecimal = len(decimalPart)
-	}
-
-	return beforeDecimal <= 13 && afterDecimal <= 4
+	return d.Equal(d.Truncate(4)) && d.LessThan(decimal.New(1, 9))
 }
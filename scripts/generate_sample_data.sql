@@ -304,7 +304,10 @@ INSERT INTO receipt (receipt_number, employee_id, card_number, print_date, sum_t
 -- Comprehensive sales data designed for individual query testing
 
 -- VIP Customer 1 (CRD0000000001) - Buys from ALL 10 categories in recent month
-INSERT INTO sale (upc, receipt_number, product_number, selling_price) VALUES
+-- Sample receipts were priced at a single 20% rate
+INSERT INTO sale (upc, receipt_number, product_number, selling_price, vat_rate, vat_sum)
+SELECT v.upc, v.receipt_number, v.product_number, v.selling_price, 0.2, v.product_number * v.selling_price * 0.2
+FROM (VALUES
 -- Electronics
 ('100000000001', 'RCP0000001', 1, 899.99),    -- Samsung Galaxy S23
 ('100000000002', 'RCP0000001', 1, 349.99),    -- Sony Headphones
@@ -445,7 +448,8 @@ INSERT INTO sale (upc, receipt_number, product_number, selling_price) VALUES
 
 -- RCP0000045 - Automotive and Office Supplies
 ('900000000001', 'RCP0000045', 2, 34.99),     -- Motor oil - Automotive
-('101000000001', 'RCP0000045', 3, 29.99);     -- Wireless mouse - Office
+('101000000001', 'RCP0000045', 3, 29.99)      -- Wireless mouse - Office
+) AS v(upc, receipt_number, product_number, selling_price);
 
 -- NOTE: Products with UPCs ending in 099 will NEVER be sold (for Arthur2)
 -- These are the unsold products: 100000000099, 200000000099, etc.